@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"expvar"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 	"syscall"
 	"time"
 
+	"cyrene/internal/cobblemon"
 	"cyrene/internal/ingest"
 	"cyrene/internal/platform/chatstore"
 	"cyrene/internal/platform/config"
@@ -29,6 +31,9 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 )
 
+// memoryBrokerPartitions is the partition count of topics when KAFKA_MODE=memory.
+const memoryBrokerPartitions = 3
+
 // @title           Cyrene API
 // @version         1.0
 // @description     RAG Agent for Pokemon data - indexes Pokemon from Kafka events into Qdrant, answers queries using retrieved context.
@@ -36,6 +41,11 @@ import (
 // @host            localhost:8080
 // @BasePath        /
 
+// @securityDefinitions.apikey  AdminKey
+// @in                          header
+// @name                        Authorization
+// @description                 "Bearer " followed by ADMIN_API_KEY
+
 func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
@@ -63,14 +73,23 @@ func main() {
 		}
 	}(qdrantClient)
 
-	if err := qdrantClient.EnsureCollection(ctx, cfg.Qdrant.Collection, uint64(cfg.Qdrant.CollectionDim)); err != nil {
-		log.Fatalf("failed to ensure qdrant collection: %v", err)
+	if err := qdrantClient.EnsureAlias(ctx, cfg.Qdrant.Collection, uint64(cfg.Qdrant.CollectionDim)); err != nil {
+		log.Fatalf("failed to ensure qdrant collection alias: %v", err)
 	}
 	if err := qdrantClient.EnsureCollection(ctx, cfg.Qdrant.CacheCollection, uint64(cfg.Qdrant.CacheCollectionDim)); err != nil {
 		log.Fatalf("failed to ensure qdrant cache collection: %v", err)
 	}
 
-	if err := kafka.EnsureTopics(ctx, cfg.Kafka.Brokers, []string{string(ingest.TopicIngestion)}); err != nil {
+	var broker *kafka.MemoryBroker
+	if cfg.Kafka.Mode == kafka.ModeMemory {
+		log.Printf("using in-memory kafka broker")
+		broker = kafka.NewMemoryBroker(memoryBrokerPartitions)
+	} else if err := kafka.EnsureTopics(ctx, cfg.Kafka.Brokers, []string{
+		string(ingest.TopicIngestion),
+		kafka.DeadLetterTopic(string(ingest.TopicIngestion)),
+		string(ingest.TopicIngestionCompleted),
+		string(ingest.TopicIngestionFailed),
+	}); err != nil {
 		log.Fatalf("failed to ensure kafka topics: %v", err)
 	}
 
@@ -85,38 +104,76 @@ func main() {
 	}
 	defer redisClient.Client.Close()
 
+	datapack := cobblemon.NewDatapack()
+	if cfg.Cobblemon.DatapackPath != "" {
+		datapack, err = cobblemon.Load(cfg.Cobblemon.DatapackPath)
+		if err != nil {
+			log.Fatalf("failed to load cobblemon datapack: %v", err)
+		}
+	}
+
 	chatStore := chatstore.NewChatStore(redisClient, cfg.ChatStore.MaxMessages, time.Duration(cfg.ChatStore.TTLMinutes)*time.Minute)
 
 	// Services
 	vectorStore := vectorstore.NewQdrantStore(qdrantClient, cfg.Qdrant.Collection, int(cfg.Qdrant.CollectionDim))
 	cacheStore := vectorstore.NewQdrantStore(qdrantClient, cfg.Qdrant.CacheCollection, int(cfg.Qdrant.CacheCollectionDim))
 	pokemonSvc := pokemon.NewService(cfg.PokemonAPI)
-	ragSvc := rag.NewService(genkitClients, pokemonSvc, vectorStore, cacheStore, chatStore)
+	ragSvc := rag.NewService(genkitClients, pokemonSvc, vectorStore, cacheStore, chatStore, cfg.ChatStore.HistoryTokens, rag.NewTurnRepository(pgDB.DB()))
 	ingestRepo := ingest.NewRepository(pgDB.DB())
-	ingestSvc := ingest.NewService(ragSvc, vectorStore, pokemonSvc, ingestRepo)
+
+	var producer kafka.Producer
+	if broker != nil {
+		producer = broker.Producer()
+	} else {
+		producer, err = kafka.NewProducer(&cfg.Kafka)
+		if err != nil {
+			log.Fatalf("failed to create kafka producer: %v", err)
+		}
+	}
+	defer producer.Close()
+
+	ingestSvc := ingest.NewService(ragSvc, vectorStore, pokemonSvc, datapack, ingestRepo, producer, ragSvc)
 
 	// Handlers
 	ingestHandler := ingest.NewHandler(ingestSvc)
-	ragHandler := rag.NewHandler(ragSvc)
+	ragHandler := rag.NewHandler(ragSvc, cfg.Server.AdminAPIKey)
 
 	// Kafka consumer
-	consumer, err := kafka.NewConsumer(&cfg.Kafka, map[string]kafka.Handler{
+	handlers := map[string]kafka.Handler{
 		string(ingest.TopicIngestion): ingestHandler.HandleKafka,
-	})
-	if err != nil {
-		log.Fatalf("failed to create kafka consumer: %v", err)
+	}
+	var consumer kafka.Consumer
+	if broker != nil {
+		consumer = broker.NewConsumer(&cfg.Kafka, handlers)
+	} else {
+		consumer, err = kafka.NewConsumer(&cfg.Kafka, handlers)
+		if err != nil {
+			log.Fatalf("failed to create kafka consumer: %v", err)
+		}
 	}
 
 	go func() {
 		if err := consumer.Run(ctx); err != nil {
-			log.Printf("kafka consumer error: %v", err)
+			log.Printf("kafka consumer stopped: %v", err)
 		}
 	}()
+	expvar.Publish("kafka_consumer", expvar.Func(func() any { return consumer.Status() }))
+
+	if cfg.Ingest.ReconcileIntervalMinutes > 0 {
+		interval := time.Duration(cfg.Ingest.ReconcileIntervalMinutes) * time.Minute
+		go ingestSvc.ReconcileEvery(ctx, interval, cfg.Ingest.ReconcileRepair)
+	}
+
+	if cfg.Ingest.OutboxIntervalSeconds > 0 {
+		interval := time.Duration(cfg.Ingest.OutboxIntervalSeconds) * time.Second
+		go ingestSvc.RunOutboxDispatcher(ctx, interval)
+	}
 
 	// Build routes
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /{$}", handleHello)
-	mux.HandleFunc("GET /health", handleHealth)
+	mux.HandleFunc("GET /health/{$}", handleHealth(consumer))
+	mux.Handle("GET /metrics/{$}", expvar.Handler())
 	mux.Handle("/ingest/", http.StripPrefix("/ingest", ingestHandler.RegisterRoutes()))
 	mux.Handle("/chat/", http.StripPrefix("/chat", ragHandler.RegisterRoutes()))
 	mux.Handle("GET /swagger/", httpSwagger.Handler())
@@ -127,7 +184,7 @@ func main() {
 
 	// Graceful shutdown
 	done := make(chan bool, 1)
-	go gracefulShutdown(ctx, srv, done)
+	go gracefulShutdown(ctx, srv, consumer, done)
 
 	log.Printf("Server starting on %s", cfg.Server.Addr)
 	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -138,7 +195,7 @@ func main() {
 	log.Println("Graceful shutdown complete.")
 }
 
-func gracefulShutdown(ctx context.Context, apiServer *http.Server, done chan bool) {
+func gracefulShutdown(ctx context.Context, apiServer *http.Server, consumer kafka.Consumer, done chan bool) {
 	// Listen for the interrupt signal.
 	<-ctx.Done()
 
@@ -152,6 +209,12 @@ func gracefulShutdown(ctx context.Context, apiServer *http.Server, done chan boo
 		log.Printf("Server forced to shutdown with error: %v", err)
 	}
 
+	// The consumer stops polling on the same signal; wait for in-flight records
+	// to be handled and committed before leaving the consumer group.
+	if err := consumer.Close(shutdownCtx); err != nil {
+		log.Printf("Kafka consumer forced to close: %v", err)
+	}
+
 	log.Println("Server exiting")
 
 	// Notify the main goroutine that the shutdown is complete
@@ -163,7 +226,21 @@ func handleHello(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"message": "Hello World"})
 }
 
-func handleHealth(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+// handleHealth reports "ok" while the Kafka consumer is making progress, and
+// "degraded" with a 503 once it has failed or stalled.
+func handleHealth(consumer kafka.Consumer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := consumer.Status()
+
+		health := map[string]any{"status": "ok", "kafka_consumer": status}
+		code := http.StatusOK
+		if !status.Healthy() {
+			health["status"] = "degraded"
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(health)
+	}
 }
@@ -0,0 +1,182 @@
+// Command reindex re-embeds ingested documents into the vector store.
+//
+// By default it re-embeds, in place, every document whose vectors were produced by a
+// different embedding model or dimension than the current configuration. A dimension
+// change cannot be applied in place and needs -rebuild.
+// With -rebuild it builds every document into a new versioned collection, validates it
+// against ingested_documents and atomically points the QDRANT_COLLECTION alias at it.
+// Previous collections are kept; -versions lists them and -rollback points the alias back.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os/signal"
+	"slices"
+	"sort"
+	"syscall"
+
+	"cyrene/internal/cobblemon"
+	"cyrene/internal/ingest"
+	"cyrene/internal/platform/config"
+	"cyrene/internal/platform/genkit"
+	"cyrene/internal/platform/kafka"
+	"cyrene/internal/platform/postgres"
+	"cyrene/internal/platform/qdrant"
+	"cyrene/internal/platform/vectorstore"
+	"cyrene/internal/pokemon"
+	"cyrene/internal/rag"
+)
+
+func main() {
+	rebuild := flag.Bool("rebuild", false, "build all documents into a new collection and swap the alias to it")
+	rollback := flag.String("rollback", "", "point the alias at an existing versioned collection")
+	versions := flag.Bool("versions", false, "list versioned collections and the one the alias points to")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	config.Load()
+	cfg := config.Get()
+
+	pgDB := postgres.New()
+	defer func(pgDB *postgres.PostgresDB) {
+		err := pgDB.Close()
+		if err != nil {
+			log.Printf("failed to close postgres: %v", err)
+		}
+	}(pgDB)
+
+	qdrantClient, err := qdrant.New(&cfg.Qdrant)
+	if err != nil {
+		log.Fatalf("failed to create qdrant client: %v", err)
+	}
+	defer func(qdrantClient *qdrant.Client) {
+		err := qdrantClient.Close()
+		if err != nil {
+			log.Printf("failed to close qdrant: %v", err)
+		}
+	}(qdrantClient)
+
+	alias := cfg.Qdrant.Collection
+
+	switch {
+	case *versions:
+		listVersions(ctx, qdrantClient, alias)
+		return
+	case *rollback != "":
+		versions, err := qdrantClient.Versions(ctx, alias)
+		if err != nil {
+			log.Fatalf("failed to list versions: %v", err)
+		}
+		if !slices.Contains(versions, *rollback) {
+			log.Fatalf("cannot roll back: %s is not a version of %s; see -versions", *rollback, alias)
+		}
+		if err := qdrantClient.SwapAlias(ctx, alias, *rollback); err != nil {
+			log.Fatalf("failed to roll back: %v", err)
+		}
+		log.Printf("alias %s now points to %s", alias, *rollback)
+		return
+	}
+
+	if err := qdrantClient.EnsureAlias(ctx, alias, uint64(cfg.Qdrant.CollectionDim)); err != nil {
+		log.Fatalf("failed to ensure qdrant collection alias: %v", err)
+	}
+
+	genkitClients, err := genkit.New(ctx, &cfg.Genkit)
+	if err != nil {
+		log.Fatalf("failed to create genkit clients: %v", err)
+	}
+
+	datapack := cobblemon.NewDatapack()
+	if cfg.Cobblemon.DatapackPath != "" {
+		datapack, err = cobblemon.Load(cfg.Cobblemon.DatapackPath)
+		if err != nil {
+			log.Fatalf("failed to load cobblemon datapack: %v", err)
+		}
+	}
+
+	// With KAFKA_MODE=memory the broker lives inside the API process, so the
+	// ingestion results of a reindex have no consumers and are kept in memory only.
+	var producer kafka.Producer
+	if cfg.Kafka.Mode == kafka.ModeMemory {
+		producer = kafka.NewMemoryBroker(1).Producer()
+	} else {
+		producer, err = kafka.NewProducer(&cfg.Kafka)
+		if err != nil {
+			log.Fatalf("failed to create kafka producer: %v", err)
+		}
+	}
+	defer producer.Close()
+
+	collection := alias
+	if *rebuild {
+		if target, err := qdrantClient.AliasTarget(ctx, alias); err != nil || target == "" {
+			log.Fatalf("cannot rebuild: %s is not an alias (%v); delete or rename the collection first", alias, err)
+		}
+		collection, err = qdrantClient.CreateVersionedCollection(ctx, alias, uint64(cfg.Qdrant.CollectionDim))
+		if err != nil {
+			log.Fatalf("failed to create collection: %v", err)
+		}
+	}
+
+	vectorStore := vectorstore.NewQdrantStore(qdrantClient, collection, int(cfg.Qdrant.CollectionDim))
+	pokemonSvc := pokemon.NewService(cfg.PokemonAPI)
+	// Reindexing only embeds, so the chat and answer cache stores are not needed.
+	ragSvc := rag.NewService(genkitClients, pokemonSvc, vectorStore, nil, nil, 0, nil)
+	ingestSvc := ingest.NewService(ragSvc, vectorStore, pokemonSvc, datapack, ingest.NewRepository(pgDB.DB()), producer, ragSvc)
+
+	var result *ingest.BatchResult
+	if *rebuild {
+		log.Printf("rebuilding all documents into %s with %s at %d dimensions", collection, ragSvc.EmbedModel(), vectorStore.Dimensions())
+		result, err = ingestSvc.Rebuild(ctx)
+	} else {
+		log.Printf("reindexing documents not embedded with %s at %d dimensions", ragSvc.EmbedModel(), vectorStore.Dimensions())
+		result, err = ingestSvc.Reindex(ctx)
+	}
+	if result != nil {
+		refs := make([]string, 0, len(result.Failed))
+		for ref := range result.Failed {
+			refs = append(refs, ref)
+		}
+		sort.Strings(refs)
+		for _, ref := range refs {
+			log.Printf("failed to reindex %s: %s", ref, result.Failed[ref])
+		}
+		log.Printf("reindexed %d of %d stale documents (%d failed)", result.Ingested+result.Skipped, result.Requested, len(result.Failed))
+	}
+	if err != nil {
+		if *rebuild {
+			log.Fatalf("rebuild: %v; %s was kept for inspection and the alias was not changed", err, collection)
+		}
+		log.Fatalf("reindex: %v", err)
+	}
+
+	if *rebuild {
+		if err := qdrantClient.SwapAlias(ctx, alias, collection); err != nil {
+			log.Fatalf("failed to swap alias: %v", err)
+		}
+		log.Printf("alias %s now points to %s", alias, collection)
+	}
+}
+
+func listVersions(ctx context.Context, client *qdrant.Client, alias string) {
+	target, err := client.AliasTarget(ctx, alias)
+	if err != nil {
+		log.Fatalf("failed to resolve alias: %v", err)
+	}
+	versions, err := client.Versions(ctx, alias)
+	if err != nil {
+		log.Fatalf("failed to list collections: %v", err)
+	}
+
+	for _, name := range versions {
+		marker := " "
+		if name == target {
+			marker = "*"
+		}
+		log.Printf("%s %s", marker, name)
+	}
+}
@@ -0,0 +1,36 @@
+// Command replay-dlq publishes the records of a dead-letter topic back to the topics
+// they originally failed on, e.g. after the cause of the failures has been fixed.
+// Records that fail again are dead-lettered again by the API's consumer.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os/signal"
+	"syscall"
+
+	"cyrene/internal/ingest"
+	"cyrene/internal/platform/config"
+	"cyrene/internal/platform/kafka"
+)
+
+func main() {
+	topic := flag.String("topic", kafka.DeadLetterTopic(string(ingest.TopicIngestion)), "dead-letter topic to replay")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	config.Load()
+	cfg := config.Get()
+	if cfg.Kafka.Mode == kafka.ModeMemory {
+		log.Fatalf("KAFKA_MODE=memory keeps dead letters inside the API process; there is nothing to replay")
+	}
+
+	replayed, err := kafka.ReplayDeadLetters(ctx, &cfg.Kafka, *topic)
+	if err != nil {
+		log.Fatalf("replay %s failed after %d records: %v", *topic, replayed, err)
+	}
+	log.Printf("replayed %d records from %s", replayed, *topic)
+}
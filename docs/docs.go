@@ -47,7 +47,400 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "invalid request body / message is required / user is required",
+                        "description": "invalid request body / message is required / user is required / invalid conversation id",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/chat/conversations": {
+            "get": {
+                "description": "List the user's conversations that have not expired, most recently updated first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "chat"
+                ],
+                "summary": "List conversations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User",
+                        "name": "user",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/rag.Conversation"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "user is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/chat/conversations/{id}": {
+            "get": {
+                "description": "Return a conversation of the user with its prompts and answers",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "chat"
+                ],
+                "summary": "Get conversation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Conversation ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User",
+                        "name": "user",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rag.ConversationDetail"
+                        }
+                    },
+                    "400": {
+                        "description": "user is required / invalid conversation id",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "conversation not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a conversation of the user and its messages",
+                "tags": [
+                    "chat"
+                ],
+                "summary": "Delete conversation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Conversation ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User",
+                        "name": "user",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "user is required / invalid conversation id",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "conversation not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Set the title of a conversation of the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "chat"
+                ],
+                "summary": "Rename conversation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Conversation ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User",
+                        "name": "user",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "New title",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rag.RenameConversationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid request body / title is required / user is required / invalid conversation id",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "conversation not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/chat/stream/": {
+            "post": {
+                "description": "Answer like /chat/ as Server-Sent Events: \"token\" events carry answer text as it is generated, \"tool_start\" and \"tool_end\" bracket tool calls, and a final \"answer\" or \"error\" event ends the stream",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "chat"
+                ],
+                "summary": "Stream a chat answer",
+                "parameters": [
+                    {
+                        "description": "Chat request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rag.ChatRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rag.ChatEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request body / message is required / user is required / invalid conversation id",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/chat/turns": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Page through the recorded transcripts of chat requests for review, oldest first, optionally filtered by user, conversation and creation time. Pass the returned next cursor as after to fetch the following page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "chat"
+                ],
+                "summary": "List chat turns",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Conversation ID",
+                        "name": "conversation_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only turns created after this RFC 3339 time",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only turns created before this RFC 3339 time",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the previous page",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rag.TurnPage"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "invalid admin key",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "admin endpoints are disabled",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/chat/turns/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Return the recorded transcript of one chat request",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "chat"
+                ],
+                "summary": "Get chat turn",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Turn ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rag.ChatTurn"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid turn id",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "invalid admin key",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "admin endpoints are disabled",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "chat turn not found",
                         "schema": {
                             "type": "string"
                         }
@@ -63,7 +456,56 @@ const docTemplate = `{
         },
         "/ingest/": {
             "post": {
-                "description": "Index a Pokemon or Move document into the vector store",
+                "description": "Queue a Pokemon, Move, Ability, Item, Type, Evolution Chain, Cobblemon Species or Spawn document for indexing and return the job ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ingest"
+                ],
+                "summary": "Ingest document",
+                "parameters": [
+                    {
+                        "description": "Ingestion event",
+                        "name": "event",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ingest.IngestionEvent"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request body",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/ingest/bulk": {
+            "post": {
+                "description": "Queue many documents of one type, given as IDs and/or ranges like \"1-151\", as a single job",
                 "consumes": [
                     "application/json"
                 ],
@@ -73,70 +515,660 @@ const docTemplate = `{
                 "tags": [
                     "ingest"
                 ],
-                "summary": "Ingest document",
+                "summary": "Bulk ingest documents",
                 "parameters": [
                     {
-                        "description": "Ingestion event",
-                        "name": "event",
+                        "description": "Batch ingestion event",
+                        "name": "batch",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/ingest.IngestionEvent"
+                            "$ref": "#/definitions/ingest.BatchIngestionEvent"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/ingest.IngestionJob"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request body",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/ingest/datapack": {
+            "post": {
+                "description": "Queue every species and spawn pool from the configured Cobblemon datapack as a single job",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ingest"
+                ],
+                "summary": "Import Cobblemon datapack",
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/ingest.IngestionJob"
+                        }
+                    },
+                    "400": {
+                        "description": "datapack is empty",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/ingest/documents": {
+            "get": {
+                "description": "Page through ingested documents, optionally filtered by type and created/updated time ranges. Pass the returned next cursor as after to fetch the following page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ingest"
+                ],
+                "summary": "List ingested documents",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only documents created after this RFC 3339 time",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only documents created before this RFC 3339 time",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only documents updated after this RFC 3339 time",
+                        "name": "updated_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only documents updated before this RFC 3339 time",
+                        "name": "updated_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the previous page",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ingest.DocumentPage"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/ingest/documents/{type}/{id}": {
+            "get": {
+                "description": "Return the ingested document record, its stored embedding text and its vector point IDs",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ingest"
+                ],
+                "summary": "Get ingested document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ingest.DocumentDetail"
+                        }
+                    },
+                    "400": {
+                        "description": "unsupported document type",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "document not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "type": "string"
                         }
                     }
-                ],
-                "responses": {
-                    "202": {
-                        "description": "Accepted",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "invalid request body",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "500": {
-                        "description": "internal server error",
-                        "schema": {
-                            "type": "string"
-                        }
+                }
+            }
+        },
+        "/ingest/jobs/{id}": {
+            "get": {
+                "description": "Return the overall and per-document status of an ingestion job",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ingest"
+                ],
+                "summary": "Get ingestion job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ingest.IngestionJob"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid job id",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "job not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/ingest/reconcile": {
+            "get": {
+                "description": "Compare the vector store with ingested documents and report drift. A POST also repairs it by re-ingesting documents without vectors and deleting orphan points.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ingest"
+                ],
+                "summary": "Reconcile vector store",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ingest.DriftReport"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Compare the vector store with ingested documents and report drift. A POST also repairs it by re-ingesting documents without vectors and deleting orphan points.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ingest"
+                ],
+                "summary": "Reconcile vector store",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ingest.DriftReport"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/ingest/{type}/{id}": {
+            "delete": {
+                "description": "Remove a document from ingested_documents and the vector store, and invalidate cached answers built from it",
+                "tags": [
+                    "ingest"
+                ],
+                "summary": "Delete document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "unsupported document type",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "ingest.BatchIngestionEvent": {
+            "type": "object",
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "job_id": {
+                    "type": "string"
+                },
+                "ranges": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "$ref": "#/definitions/ingest.DocumentType"
+                }
+            }
+        },
+        "ingest.BatchResult": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "ingested": {
+                    "type": "integer"
+                },
+                "requested": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                }
+            }
+        },
+        "ingest.DocumentChunk": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "point_id": {
+                    "type": "string"
+                },
+                "section": {
+                    "type": "string"
+                }
+            }
+        },
+        "ingest.DocumentDetail": {
+            "type": "object",
+            "properties": {
+                "chunks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ingest.DocumentChunk"
+                    }
+                },
+                "content": {
+                    "type": "string"
+                },
+                "content_hash": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "embedding_dim": {
+                    "type": "integer"
+                },
+                "embedding_model": {
+                    "type": "string"
+                },
+                "external_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "point_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     }
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/ingest.DocumentType"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
-        }
-    },
-    "definitions": {
+        },
+        "ingest.DocumentPage": {
+            "type": "object",
+            "properties": {
+                "documents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ingest.IngestedDocument"
+                    }
+                },
+                "next": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
         "ingest.DocumentType": {
             "type": "string",
             "enum": [
                 "pokemon",
-                "move"
+                "move",
+                "ability",
+                "item",
+                "type",
+                "evolution_chain",
+                "cobblemon_species",
+                "spawn"
             ],
             "x-enum-varnames": [
                 "DocumentTypePokemon",
-                "DocumentTypeMove"
+                "DocumentTypeMove",
+                "DocumentTypeAbility",
+                "DocumentTypeItem",
+                "DocumentTypeType",
+                "DocumentTypeEvolutionChain",
+                "DocumentTypeCobblemonSpecies",
+                "DocumentTypeSpawn"
+            ]
+        },
+        "ingest.DriftReport": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer"
+                },
+                "documents": {
+                    "type": "integer"
+                },
+                "missing": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "orphan_points": {
+                    "type": "integer"
+                },
+                "orphans": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "references": {
+                    "type": "integer"
+                },
+                "repair": {
+                    "$ref": "#/definitions/ingest.BatchResult"
+                }
+            }
+        },
+        "ingest.EventAction": {
+            "type": "string",
+            "enum": [
+                "ingest",
+                "delete"
+            ],
+            "x-enum-varnames": [
+                "ActionIngest",
+                "ActionDelete"
             ]
         },
+        "ingest.IngestedDocument": {
+            "type": "object",
+            "properties": {
+                "content_hash": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "embedding_dim": {
+                    "type": "integer"
+                },
+                "embedding_model": {
+                    "type": "string"
+                },
+                "external_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/ingest.DocumentType"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
         "ingest.IngestionEvent": {
             "type": "object",
             "properties": {
+                "action": {
+                    "$ref": "#/definitions/ingest.EventAction"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "job_id": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/ingest.DocumentType"
+                }
+            }
+        },
+        "ingest.IngestionJob": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "documents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ingest.JobDocument"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/ingest.JobStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "ingest.JobDocument": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
+                "status": {
+                    "$ref": "#/definitions/ingest.JobStatus"
+                },
                 "type": {
                     "$ref": "#/definitions/ingest.DocumentType"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "ingest.JobStatus": {
+            "type": "string",
+            "enum": [
+                "queued",
+                "running",
+                "retrying",
+                "succeeded",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "JobStatusQueued",
+                "JobStatusRunning",
+                "JobStatusRetrying",
+                "JobStatusSucceeded",
+                "JobStatusFailed"
+            ]
+        },
+        "rag.ChatEvent": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "input": {},
+                "text": {
+                    "type": "string"
+                },
+                "tool": {
+                    "type": "string"
                 }
             }
         },
         "rag.ChatRequest": {
             "type": "object",
             "properties": {
+                "conversation_id": {
+                    "type": "string"
+                },
                 "message": {
                     "type": "string"
                 },
@@ -148,10 +1180,168 @@ const docTemplate = `{
         "rag.ChatResponse": {
             "type": "object",
             "properties": {
+                "conversation_id": {
+                    "type": "string"
+                },
                 "response": {
                     "type": "string"
                 }
             }
+        },
+        "rag.ChatTurn": {
+            "type": "object",
+            "properties": {
+                "answer": {
+                    "type": "string"
+                },
+                "cache_hit": {
+                    "type": "boolean"
+                },
+                "conversation_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "prompt": {
+                    "type": "string"
+                },
+                "rejected": {
+                    "type": "boolean"
+                },
+                "rewritten_prompt": {
+                    "type": "string"
+                },
+                "tool_calls": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rag.ToolCall"
+                    }
+                },
+                "user": {
+                    "type": "string"
+                }
+            }
+        },
+        "rag.Conversation": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "message_count": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "rag.ConversationDetail": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "message_count": {
+                    "type": "integer"
+                },
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rag.ConversationMessage"
+                    }
+                },
+                "summary": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "rag.ConversationMessage": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "tools": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "rag.RenameConversationRequest": {
+            "type": "object",
+            "properties": {
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "rag.ToolCall": {
+            "type": "object",
+            "properties": {
+                "input": {},
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "rag.TurnPage": {
+            "type": "object",
+            "properties": {
+                "next": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "turns": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rag.ChatTurn"
+                    }
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "AdminKey": {
+            "description": "\"Bearer \" followed by ADMIN_API_KEY",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
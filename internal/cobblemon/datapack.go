@@ -0,0 +1,172 @@
+package cobblemon
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	speciesDir   = "species"
+	spawnPoolDir = "spawn_pool_world"
+)
+
+// Datapack holds the species and spawn pool definitions read from a Cobblemon datapack.
+type Datapack struct {
+	species    map[string]*Species
+	spawnPools map[string]*SpawnPool
+}
+
+// NewDatapack returns an empty datapack, used when no datapack is configured.
+func NewDatapack() *Datapack {
+	return &Datapack{
+		species:    make(map[string]*Species),
+		spawnPools: make(map[string]*SpawnPool),
+	}
+}
+
+// Load reads a datapack from a directory or a .zip archive.
+// Any JSON file below a species/ or spawn_pool_world/ directory is picked up,
+// so both a bare datapack and a full data/<namespace>/... tree are supported.
+func Load(root string) (*Datapack, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("stat datapack: %w", err)
+	}
+
+	if info.IsDir() {
+		return load(os.DirFS(root))
+	}
+
+	if !strings.EqualFold(path.Ext(root), ".zip") {
+		return nil, fmt.Errorf("datapack %s is neither a directory nor a zip archive", root)
+	}
+
+	archive, err := zip.OpenReader(root)
+	if err != nil {
+		return nil, fmt.Errorf("open datapack zip: %w", err)
+	}
+	defer func(archive *zip.ReadCloser) {
+		_ = archive.Close()
+	}(archive)
+
+	return load(archive)
+}
+
+// load reads the species and spawn pools of fsys. Entries are keyed by file name,
+// so two files of the same kind and name, such as one per namespace or subfolder,
+// are rejected rather than one silently replacing the other.
+func load(fsys fs.FS) (*Datapack, error) {
+	d := NewDatapack()
+	seen := make(map[string]string)
+
+	err := fs.WalkDir(fsys, ".", func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || path.Ext(p) != ".json" {
+			return nil
+		}
+
+		kind := entryKind(p)
+		if kind == "" {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", p, err)
+		}
+
+		var raw map[string]any
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("decode %s: %w", p, err)
+		}
+		rawBytes, _ := json.Marshal(raw)
+
+		id := strings.ToLower(strings.TrimSuffix(path.Base(p), ".json"))
+		if prev, ok := seen[kind+"/"+id]; ok {
+			return fmt.Errorf("%s and %s both define %s %s", prev, p, kind, id)
+		}
+		seen[kind+"/"+id] = p
+
+		switch kind {
+		case speciesDir:
+			name, _ := raw["name"].(string)
+			if name == "" {
+				name = id
+			}
+			d.species[id] = &Species{
+				ID:         id,
+				Identifier: name,
+				RawJSON:    string(rawBytes),
+				Metadata:   raw,
+			}
+		case spawnPoolDir:
+			d.spawnPools[id] = &SpawnPool{
+				ID:       id,
+				RawJSON:  string(rawBytes),
+				Metadata: raw,
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk datapack: %w", err)
+	}
+
+	return d, nil
+}
+
+// entryKind reports which datapack directory a file belongs to, or "" if neither.
+func entryKind(p string) string {
+	for _, segment := range strings.Split(path.Dir(p), "/") {
+		switch segment {
+		case speciesDir, spawnPoolDir:
+			return segment
+		}
+	}
+	return ""
+}
+
+func (d *Datapack) GetSpeciesByID(ctx context.Context, id string) (*Species, error) {
+	s, ok := d.species[strings.ToLower(id)]
+	if !ok {
+		return nil, fmt.Errorf("species %s: %w", id, ErrNotFound)
+	}
+	return s, nil
+}
+
+func (d *Datapack) GetSpawnPoolByID(ctx context.Context, id string) (*SpawnPool, error) {
+	p, ok := d.spawnPools[strings.ToLower(id)]
+	if !ok {
+		return nil, fmt.Errorf("spawn pool %s: %w", id, ErrNotFound)
+	}
+	return p, nil
+}
+
+// SpeciesIDs returns the IDs of all species in the datapack, sorted.
+func (d *Datapack) SpeciesIDs() []string {
+	ids := make([]string, 0, len(d.species))
+	for id := range d.species {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// SpawnPoolIDs returns the IDs of all spawn pools in the datapack, sorted.
+func (d *Datapack) SpawnPoolIDs() []string {
+	ids := make([]string, 0, len(d.spawnPools))
+	for id := range d.spawnPools {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,138 @@
+package cobblemon
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFiles = map[string]string{
+	"data/cobblemon/species/generation1/charizard.json": `{
+		"name": "Charizard",
+		"nationalPokedexNumber": 6,
+		"primaryType": "fire",
+		"secondaryType": "flying",
+		"abilities": ["blaze", "h:solarpower"],
+		"catchRate": 45,
+		"moves": ["1:scratch", "tm:fly"],
+		"drops": {"amount": 1, "entries": [{"item": "minecraft:blaze_powder", "quantityRange": "1-2", "percentage": 50}]}
+	}`,
+	"data/cobblemon/spawn_pool_world/charizard.json": `{
+		"enabled": true,
+		"spawns": [
+			{
+				"id": "charizard-1",
+				"pokemon": "charizard",
+				"bucket": "ultra-rare",
+				"level": "36-50",
+				"weight": 0.6,
+				"condition": {"biomes": ["#cobblemon:is_volcanic"], "canSeeSky": true, "minY": 60}
+			}
+		]
+	}`,
+	"data/cobblemon/species_additions/charizard.json": `{"target": "cobblemon:charizard"}`,
+	"pack.mcmeta": `{"pack": {"pack_format": 15}}`,
+}
+
+func writeTestDir(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range testFiles {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+	return root
+}
+
+func writeTestZip(t *testing.T) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "pack.zip")
+	f, err := os.Create(p)
+	require.NoError(t, err)
+
+	w := zip.NewWriter(f)
+	for name, content := range testFiles {
+		fw, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+	return p
+}
+
+func TestLoad(t *testing.T) {
+	tests := map[string]func(t *testing.T) string{
+		"directory": writeTestDir,
+		"zip":       writeTestZip,
+	}
+
+	for name, setup := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			d, err := Load(setup(t))
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"charizard"}, d.SpeciesIDs())
+			assert.Equal(t, []string{"charizard"}, d.SpawnPoolIDs())
+
+			species, err := d.GetSpeciesByID(ctx, "Charizard")
+			require.NoError(t, err)
+			assert.Equal(t, "Charizard", species.Identifier)
+
+			text := species.EmbeddingText()
+			assert.Contains(t, text, "Cobblemon Species: Charizard (ID: charizard)")
+			assert.Contains(t, text, "Types: fire, flying")
+			assert.Contains(t, text, "Abilities: blaze")
+			assert.Contains(t, text, "Hidden Ability: solarpower")
+			assert.Contains(t, text, "Level-Up Moves: scratch (lv 1)")
+			assert.Contains(t, text, "Drops: minecraft:blaze_powder x1-2 (50%)")
+
+			pool, err := d.GetSpawnPoolByID(ctx, "charizard")
+			require.NoError(t, err)
+
+			text = pool.EmbeddingText()
+			assert.Contains(t, text, "Spawn: charizard (charizard-1)")
+			assert.Contains(t, text, "bucket: ultra-rare, level: 36-50, weight: 0.6")
+			assert.Contains(t, text, "Conditions Biomes: #cobblemon:is_volcanic")
+			assert.Contains(t, text, "Conditions: canSeeSky=true, minY=60")
+		})
+	}
+}
+
+func TestLoad_UnsupportedFile(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "pack.tar")
+	require.NoError(t, os.WriteFile(p, nil, 0o644))
+
+	_, err := Load(p)
+	require.Error(t, err)
+}
+
+func TestLoad_DuplicateIDs(t *testing.T) {
+	root := writeTestDir(t)
+	dup := filepath.Join(root, "data", "addon", "species", "Charizard.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(dup), 0o755))
+	require.NoError(t, os.WriteFile(dup, []byte(`{"name": "Charizard"}`), 0o644))
+
+	_, err := Load(root)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "both define species charizard")
+}
+
+func TestDatapack_NotFound(t *testing.T) {
+	d := NewDatapack()
+
+	_, err := d.GetSpeciesByID(context.Background(), "charizard")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = d.GetSpawnPoolByID(context.Background(), "charizard")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
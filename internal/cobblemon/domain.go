@@ -0,0 +1,312 @@
+package cobblemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var ErrNotFound = errors.New("datapack entry not found")
+
+// Species is a species definition from a datapack's species/ directory.
+// ID is the file name without extension, e.g. "charizard".
+type Species struct {
+	ID         string
+	Identifier string
+	RawJSON    string
+	Metadata   map[string]any
+}
+
+func (s *Species) EmbeddingText() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Cobblemon Species: %s (ID: %s)\n", s.Identifier, s.ID))
+
+	if dex, ok := s.Metadata["nationalPokedexNumber"].(float64); ok {
+		sb.WriteString(fmt.Sprintf("National Pokedex Number: %.0f\n", dex))
+	}
+
+	var types []string
+	for _, key := range []string{"primaryType", "secondaryType"} {
+		if t, ok := s.Metadata[key].(string); ok && t != "" {
+			types = append(types, t)
+		}
+	}
+	if len(types) > 0 {
+		sb.WriteString(fmt.Sprintf("Types: %s\n", strings.Join(types, ", ")))
+	}
+
+	if abilities := stringList(s.Metadata["abilities"]); len(abilities) > 0 {
+		var regular, hidden []string
+		for _, a := range abilities {
+			if name, ok := strings.CutPrefix(a, "h:"); ok {
+				hidden = append(hidden, name)
+			} else {
+				regular = append(regular, a)
+			}
+		}
+		if len(regular) > 0 {
+			sb.WriteString(fmt.Sprintf("Abilities: %s\n", strings.Join(regular, ", ")))
+		}
+		if len(hidden) > 0 {
+			sb.WriteString(fmt.Sprintf("Hidden Ability: %s\n", strings.Join(hidden, ", ")))
+		}
+	}
+
+	if stats, ok := s.Metadata["baseStats"].(map[string]any); ok {
+		var statStrs []string
+		for _, name := range sortedKeys(stats) {
+			if v, ok := stats[name].(float64); ok {
+				statStrs = append(statStrs, fmt.Sprintf("%s: %.0f", name, v))
+			}
+		}
+		if len(statStrs) > 0 {
+			sb.WriteString(fmt.Sprintf("Stats: %s\n", strings.Join(statStrs, ", ")))
+		}
+	}
+
+	if catchRate, ok := s.Metadata["catchRate"].(float64); ok {
+		sb.WriteString(fmt.Sprintf("Catch Rate: %.0f\n", catchRate))
+	}
+	if maleRatio, ok := s.Metadata["maleRatio"].(float64); ok {
+		if maleRatio < 0 {
+			sb.WriteString("Gender: genderless\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("Gender: %.1f%% male\n", maleRatio*100))
+		}
+	}
+	if eggGroups := stringList(s.Metadata["eggGroups"]); len(eggGroups) > 0 {
+		sb.WriteString(fmt.Sprintf("Egg Groups: %s\n", strings.Join(eggGroups, ", ")))
+	}
+	if group, ok := s.Metadata["experienceGroup"].(string); ok && group != "" {
+		sb.WriteString(fmt.Sprintf("Experience Group: %s\n", group))
+	}
+
+	if evolutions, ok := s.Metadata["evolutions"].([]any); ok {
+		var steps []string
+		for _, e := range evolutions {
+			em, ok := e.(map[string]any)
+			if !ok {
+				continue
+			}
+			result, _ := em["result"].(string)
+			if result == "" {
+				continue
+			}
+			step := result
+			var conditions []string
+			if variant, ok := em["variant"].(string); ok && variant != "" {
+				conditions = append(conditions, variant)
+			}
+			if requirements, ok := em["requirements"].([]any); ok {
+				for _, r := range requirements {
+					if rm, ok := r.(map[string]any); ok {
+						conditions = append(conditions, describeFields(rm))
+					}
+				}
+			}
+			if len(conditions) > 0 {
+				step += fmt.Sprintf(" (%s)", strings.Join(conditions, "; "))
+			}
+			steps = append(steps, step)
+		}
+		if len(steps) > 0 {
+			sb.WriteString(fmt.Sprintf("Evolves Into: %s\n", strings.Join(steps, ", ")))
+		}
+	}
+
+	if drops, ok := s.Metadata["drops"].(map[string]any); ok {
+		if entries, ok := drops["entries"].([]any); ok {
+			var dropStrs []string
+			for _, e := range entries {
+				em, ok := e.(map[string]any)
+				if !ok {
+					continue
+				}
+				item, _ := em["item"].(string)
+				if item == "" {
+					continue
+				}
+				drop := item
+				if qty, ok := em["quantityRange"].(string); ok && qty != "" {
+					drop += " x" + qty
+				}
+				if pct, ok := em["percentage"].(float64); ok {
+					drop += fmt.Sprintf(" (%.0f%%)", pct)
+				}
+				dropStrs = append(dropStrs, drop)
+			}
+			if len(dropStrs) > 0 {
+				sb.WriteString(fmt.Sprintf("Drops: %s\n", strings.Join(dropStrs, ", ")))
+			}
+		}
+	}
+
+	if moves := stringList(s.Metadata["moves"]); len(moves) > 0 {
+		var levelUp, other []string
+		for _, m := range moves {
+			source, move, ok := strings.Cut(m, ":")
+			if !ok {
+				other = append(other, m)
+				continue
+			}
+			if isDigits(source) {
+				levelUp = append(levelUp, fmt.Sprintf("%s (lv %s)", move, source))
+			} else {
+				other = append(other, move)
+			}
+		}
+		if len(levelUp) > 0 {
+			sb.WriteString(fmt.Sprintf("Level-Up Moves: %s\n", strings.Join(levelUp, ", ")))
+		}
+		if len(other) > 0 {
+			sb.WriteString(fmt.Sprintf("Other Moves: %s\n", strings.Join(other, ", ")))
+		}
+	}
+
+	if labels := stringList(s.Metadata["labels"]); len(labels) > 0 {
+		sb.WriteString(fmt.Sprintf("Labels: %s\n", strings.Join(labels, ", ")))
+	}
+
+	return sb.String()
+}
+
+func (s *Species) MetadataJSON() string {
+	b, _ := json.Marshal(s.Metadata)
+	return string(b)
+}
+
+// SpawnPool is a spawn pool definition from a datapack's spawn_pool_world/ directory.
+// ID is the file name without extension, e.g. "charizard".
+type SpawnPool struct {
+	ID       string
+	RawJSON  string
+	Metadata map[string]any
+}
+
+func (p *SpawnPool) EmbeddingText() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Spawn Pool: %s\n", p.ID))
+
+	if enabled, ok := p.Metadata["enabled"].(bool); ok && !enabled {
+		sb.WriteString("Enabled: false\n")
+	}
+
+	spawns, _ := p.Metadata["spawns"].([]any)
+	for _, s := range spawns {
+		spawn, ok := s.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		pokemon, _ := spawn["pokemon"].(string)
+		sb.WriteString(fmt.Sprintf("Spawn: %s", pokemon))
+		if id, ok := spawn["id"].(string); ok && id != "" {
+			sb.WriteString(fmt.Sprintf(" (%s)", id))
+		}
+		sb.WriteString("\n")
+
+		var details []string
+		for _, key := range []string{"bucket", "level", "context"} {
+			if v, ok := spawn[key].(string); ok && v != "" {
+				details = append(details, fmt.Sprintf("%s: %s", key, v))
+			}
+		}
+		if weight, ok := spawn["weight"].(float64); ok {
+			details = append(details, fmt.Sprintf("weight: %g", weight))
+		}
+		if presets := stringList(spawn["presets"]); len(presets) > 0 {
+			details = append(details, fmt.Sprintf("presets: %s", strings.Join(presets, ", ")))
+		}
+		if len(details) > 0 {
+			sb.WriteString(fmt.Sprintf("  %s\n", strings.Join(details, ", ")))
+		}
+
+		if condition, ok := spawn["condition"].(map[string]any); ok {
+			writeCondition(&sb, "Conditions", condition)
+		}
+		if anticondition, ok := spawn["anticondition"].(map[string]any); ok {
+			writeCondition(&sb, "Excluded When", anticondition)
+		}
+	}
+
+	return sb.String()
+}
+
+func (p *SpawnPool) MetadataJSON() string {
+	b, _ := json.Marshal(p.Metadata)
+	return string(b)
+}
+
+func writeCondition(sb *strings.Builder, label string, condition map[string]any) {
+	if biomes := stringList(condition["biomes"]); len(biomes) > 0 {
+		sb.WriteString(fmt.Sprintf("  %s Biomes: %s\n", label, strings.Join(biomes, ", ")))
+	}
+
+	rest := make(map[string]any, len(condition))
+	for k, v := range condition {
+		if k != "biomes" {
+			rest[k] = v
+		}
+	}
+	if len(rest) > 0 {
+		sb.WriteString(fmt.Sprintf("  %s: %s\n", label, describeFields(rest)))
+	}
+}
+
+// describeFields renders a flat JSON object as "key=value" pairs in key order.
+func describeFields(m map[string]any) string {
+	var parts []string
+	for _, k := range sortedKeys(m) {
+		switch v := m[k].(type) {
+		case string:
+			parts = append(parts, fmt.Sprintf("%s=%s", k, v))
+		case float64, bool:
+			parts = append(parts, fmt.Sprintf("%s=%v", k, v))
+		case []any:
+			parts = append(parts, fmt.Sprintf("%s=%s", k, strings.Join(stringList(v), "|")))
+		default:
+			b, _ := json.Marshal(v)
+			parts = append(parts, fmt.Sprintf("%s=%s", k, b))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func stringList(v any) []string {
+	list, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+	var out []string
+	for _, item := range list {
+		if s, ok := item.(string); ok && s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package ingest
+
+import (
+	"strings"
+)
+
+// maxChunkLen is the length in bytes above which a section is split into
+// several chunks, so a long move list does not drown out the rest of a section.
+const maxChunkLen = 1200
+
+// Sections a document's embedding text is split into. Documents without any of
+// the labelled lines below, such as moves and items, form a single summary chunk.
+const (
+	sectionSummary   = "summary"
+	sectionStats     = "stats"
+	sectionAbilities = "abilities"
+	sectionMoves     = "moves"
+	sectionLore      = "lore"
+)
+
+// sectionLabels maps the label of an embedding text line to the section it starts.
+// Unlabelled lines, such as the species details following "Species", stay in the
+// section of the line before them.
+var sectionLabels = map[string]string{
+	"Types":     sectionStats,
+	"Stats":     sectionStats,
+	"Height":    sectionStats,
+	"Weight":    sectionStats,
+	"Abilities": sectionAbilities,
+	"Moves":     sectionMoves,
+	"Species":   sectionLore,
+}
+
+// chunk is a separately embedded part of a document.
+type chunk struct {
+	section string
+	text    string
+}
+
+// chunkText splits an embedding text into section chunks. The first line names
+// the document and is repeated at the top of every chunk so each one can be
+// matched and understood on its own.
+func chunkText(text string) []chunk {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	header, lines := lines[0], lines[1:]
+
+	var order []string
+	bySection := make(map[string][]string)
+	section := sectionSummary
+	for _, line := range lines {
+		label, _, _ := strings.Cut(line, ":")
+		if next, ok := sectionLabels[label]; ok {
+			section = next
+		}
+		if _, ok := bySection[section]; !ok {
+			order = append(order, section)
+		}
+		bySection[section] = append(bySection[section], line)
+	}
+	if len(order) == 0 {
+		return []chunk{{section: sectionSummary, text: text}}
+	}
+
+	var chunks []chunk
+	for _, section := range order {
+		for _, body := range splitLines(bySection[section], maxChunkLen-len(header)-1) {
+			chunks = append(chunks, chunk{section: section, text: header + "\n" + body})
+		}
+	}
+	return chunks
+}
+
+// splitLines joins lines into texts of at most limit bytes where possible. A line
+// longer than limit is split between the items of its comma-separated list, each
+// part keeping the line's label.
+func splitLines(lines []string, limit int) []string {
+	var texts []string
+	var sb strings.Builder
+	add := func(line string) {
+		if sb.Len() > 0 && sb.Len()+len(line)+1 > limit {
+			texts = append(texts, sb.String())
+			sb.Reset()
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+
+	for _, line := range lines {
+		if len(line) <= limit {
+			add(line)
+			continue
+		}
+		label, list, ok := strings.Cut(line, ": ")
+		if !ok {
+			add(line)
+			continue
+		}
+		var part []string
+		partLen := len(label) + 2
+		for _, item := range strings.Split(list, ", ") {
+			if len(part) > 0 && partLen+len(item)+2 > limit {
+				add(label + ": " + strings.Join(part, ", "))
+				part, partLen = nil, len(label)+2
+			}
+			part = append(part, item)
+			partLen += len(item) + 2
+		}
+		add(label + ": " + strings.Join(part, ", "))
+	}
+	if sb.Len() > 0 {
+		texts = append(texts, sb.String())
+	}
+	return texts
+}
+
+// payloadInt reads an integer payload value, which the vector store returns as int64.
+func payloadInt(v any) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,76 @@
+package ingest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkText_Sections(t *testing.T) {
+	text := "Pokemon: pikachu (ID: 25)\n" +
+		"Types: electric\n" +
+		"Abilities: static, lightning-rod\n" +
+		"Moves: thunderbolt, quick-attack\n" +
+		"Stats: hp: 35, speed: 90\n" +
+		"Height: 4.0\n" +
+		"Species: pikachu\n" +
+		"Genus: Mouse Pokémon\n" +
+		"Description: When several of these Pokémon gather, their electricity can build and cause lightning storms.\n"
+
+	chunks := chunkText(text)
+
+	assert.Equal(t, []chunk{
+		{sectionStats, "Pokemon: pikachu (ID: 25)\nTypes: electric\nStats: hp: 35, speed: 90\nHeight: 4.0\n"},
+		{sectionAbilities, "Pokemon: pikachu (ID: 25)\nAbilities: static, lightning-rod\n"},
+		{sectionMoves, "Pokemon: pikachu (ID: 25)\nMoves: thunderbolt, quick-attack\n"},
+		{sectionLore, "Pokemon: pikachu (ID: 25)\nSpecies: pikachu\nGenus: Mouse Pokémon\n" +
+			"Description: When several of these Pokémon gather, their electricity can build and cause lightning storms.\n"},
+	}, chunks)
+}
+
+func TestChunkText_Unsectioned(t *testing.T) {
+	text := "Move: thunderbolt (ID: 85)\nType: electric\nPower: 90\n"
+	assert.Equal(t, []chunk{{sectionSummary, text}}, chunkText(text))
+
+	assert.Equal(t, []chunk{{sectionSummary, "Item: leftovers (ID: 234)\n"}}, chunkText("Item: leftovers (ID: 234)\n"))
+}
+
+func TestChunkText_SplitsLongSections(t *testing.T) {
+	moves := make([]string, 150)
+	for i := range moves {
+		moves[i] = fmt.Sprintf("move-number-%03d", i)
+	}
+	header := "Pokemon: mew (ID: 151)"
+	text := header + "\nTypes: psychic\nMoves: " + strings.Join(moves, ", ") + "\n"
+
+	chunks := chunkText(text)
+	require.Greater(t, len(chunks), 2)
+	assert.Equal(t, sectionStats, chunks[0].section)
+
+	var got []string
+	for _, c := range chunks[1:] {
+		assert.Equal(t, sectionMoves, c.section)
+		assert.LessOrEqual(t, len(c.text), maxChunkLen)
+
+		body, ok := strings.CutPrefix(c.text, header+"\nMoves: ")
+		require.True(t, ok, "every part keeps the header and label")
+		got = append(got, strings.Split(strings.TrimSuffix(body, "\n"), ", ")...)
+	}
+	assert.Equal(t, moves, got, "no move is lost or repeated")
+}
+
+func TestContentHash_CoversChunking(t *testing.T) {
+	text := "Pokemon: pikachu (ID: 25)\nTypes: electric\nSpecies: pikachu\nGenus: Mouse Pokémon\n"
+
+	assert.Equal(t, ContentHash(text), ContentHash(text))
+	assert.NotEqual(t, ContentHash(text), ContentHash(text+"Height: 4.0\n"))
+
+	whole := sha256.Sum256([]byte(text))
+	assert.NotEqual(t, hex.EncodeToString(whole[:]), ContentHash(text),
+		"documents hashed before they were chunked must be re-embedded")
+}
@@ -1,23 +1,57 @@
 package ingest
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"cyrene/internal/platform/vectorstore"
+
 	"github.com/google/uuid"
 )
 
 var ErrNotFound = errors.New("document not found")
+var ErrJobNotFound = errors.New("job not found")
+var ErrInvalidBatch = errors.New("invalid batch")
+var ErrUnsupportedType = errors.New("unsupported document type")
+var ErrOutboxSuperseded = errors.New("outbox entry superseded")
+var ErrUnsupportedEvent = errors.New("unsupported event type")
 
 const referenceKey = "reference"
 const typeKey = "type"
 const contentKey = "content"
+const chunkIndexKey = "chunk_index"
+const sectionKey = "section"
+
+// maxBatchSize caps the number of documents a single batch may expand to.
+const maxBatchSize = 5000
 
 type Topic string
 
 const (
 	TopicIngestion Topic = "ingestion"
+	// TopicIngestionCompleted and TopicIngestionFailed receive an IngestionResult
+	// for every document (re)indexed or failed, for downstream consumers.
+	TopicIngestionCompleted Topic = "ingestion.completed"
+	TopicIngestionFailed    Topic = "ingestion.failed"
+)
+
+// EventSource is the CloudEvents source of the events the ingest service publishes.
+const EventSource = "/cyrene/ingest"
+
+// Ingestion request event types. The schema version of the data is the last
+// segment of the type: a breaking change gets a new type, decoded alongside the
+// old one until every producer has moved over. Payloads published without an
+// envelope are read as version 1.
+const (
+	EventTypeIngestionRequestedV1 = "cyrene.ingestion.requested.v1"
+	EventTypeIngestionCompletedV1 = "cyrene.ingestion.completed.v1"
+	EventTypeIngestionFailedV1    = "cyrene.ingestion.failed.v1"
 )
 
 type DocumentType string
@@ -25,21 +59,340 @@ type DocumentType string
 const (
 	DocumentTypePokemon DocumentType = "pokemon"
 	DocumentTypeMove    DocumentType = "move"
+	DocumentTypeAbility DocumentType = "ability"
+	DocumentTypeItem    DocumentType = "item"
+	DocumentTypeType    DocumentType = "type"
+
+	DocumentTypeEvolutionChain DocumentType = "evolution_chain"
+
+	DocumentTypeCobblemonSpecies DocumentType = "cobblemon_species"
+	DocumentTypeSpawn            DocumentType = "spawn"
 )
 
+var documentTypes = []DocumentType{
+	DocumentTypePokemon, DocumentTypeMove, DocumentTypeAbility, DocumentTypeItem, DocumentTypeType,
+	DocumentTypeEvolutionChain, DocumentTypeCobblemonSpecies, DocumentTypeSpawn,
+}
+
+// Valid reports whether d is a document type the ingest service knows how to fetch.
+func (d DocumentType) Valid() bool {
+	return slices.Contains(documentTypes, d)
+}
+
 func NewDocumentID(d DocumentType, id string) string {
 	return fmt.Sprintf("%s_%s", d, id)
 }
 
+// ParseDocumentID splits a reference built by NewDocumentID back into its type and ID.
+// The longest matching type wins, since some types contain underscores themselves.
+func ParseDocumentID(reference string) (DocumentType, string, bool) {
+	var match DocumentType
+	for _, d := range documentTypes {
+		if strings.HasPrefix(reference, string(d)+"_") && len(d) > len(match) {
+			match = d
+		}
+	}
+	if match == "" {
+		return "", "", false
+	}
+	return match, strings.TrimPrefix(reference, string(match)+"_"), true
+}
+
+// IngestedDocument is the record of a document whose vectors were written. Content is
+// the embedding text it was last ingested from; it is only served by DocumentDetail.
 type IngestedDocument struct {
-	ID           uuid.UUID
-	DocumentType DocumentType
-	ExternalID   string
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ID             uuid.UUID    `json:"id"`
+	DocumentType   DocumentType `json:"type"`
+	ExternalID     string       `json:"external_id"`
+	ContentHash    string       `json:"content_hash"`
+	EmbeddingModel string       `json:"embedding_model"`
+	EmbeddingDim   int          `json:"embedding_dim"`
+	Content        string       `json:"-"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// DocumentFilter selects ingested documents. Zero fields do not filter; the time
+// bounds are exclusive. After is the ID of the last document of the previous page.
+type DocumentFilter struct {
+	Type          DocumentType
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+	After         uuid.UUID
+	Limit         int
+}
+
+// DocumentPage is one page of ingested documents. Next is the cursor for the
+// following page and is omitted on the last one.
+type DocumentPage struct {
+	Documents []*IngestedDocument `json:"documents"`
+	Total     int                 `json:"total"`
+	Next      uuid.UUID           `json:"next,omitzero"`
+}
+
+// DocumentDetail is an ingested document together with what the vector store holds
+// for it. Content is the document's full embedding text.
+type DocumentDetail struct {
+	*IngestedDocument
+	Reference string          `json:"reference"`
+	Content   string          `json:"content"`
+	PointIDs  []string        `json:"point_ids"`
+	Chunks    []DocumentChunk `json:"chunks"`
+}
+
+// DocumentChunk is one embedded chunk of a document. Points written before
+// documents were chunked have no section and all carry the whole content.
+type DocumentChunk struct {
+	PointID string `json:"point_id"`
+	Index   int    `json:"index"`
+	Section string `json:"section,omitempty"`
+	Content string `json:"content"`
+}
+
+// IsCurrent reports whether the stored vectors were embedded from the same
+// content with the same model and dimension, so re-embedding can be skipped.
+func (d *IngestedDocument) IsCurrent(contentHash, model string, dim int) bool {
+	return d.ContentHash == contentHash && d.EmbeddingModel == model && d.EmbeddingDim == dim
+}
+
+// ContentHash returns the hex-encoded SHA-256 of a document's embedding text as
+// it is chunked, so documents embedded before a change to chunking, including
+// those embedded whole, are no longer current.
+func ContentHash(text string) string {
+	h := sha256.New()
+	for _, c := range chunkText(text) {
+		fmt.Fprintf(h, "%d:%s%d:%s", len(c.section), c.section, len(c.text), c.text)
+	}
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 type IngestionEvent struct {
-	Type DocumentType `json:"type"`
-	ID   string       `json:"id"`
+	Type   DocumentType `json:"type"`
+	ID     string       `json:"id"`
+	Action EventAction  `json:"action,omitempty"`
+	JobID  uuid.UUID    `json:"job_id,omitzero"`
+}
+
+// EventAction says what an IngestionEvent does to its document. The zero value ingests it.
+type EventAction string
+
+const (
+	ActionIngest EventAction = "ingest"
+	// ActionDelete removes the document row, its vectors and the cached answers built from it.
+	ActionDelete EventAction = "delete"
+)
+
+// IngestionResult is the outcome of ingesting one document. Vectors and ContentHash
+// are set when it completed and Error when it failed. DurationMS runs from the
+// start of the ingestion until the document was stored or failed; documents of a
+// batch share the start of the batch.
+type IngestionResult struct {
+	Reference   string       `json:"reference"`
+	Type        DocumentType `json:"type"`
+	ID          string       `json:"id"`
+	JobID       uuid.UUID    `json:"job_id,omitzero"`
+	Vectors     int          `json:"vectors,omitempty"`
+	ContentHash string       `json:"content_hash,omitempty"`
+	DurationMS  int64        `json:"duration_ms"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// BatchIngestionEvent requests ingestion of many documents of one type.
+// IDs lists individual IDs and Ranges lists inclusive numeric ranges such as "1-151".
+type BatchIngestionEvent struct {
+	Type   DocumentType `json:"type"`
+	IDs    []string     `json:"ids,omitempty"`
+	Ranges []string     `json:"ranges,omitempty"`
+	JobID  uuid.UUID    `json:"job_id,omitzero"`
+}
+
+// ExpandIDs returns the de-duplicated IDs of the batch, explicit IDs first.
+func (b BatchIngestionEvent) ExpandIDs() ([]string, error) {
+	if !b.Type.Valid() {
+		return nil, fmt.Errorf("%w: unsupported document type: %s", ErrInvalidBatch, b.Type)
+	}
+
+	seen := make(map[string]bool)
+	var ids []string
+	add := func(id string) error {
+		if id == "" || seen[id] {
+			return nil
+		}
+		if len(ids) >= maxBatchSize {
+			return fmt.Errorf("%w: more than %d documents", ErrInvalidBatch, maxBatchSize)
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		return nil
+	}
+
+	for _, id := range b.IDs {
+		if err := add(strings.TrimSpace(id)); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, r := range b.Ranges {
+		startStr, endStr, ok := strings.Cut(strings.TrimSpace(r), "-")
+		if !ok {
+			return nil, fmt.Errorf("%w: range %q must look like 1-151", ErrInvalidBatch, r)
+		}
+		start, err := strconv.Atoi(strings.TrimSpace(startStr))
+		if err != nil {
+			return nil, fmt.Errorf("%w: range %q: %v", ErrInvalidBatch, r, err)
+		}
+		end, err := strconv.Atoi(strings.TrimSpace(endStr))
+		if err != nil {
+			return nil, fmt.Errorf("%w: range %q: %v", ErrInvalidBatch, r, err)
+		}
+		if start < 1 || end < start {
+			return nil, fmt.Errorf("%w: range %q is empty", ErrInvalidBatch, r)
+		}
+		if end-start >= maxBatchSize {
+			return nil, fmt.Errorf("%w: range %q exceeds %d documents", ErrInvalidBatch, r, maxBatchSize)
+		}
+		for i := start; i <= end; i++ {
+			if err := add(strconv.Itoa(i)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: no ids given", ErrInvalidBatch)
+	}
+	return ids, nil
+}
+
+// BatchResult summarizes a batch ingestion. Failed maps external IDs to their error.
+type BatchResult struct {
+	Requested int               `json:"requested"`
+	Ingested  int               `json:"ingested"`
+	Skipped   int               `json:"skipped,omitempty"`
+	Failed    map[string]string `json:"failed,omitempty"`
+}
+
+// add merges the result of a batch of docType into r, keying failures by document reference.
+func (r *BatchResult) add(docType DocumentType, other *BatchResult) {
+	r.Requested += other.Requested
+	r.Ingested += other.Ingested
+	r.Skipped += other.Skipped
+	for id, msg := range other.Failed {
+		if r.Failed == nil {
+			r.Failed = make(map[string]string)
+		}
+		r.Failed[NewDocumentID(docType, id)] = msg
+	}
+}
+
+// DriftReport describes how the vector store differs from ingested_documents.
+// Missing lists documents recorded in Postgres without any vectors; Orphans lists
+// references that have vectors but no document row.
+type DriftReport struct {
+	Documents    int          `json:"documents"`
+	References   int          `json:"references"`
+	Missing      []string     `json:"missing"`
+	Orphans      []string     `json:"orphans"`
+	OrphanPoints int          `json:"orphan_points"`
+	Repair       *BatchResult `json:"repair,omitempty"`
+	Deleted      int          `json:"deleted,omitempty"`
+}
+
+// InSync reports whether the vector store and ingested_documents agree.
+func (r *DriftReport) InSync() bool {
+	return len(r.Missing) == 0 && len(r.Orphans) == 0
+}
+
+type OutboxOperation string
+
+const (
+	// OutboxUpsert replaces every vector of a reference with the entry's points.
+	OutboxUpsert OutboxOperation = "upsert"
+	// OutboxDelete removes every vector of a reference.
+	OutboxDelete OutboxOperation = "delete"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxPending    OutboxStatus = "pending"
+	OutboxDone       OutboxStatus = "done"
+	OutboxFailed     OutboxStatus = "failed"
+	OutboxSuperseded OutboxStatus = "superseded"
+)
+
+// OutboxEntry is a vector store write recorded in the same transaction as the
+// document it belongs to, and applied to the vector store afterwards.
+type OutboxEntry struct {
+	ID            uuid.UUID
+	Reference     string
+	Operation     OutboxOperation
+	Points        []vectorstore.Point
+	Status        OutboxStatus
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+}
+
+// JobStatus is the status of a job or one of its documents. A document is retrying
+// while its ingestion failed but the consumer will attempt it again.
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusRetrying  JobStatus = "retrying"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+)
+
+// IngestionJob tracks an asynchronous ingestion request and the status of each document in it.
+type IngestionJob struct {
+	ID        uuid.UUID      `json:"id"`
+	Status    JobStatus      `json:"status"`
+	Documents []*JobDocument `json:"documents"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type JobDocument struct {
+	DocumentType DocumentType `json:"type"`
+	ExternalID   string       `json:"id"`
+	Status       JobStatus    `json:"status"`
+	Error        string       `json:"error,omitempty"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// summarize derives the overall job status and timestamps from its documents.
+// A job is queued until any document starts, running until every document
+// finishes, retries included, and failed if any document failed.
+func (j *IngestionJob) summarize() {
+	var queued, running, failed int
+	for i, d := range j.Documents {
+		switch d.Status {
+		case JobStatusQueued:
+			queued++
+		case JobStatusRunning, JobStatusRetrying:
+			running++
+		case JobStatusFailed:
+			failed++
+		}
+		if i == 0 || d.UpdatedAt.After(j.UpdatedAt) {
+			j.UpdatedAt = d.UpdatedAt
+		}
+	}
+
+	switch {
+	case queued == len(j.Documents):
+		j.Status = JobStatusQueued
+	case queued > 0 || running > 0:
+		j.Status = JobStatusRunning
+	case failed > 0:
+		j.Status = JobStatusFailed
+	default:
+		j.Status = JobStatusSucceeded
+	}
 }
@@ -3,8 +3,18 @@ package ingest
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"cyrene/internal/platform/cloudevents"
+	"cyrene/internal/platform/kafka"
+	"cyrene/internal/platform/server"
+
+	"github.com/google/uuid"
 )
 
 type Handler struct {
@@ -18,19 +28,101 @@ func NewHandler(service Service) *Handler {
 func (h *Handler) RegisterRoutes() *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.HandleFunc("POST /{$}", h.ingest)
+	server.HandleFunc(mux, "POST /bulk", h.ingestBulk)
+	server.HandleFunc(mux, "POST /datapack", h.importDatapack)
+	server.HandleFunc(mux, "GET /jobs/{id}", h.getJob)
+	server.HandleFunc(mux, "GET /reconcile", h.reconcile)
+	server.HandleFunc(mux, "POST /reconcile", h.reconcile)
+	server.HandleFunc(mux, "GET /documents", h.listDocuments)
+	server.HandleFunc(mux, "GET /documents/{type}/{id}", h.getDocument)
+	server.HandleFunc(mux, "DELETE /{type}/{id}", h.delete)
 	return mux
 }
 
+// HandleKafka accepts both single IngestionEvent and BatchIngestionEvent payloads.
+// A payload carrying ids or ranges is treated as a batch; a single event with the
+// delete action removes its document. Payloads are either CloudEvents envelopes of
+// a supported ingestion request type or the bare JSON published before envelopes.
 func (h *Handler) HandleKafka(ctx context.Context, payload []byte) error {
-	var event IngestionEvent
-	if err := json.Unmarshal(payload, &event); err != nil {
-		return fmt.Errorf("unmarshal payload: %w", err)
+	event, err := parseEvent(payload)
+	if err != nil {
+		return kafka.Permanent(err)
+	}
+	decode, ok := requestDecoders[event.Type]
+	if !ok {
+		return kafka.Permanent(fmt.Errorf("%w: %s", ErrUnsupportedEvent, event.Type))
+	}
+	msg, err := decode(event.Data)
+	if err != nil {
+		return kafka.Permanent(err)
+	}
+	ctx = cloudevents.WithTraceparent(ctx, event.Traceparent)
+
+	if len(msg.IDs) == 0 && len(msg.Ranges) == 0 {
+		switch msg.Action {
+		case "", ActionIngest:
+			return permanent(h.service.Ingest(ctx, msg.IngestionEvent))
+		case ActionDelete:
+			return permanent(h.service.Delete(ctx, msg.Type, msg.ID))
+		default:
+			return kafka.Permanent(fmt.Errorf("unsupported action: %s", msg.Action))
+		}
 	}
-	return h.service.Ingest(ctx, event)
+
+	result, err := h.service.IngestBatch(ctx, BatchIngestionEvent{
+		Type:   msg.Type,
+		IDs:    msg.IDs,
+		Ranges: msg.Ranges,
+		JobID:  msg.JobID,
+	})
+	if err != nil {
+		return permanent(err)
+	}
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("batch ingestion: %d of %d documents failed", len(result.Failed), result.Requested)
+	}
+	return nil
+}
+
+// permanent marks the service errors that no retry can fix, so the consumer
+// dead-letters the record straight away.
+func permanent(err error) error {
+	if isPermanent(err) {
+		return kafka.Permanent(err)
+	}
+	return err
+}
+
+// ingestionRequest is the data of an ingestion request: a single document, or
+// a batch when IDs or Ranges are set.
+type ingestionRequest struct {
+	IngestionEvent
+	IDs    []string `json:"ids"`
+	Ranges []string `json:"ranges"`
+}
+
+// requestDecoders decode the data of every supported ingestion request type.
+var requestDecoders = map[string]func(data []byte) (ingestionRequest, error){
+	EventTypeIngestionRequestedV1: func(data []byte) (ingestionRequest, error) {
+		var req ingestionRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			return req, fmt.Errorf("unmarshal payload: %w", err)
+		}
+		return req, nil
+	},
+}
+
+// parseEvent reads a CloudEvents envelope, or wraps a legacy payload in a
+// version 1 ingestion request.
+func parseEvent(payload []byte) (*cloudevents.Event, error) {
+	if !cloudevents.IsEnvelope(payload) {
+		return &cloudevents.Event{Type: EventTypeIngestionRequestedV1, Data: payload}, nil
+	}
+	return cloudevents.Parse(payload)
 }
 
 // @Summary      Ingest document
-// @Description  Index a Pokemon or Move document into the vector store
+// @Description  Queue a Pokemon, Move, Ability, Item, Type, Evolution Chain, Cobblemon Species or Spawn document for indexing and return the job ID
 // @Tags         ingest
 // @Accept       json
 // @Produce      json
@@ -46,12 +138,248 @@ func (h *Handler) ingest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.Ingest(r.Context(), event); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	job, err := h.service.Enqueue(r.Context(), BatchIngestionEvent{Type: event.Type, IDs: []string{event.ID}})
+	if err != nil {
+		writeEnqueueError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"status": "accepted", "job_id": job.ID.String()})
+}
+
+// @Summary      Bulk ingest documents
+// @Description  Queue many documents of one type, given as IDs and/or ranges like "1-151", as a single job
+// @Tags         ingest
+// @Accept       json
+// @Produce      json
+// @Param        batch  body      BatchIngestionEvent  true  "Batch ingestion event"
+// @Success      202    {object}  IngestionJob
+// @Failure      400    {string}  string  "invalid request body"
+// @Failure      500    {string}  string  "internal server error"
+// @Router       /ingest/bulk [post]
+func (h *Handler) ingestBulk(w http.ResponseWriter, r *http.Request) {
+	var batch BatchIngestionEvent
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.service.Enqueue(r.Context(), batch)
+	if err != nil {
+		writeEnqueueError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusAccepted)
-	json.NewEncoder(w).Encode(map[string]string{"status": "accepted"})
+	json.NewEncoder(w).Encode(job)
+}
+
+// @Summary      Import Cobblemon datapack
+// @Description  Queue every species and spawn pool from the configured Cobblemon datapack as a single job
+// @Tags         ingest
+// @Produce      json
+// @Success      202    {object}  IngestionJob
+// @Failure      400    {string}  string  "datapack is empty"
+// @Failure      500    {string}  string  "internal server error"
+// @Router       /ingest/datapack [post]
+func (h *Handler) importDatapack(w http.ResponseWriter, r *http.Request) {
+	job, err := h.service.ImportDatapack(r.Context())
+	if err != nil {
+		writeEnqueueError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// @Summary      Get ingestion job
+// @Description  Return the overall and per-document status of an ingestion job
+// @Tags         ingest
+// @Produce      json
+// @Param        id   path      string  true  "Job ID"
+// @Success      200  {object}  IngestionJob
+// @Failure      400  {string}  string  "invalid job id"
+// @Failure      404  {string}  string  "job not found"
+// @Failure      500  {string}  string  "internal server error"
+// @Router       /ingest/jobs/{id} [get]
+func (h *Handler) getJob(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid job id", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.service.GetJob(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, ErrJobNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// @Summary      Reconcile vector store
+// @Description  Compare the vector store with ingested documents and report drift. A POST also repairs it by re-ingesting documents without vectors and deleting orphan points.
+// @Tags         ingest
+// @Produce      json
+// @Success      200  {object}  DriftReport
+// @Failure      500  {string}  string  "internal server error"
+// @Router       /ingest/reconcile [get]
+// @Router       /ingest/reconcile [post]
+func (h *Handler) reconcile(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.Reconcile(r.Context(), r.Method == http.MethodPost)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// @Summary      List ingested documents
+// @Description  Page through ingested documents, optionally filtered by type and created/updated time ranges. Pass the returned next cursor as after to fetch the following page.
+// @Tags         ingest
+// @Produce      json
+// @Param        type            query     string  false  "Document type"
+// @Param        created_after   query     string  false  "Only documents created after this RFC 3339 time"
+// @Param        created_before  query     string  false  "Only documents created before this RFC 3339 time"
+// @Param        updated_after   query     string  false  "Only documents updated after this RFC 3339 time"
+// @Param        updated_before  query     string  false  "Only documents updated before this RFC 3339 time"
+// @Param        after           query     string  false  "Cursor from the previous page"
+// @Param        limit           query     int     false  "Page size (default 50, max 500)"
+// @Success      200             {object}  DocumentPage
+// @Failure      400             {string}  string  "invalid query"
+// @Failure      500             {string}  string  "internal server error"
+// @Router       /ingest/documents [get]
+func (h *Handler) listDocuments(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseDocumentFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.service.ListDocuments(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, ErrUnsupportedType) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// @Summary      Get ingested document
+// @Description  Return the ingested document record, its stored embedding text and its vector point IDs
+// @Tags         ingest
+// @Produce      json
+// @Param        type  path      string  true  "Document type"
+// @Param        id    path      string  true  "Document ID"
+// @Success      200   {object}  DocumentDetail
+// @Failure      400   {string}  string  "unsupported document type"
+// @Failure      404   {string}  string  "document not found"
+// @Failure      500   {string}  string  "internal server error"
+// @Router       /ingest/documents/{type}/{id} [get]
+func (h *Handler) getDocument(w http.ResponseWriter, r *http.Request) {
+	detail, err := h.service.GetDocument(r.Context(), DocumentType(r.PathValue("type")), r.PathValue("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUnsupportedType):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+// @Summary      Delete document
+// @Description  Remove a document from ingested_documents and the vector store, and invalidate cached answers built from it
+// @Tags         ingest
+// @Param        type  path      string  true  "Document type"
+// @Param        id    path      string  true  "Document ID"
+// @Success      204
+// @Failure      400   {string}  string  "unsupported document type"
+// @Failure      500   {string}  string  "internal server error"
+// @Router       /ingest/{type}/{id} [delete]
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
+	err := h.service.Delete(r.Context(), DocumentType(r.PathValue("type")), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, ErrUnsupportedType) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseDocumentFilter reads a DocumentFilter from the query string of a list request.
+func parseDocumentFilter(query url.Values) (DocumentFilter, error) {
+	filter := DocumentFilter{Type: DocumentType(query.Get("type"))}
+
+	times := map[string]*time.Time{
+		"created_after":  &filter.CreatedAfter,
+		"created_before": &filter.CreatedBefore,
+		"updated_after":  &filter.UpdatedAfter,
+		"updated_before": &filter.UpdatedBefore,
+	}
+	for name, dest := range times {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s: must be an RFC 3339 time", name)
+		}
+		*dest = t
+	}
+
+	if after := query.Get("after"); after != "" {
+		id, err := uuid.Parse(after)
+		if err != nil {
+			return filter, errors.New("invalid after cursor")
+		}
+		filter.After = id
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return filter, errors.New("invalid limit")
+		}
+		filter.Limit = n
+	}
+
+	return filter, nil
+}
+
+func writeEnqueueError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, ErrInvalidBatch) {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
 }
@@ -2,79 +2,632 @@ package ingest
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"cyrene/internal/platform/cloudevents"
+	"cyrene/internal/platform/kafka"
+
+	"github.com/google/uuid"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 type mockService struct {
-	ingestPokemonFn func(ctx context.Context, pokemonID string) error
+	ingestFn      func(ctx context.Context, event IngestionEvent) error
+	ingestBatchFn func(ctx context.Context, batch BatchIngestionEvent) (*BatchResult, error)
+	enqueueFn     func(ctx context.Context, batches ...BatchIngestionEvent) (*IngestionJob, error)
+	getJobFn      func(ctx context.Context, id uuid.UUID) (*IngestionJob, error)
+	reconcileFn   func(ctx context.Context, repair bool) (*DriftReport, error)
+	deleteFn      func(ctx context.Context, docType DocumentType, externalID string) error
+	listFn        func(ctx context.Context, filter DocumentFilter) (*DocumentPage, error)
+	getFn         func(ctx context.Context, docType DocumentType, externalID string) (*DocumentDetail, error)
+}
+
+func (m *mockService) ListDocuments(ctx context.Context, filter DocumentFilter) (*DocumentPage, error) {
+	if m.listFn != nil {
+		return m.listFn(ctx, filter)
+	}
+	return &DocumentPage{}, nil
+}
+
+func (m *mockService) GetDocument(ctx context.Context, docType DocumentType, externalID string) (*DocumentDetail, error) {
+	if m.getFn != nil {
+		return m.getFn(ctx, docType, externalID)
+	}
+	return nil, ErrNotFound
+}
+
+func (m *mockService) Delete(ctx context.Context, docType DocumentType, externalID string) error {
+	if m.deleteFn != nil {
+		return m.deleteFn(ctx, docType, externalID)
+	}
+	return nil
+}
+
+func (m *mockService) Reconcile(ctx context.Context, repair bool) (*DriftReport, error) {
+	if m.reconcileFn != nil {
+		return m.reconcileFn(ctx, repair)
+	}
+	return &DriftReport{}, nil
+}
+
+func (m *mockService) IngestBatch(ctx context.Context, batch BatchIngestionEvent) (*BatchResult, error) {
+	if m.ingestBatchFn != nil {
+		return m.ingestBatchFn(ctx, batch)
+	}
+	return &BatchResult{}, nil
+}
+
+func (m *mockService) Enqueue(ctx context.Context, batches ...BatchIngestionEvent) (*IngestionJob, error) {
+	if m.enqueueFn != nil {
+		return m.enqueueFn(ctx, batches...)
+	}
+	return &IngestionJob{ID: uuid.Must(uuid.NewV7()), Status: JobStatusQueued}, nil
+}
+
+func (m *mockService) GetJob(ctx context.Context, id uuid.UUID) (*IngestionJob, error) {
+	if m.getJobFn != nil {
+		return m.getJobFn(ctx, id)
+	}
+	return nil, ErrJobNotFound
+}
+
+func (m *mockService) ImportDatapack(ctx context.Context) (*IngestionJob, error) {
+	return m.Enqueue(ctx)
 }
 
-func (m *mockService) IngestPokemon(ctx context.Context, pokemonID string) error {
-	if m.ingestPokemonFn != nil {
-		return m.ingestPokemonFn(ctx, pokemonID)
+func (m *mockService) Ingest(ctx context.Context, event IngestionEvent) error {
+	if m.ingestFn != nil {
+		return m.ingestFn(ctx, event)
 	}
 	return nil
 }
 
-func TestHandler_HandleIngest_Pokemon_Success(t *testing.T) {
+func TestHandler_HandleKafka_Pokemon_Success(t *testing.T) {
 	ctx := context.Background()
 	payload := []byte(`{"type":"pokemon","id":"25"}`)
 
-	var calledWith string
+	var calledWith IngestionEvent
 	svc := &mockService{
-		ingestPokemonFn: func(ctx context.Context, pokemonID string) error {
-			calledWith = pokemonID
+		ingestFn: func(ctx context.Context, event IngestionEvent) error {
+			calledWith = event
 			return nil
 		},
 	}
 
 	h := NewHandler(svc)
-	err := h.HandleIngest(ctx, payload)
+	err := h.HandleKafka(ctx, payload)
 
 	require.NoError(t, err)
-	assert.Equal(t, "25", calledWith)
+	assert.Equal(t, IngestionEvent{Type: DocumentTypePokemon, ID: "25"}, calledWith)
 }
 
-func TestHandler_HandleIngest_InvalidJSON(t *testing.T) {
+func TestHandler_HandleKafka_Move_Success(t *testing.T) {
+	ctx := context.Background()
+	payload := []byte(`{"type":"move","id":"85"}`)
+
+	var calledWith IngestionEvent
+	svc := &mockService{
+		ingestFn: func(ctx context.Context, event IngestionEvent) error {
+			calledWith = event
+			return nil
+		},
+	}
+
+	h := NewHandler(svc)
+	err := h.HandleKafka(ctx, payload)
+
+	require.NoError(t, err)
+	assert.Equal(t, IngestionEvent{Type: DocumentTypeMove, ID: "85"}, calledWith)
+}
+
+func TestHandler_HandleKafka_InvalidJSON(t *testing.T) {
 	ctx := context.Background()
 	payload := []byte(`{invalid json}`)
 
 	h := NewHandler(&mockService{})
-	err := h.HandleIngest(ctx, payload)
+	err := h.HandleKafka(ctx, payload)
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "unmarshal")
+	assert.True(t, kafka.IsPermanent(err), "a malformed payload is dead-lettered without retries")
 }
 
-func TestHandler_HandleIngest_UnsupportedType(t *testing.T) {
-	ctx := context.Background()
-	payload := []byte(`{"type":"unknown","id":"1"}`)
+func TestHandler_HandleKafka_Envelope(t *testing.T) {
+	payload := []byte(`{
+		"specversion": "1.0",
+		"id": "0b1c6f0e-1d1f-4c55-9a4a-6c1de3c7a2b1",
+		"type": "cyrene.ingestion.requested.v1",
+		"source": "/pokedex/sync",
+		"time": "2025-12-16T12:00:00Z",
+		"datacontenttype": "application/json",
+		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"data": {"type": "pokemon", "id": "25"}
+	}`)
 
-	h := NewHandler(&mockService{})
-	err := h.HandleIngest(ctx, payload)
+	var calledWith IngestionEvent
+	var traceparent string
+	svc := &mockService{
+		ingestFn: func(ctx context.Context, event IngestionEvent) error {
+			calledWith = event
+			traceparent = cloudevents.Traceparent(ctx)
+			return nil
+		},
+	}
 
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "unsupported event type")
+	require.NoError(t, NewHandler(svc).HandleKafka(context.Background(), payload))
+	assert.Equal(t, IngestionEvent{Type: DocumentTypePokemon, ID: "25"}, calledWith)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", traceparent)
+}
+
+func TestHandler_HandleKafka_UnsupportedEvents(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		wantErr error
+	}{
+		{
+			name:    "unknown schema version",
+			payload: `{"specversion":"1.0","id":"1","type":"cyrene.ingestion.requested.v2","source":"/x","data":{}}`,
+			wantErr: ErrUnsupportedEvent,
+		},
+		{
+			name:    "unknown spec version",
+			payload: `{"specversion":"2.0","id":"1","type":"cyrene.ingestion.requested.v1","source":"/x","data":{}}`,
+			wantErr: cloudevents.ErrInvalidEvent,
+		},
+		{
+			name:    "missing id",
+			payload: `{"specversion":"1.0","type":"cyrene.ingestion.requested.v1","source":"/x","data":{}}`,
+			wantErr: cloudevents.ErrInvalidEvent,
+		},
+		{
+			name:    "non-json data",
+			payload: `{"specversion":"1.0","id":"1","type":"cyrene.ingestion.requested.v1","source":"/x","datacontenttype":"application/avro","data":{}}`,
+			wantErr: cloudevents.ErrInvalidEvent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockService{
+				ingestFn: func(ctx context.Context, event IngestionEvent) error {
+					t.Fatal("unsupported events must not be ingested")
+					return nil
+				},
+			}
+			err := NewHandler(svc).HandleKafka(context.Background(), []byte(tt.payload))
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.True(t, kafka.IsPermanent(err))
+		})
+	}
 }
 
-func TestHandler_HandleIngest_ServiceError(t *testing.T) {
+func TestHandler_HandleKafka_ServiceError(t *testing.T) {
 	ctx := context.Background()
 	payload := []byte(`{"type":"pokemon","id":"999"}`)
 	expectedErr := errors.New("pokemon not found")
 
 	svc := &mockService{
-		ingestPokemonFn: func(ctx context.Context, pokemonID string) error {
+		ingestFn: func(ctx context.Context, event IngestionEvent) error {
 			return expectedErr
 		},
 	}
 
 	h := NewHandler(svc)
-	err := h.HandleIngest(ctx, payload)
+	err := h.HandleKafka(ctx, payload)
 
 	require.Error(t, err)
 	assert.ErrorIs(t, err, expectedErr)
+	assert.False(t, kafka.IsPermanent(err), "a failed lookup may succeed on a retry")
+}
+
+func TestHandler_HandleKafka_PermanentServiceErrors(t *testing.T) {
+	svc := &mockService{
+		ingestFn: func(ctx context.Context, event IngestionEvent) error {
+			return fmt.Errorf("%w: %s", ErrUnsupportedType, event.Type)
+		},
+		ingestBatchFn: func(ctx context.Context, batch BatchIngestionEvent) (*BatchResult, error) {
+			return nil, fmt.Errorf("%w: range %q is empty", ErrInvalidBatch, batch.Ranges[0])
+		},
+	}
+
+	for _, payload := range []string{`{"type":"widget","id":"1"}`, `{"type":"pokemon","ranges":["9-1"]}`} {
+		err := NewHandler(svc).HandleKafka(context.Background(), []byte(payload))
+		assert.True(t, kafka.IsPermanent(err), payload)
+	}
+}
+
+func TestHandler_HandleKafka_Batch(t *testing.T) {
+	ctx := context.Background()
+	payload := []byte(`{"type":"pokemon","ids":["25"],"ranges":["1-3"]}`)
+
+	var calledWith BatchIngestionEvent
+	svc := &mockService{
+		ingestFn: func(ctx context.Context, event IngestionEvent) error {
+			t.Fatal("single ingest should not be called for a batch payload")
+			return nil
+		},
+		ingestBatchFn: func(ctx context.Context, batch BatchIngestionEvent) (*BatchResult, error) {
+			calledWith = batch
+			return &BatchResult{Requested: 4, Ingested: 4}, nil
+		},
+	}
+
+	h := NewHandler(svc)
+	err := h.HandleKafka(ctx, payload)
+
+	require.NoError(t, err)
+	assert.Equal(t, BatchIngestionEvent{Type: DocumentTypePokemon, IDs: []string{"25"}, Ranges: []string{"1-3"}}, calledWith)
+}
+
+func TestHandler_HandleKafka_BatchPartialFailure(t *testing.T) {
+	ctx := context.Background()
+	payload := []byte(`{"type":"pokemon","ranges":["1-3"]}`)
+
+	svc := &mockService{
+		ingestBatchFn: func(ctx context.Context, batch BatchIngestionEvent) (*BatchResult, error) {
+			return &BatchResult{Requested: 3, Ingested: 2, Failed: map[string]string{"2": "boom"}}, nil
+		},
+	}
+
+	h := NewHandler(svc)
+	err := h.HandleKafka(ctx, payload)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 of 3 documents failed")
+}
+
+func TestHandler_HandleKafka_PassesJobID(t *testing.T) {
+	jobID := uuid.Must(uuid.NewV7())
+	payload := []byte(`{"type":"pokemon","ids":["1","2"],"job_id":"` + jobID.String() + `"}`)
+
+	var calledWith BatchIngestionEvent
+	svc := &mockService{
+		ingestBatchFn: func(ctx context.Context, batch BatchIngestionEvent) (*BatchResult, error) {
+			calledWith = batch
+			return &BatchResult{Requested: 2, Ingested: 2}, nil
+		},
+	}
+
+	err := NewHandler(svc).HandleKafka(context.Background(), payload)
+
+	require.NoError(t, err)
+	assert.Equal(t, jobID, calledWith.JobID)
+}
+
+// mounted serves the handler's routes under /ingest, as cmd/api mounts them.
+func mounted(h *Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/ingest/", http.StripPrefix("/ingest", h.RegisterRoutes()))
+	return mux
+}
+
+func TestHandler_ImportDatapack_MountedUnderPrefix(t *testing.T) {
+	routes := mounted(NewHandler(&mockService{}))
+
+	for _, path := range []string{"/ingest/datapack", "/ingest/datapack/"} {
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, nil))
+		assert.Equal(t, http.StatusAccepted, rec.Code, path)
+	}
+}
+
+func TestHandler_Ingest_EnqueuesJob(t *testing.T) {
+	jobID := uuid.Must(uuid.NewV7())
+
+	var calledWith []BatchIngestionEvent
+	svc := &mockService{
+		ingestFn: func(ctx context.Context, event IngestionEvent) error {
+			t.Fatal("HTTP ingest should enqueue instead of ingesting inline")
+			return nil
+		},
+		enqueueFn: func(ctx context.Context, batches ...BatchIngestionEvent) (*IngestionJob, error) {
+			calledWith = batches
+			return &IngestionJob{ID: jobID, Status: JobStatusQueued}, nil
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"type":"pokemon","id":"25"}`))
+	rec := httptest.NewRecorder()
+	NewHandler(svc).RegisterRoutes().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, []BatchIngestionEvent{{Type: DocumentTypePokemon, IDs: []string{"25"}}}, calledWith)
+
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, jobID.String(), body["job_id"])
+}
+
+func TestHandler_IngestBulk_InvalidBatch(t *testing.T) {
+	svc := &mockService{
+		enqueueFn: func(ctx context.Context, batches ...BatchIngestionEvent) (*IngestionJob, error) {
+			return nil, ErrInvalidBatch
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/bulk/", strings.NewReader(`{"type":"pokemon"}`))
+	rec := httptest.NewRecorder()
+	NewHandler(svc).RegisterRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandler_IngestBulk_MountedUnderPrefix(t *testing.T) {
+	routes := mounted(NewHandler(&mockService{}))
+
+	for _, path := range []string{"/ingest/bulk", "/ingest/bulk/"} {
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"type":"pokemon","ids":["1","2"]}`)))
+		assert.Equal(t, http.StatusAccepted, rec.Code, path)
+	}
+}
+
+func TestHandler_GetJob(t *testing.T) {
+	jobID := uuid.Must(uuid.NewV7())
+	svc := &mockService{
+		getJobFn: func(ctx context.Context, id uuid.UUID) (*IngestionJob, error) {
+			if id != jobID {
+				return nil, ErrJobNotFound
+			}
+			return &IngestionJob{ID: jobID, Status: JobStatusSucceeded}, nil
+		},
+	}
+	routes := NewHandler(svc).RegisterRoutes()
+
+	tests := map[string]struct {
+		path string
+		want int
+	}{
+		"found":      {"/jobs/" + jobID.String() + "/", http.StatusOK},
+		"not found":  {"/jobs/" + uuid.NewString() + "/", http.StatusNotFound},
+		"invalid id": {"/jobs/not-a-uuid/", http.StatusBadRequest},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			routes.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestHandler_GetJob_MountedUnderPrefix(t *testing.T) {
+	jobID := uuid.Must(uuid.NewV7())
+	svc := &mockService{
+		getJobFn: func(ctx context.Context, id uuid.UUID) (*IngestionJob, error) {
+			return &IngestionJob{ID: id, Status: JobStatusSucceeded}, nil
+		},
+	}
+	routes := mounted(NewHandler(svc))
+
+	for _, path := range []string{"/ingest/jobs/" + jobID.String(), "/ingest/jobs/" + jobID.String() + "/"} {
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+	}
+}
+
+func TestHandler_Reconcile(t *testing.T) {
+	tests := map[string]struct {
+		method string
+		repair bool
+	}{
+		"report": {http.MethodGet, false},
+		"repair": {http.MethodPost, true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calledWith *bool
+			svc := &mockService{
+				reconcileFn: func(ctx context.Context, repair bool) (*DriftReport, error) {
+					calledWith = &repair
+					return &DriftReport{Documents: 2, Missing: []string{"move_2"}}, nil
+				},
+			}
+
+			rec := httptest.NewRecorder()
+			NewHandler(svc).RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(tt.method, "/reconcile/", nil))
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.NotNil(t, calledWith)
+			assert.Equal(t, tt.repair, *calledWith)
+
+			var report DriftReport
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+			assert.Equal(t, []string{"move_2"}, report.Missing)
+		})
+	}
+}
+
+func TestHandler_Reconcile_MountedUnderPrefix(t *testing.T) {
+	routes := mounted(NewHandler(&mockService{}))
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		for _, path := range []string{"/ingest/reconcile", "/ingest/reconcile/"} {
+			rec := httptest.NewRecorder()
+			routes.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+			assert.Equal(t, http.StatusOK, rec.Code, method+" "+path)
+		}
+	}
+}
+
+func TestHandler_HandleKafka_Delete(t *testing.T) {
+	var deleted string
+	svc := &mockService{
+		ingestFn: func(ctx context.Context, event IngestionEvent) error {
+			t.Fatal("delete event must not ingest")
+			return nil
+		},
+		deleteFn: func(ctx context.Context, docType DocumentType, externalID string) error {
+			deleted = NewDocumentID(docType, externalID)
+			return nil
+		},
+	}
+
+	err := NewHandler(svc).HandleKafka(context.Background(), []byte(`{"type":"cobblemon_species","id":"fakemon","action":"delete"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "cobblemon_species_fakemon", deleted)
+
+	err = NewHandler(svc).HandleKafka(context.Background(), []byte(`{"type":"pokemon","id":"25","action":"purge"}`))
+	assert.ErrorContains(t, err, "unsupported action")
+	assert.True(t, kafka.IsPermanent(err))
+}
+
+func TestHandler_Delete(t *testing.T) {
+	tests := map[string]struct {
+		path   string
+		err    error
+		status int
+	}{
+		"deleted":          {"/cobblemon_species/fakemon/", nil, http.StatusNoContent},
+		"unsupported type": {"/widget/1/", fmt.Errorf("%w: widget", ErrUnsupportedType), http.StatusBadRequest},
+		"service error":    {"/pokemon/25/", errors.New("db down"), http.StatusInternalServerError},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			svc := &mockService{
+				deleteFn: func(ctx context.Context, docType DocumentType, externalID string) error {
+					return tt.err
+				},
+			}
+
+			rec := httptest.NewRecorder()
+			NewHandler(svc).RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, tt.path, nil))
+
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
+
+func TestHandler_Delete_MountedUnderPrefix(t *testing.T) {
+	var deleted []string
+	svc := &mockService{
+		deleteFn: func(ctx context.Context, docType DocumentType, externalID string) error {
+			deleted = append(deleted, NewDocumentID(docType, externalID))
+			return nil
+		},
+	}
+	routes := mounted(NewHandler(svc))
+
+	for _, path := range []string{"/ingest/pokemon/25", "/ingest/pokemon/25/"} {
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, path, nil))
+		assert.Equal(t, http.StatusNoContent, rec.Code, path)
+	}
+	assert.Equal(t, []string{"pokemon_25", "pokemon_25"}, deleted)
+}
+
+func TestHandler_ListDocuments(t *testing.T) {
+	cursor := uuid.Must(uuid.NewV7())
+
+	var got DocumentFilter
+	svc := &mockService{
+		listFn: func(ctx context.Context, filter DocumentFilter) (*DocumentPage, error) {
+			got = filter
+			return &DocumentPage{
+				Documents: []*IngestedDocument{{DocumentType: DocumentTypePokemon, ExternalID: "25"}},
+				Total:     1,
+			}, nil
+		},
+	}
+
+	path := "/documents/?type=pokemon&created_after=2025-12-01T00:00:00Z&updated_before=2025-12-15T12:00:00Z&limit=10&after=" + cursor.String()
+	rec := httptest.NewRecorder()
+	NewHandler(svc).RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, DocumentTypePokemon, got.Type)
+	assert.Equal(t, time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), got.CreatedAfter)
+	assert.Equal(t, time.Date(2025, 12, 15, 12, 0, 0, 0, time.UTC), got.UpdatedBefore)
+	assert.True(t, got.CreatedBefore.IsZero())
+	assert.Equal(t, cursor, got.After)
+	assert.Equal(t, 10, got.Limit)
+
+	var page DocumentPage
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	require.Len(t, page.Documents, 1)
+	assert.Equal(t, "25", page.Documents[0].ExternalID)
+	assert.NotContains(t, rec.Body.String(), `"next"`)
+}
+
+func TestHandler_ListDocuments_InvalidQuery(t *testing.T) {
+	for _, query := range []string{"created_after=yesterday", "after=nope", "limit=0", "limit=ten"} {
+		t.Run(query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewHandler(&mockService{}).RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/documents/?"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestHandler_GetDocument(t *testing.T) {
+	tests := map[string]struct {
+		path   string
+		err    error
+		status int
+	}{
+		"found":            {"/documents/pokemon/25/", nil, http.StatusOK},
+		"not found":        {"/documents/pokemon/9999/", ErrNotFound, http.StatusNotFound},
+		"unsupported type": {"/documents/widget/1/", fmt.Errorf("%w: widget", ErrUnsupportedType), http.StatusBadRequest},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			svc := &mockService{
+				getFn: func(ctx context.Context, docType DocumentType, externalID string) (*DocumentDetail, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &DocumentDetail{
+						IngestedDocument: &IngestedDocument{DocumentType: docType, ExternalID: externalID},
+						Reference:        NewDocumentID(docType, externalID),
+						Content:          "Pokemon: pikachu",
+						PointIDs:         []string{"p1"},
+					}, nil
+				},
+			}
+
+			rec := httptest.NewRecorder()
+			NewHandler(svc).RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			require.Equal(t, tt.status, rec.Code)
+			if tt.status == http.StatusOK {
+				var detail map[string]any
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&detail))
+				assert.Equal(t, "pokemon", detail["type"])
+				assert.Equal(t, "25", detail["external_id"])
+				assert.Equal(t, "pokemon_25", detail["reference"])
+				assert.Equal(t, "Pokemon: pikachu", detail["content"])
+				assert.Equal(t, []any{"p1"}, detail["point_ids"])
+			}
+		})
+	}
+}
+
+func TestHandler_Documents_MountedUnderPrefix(t *testing.T) {
+	svc := &mockService{
+		getFn: func(ctx context.Context, docType DocumentType, externalID string) (*DocumentDetail, error) {
+			return &DocumentDetail{IngestedDocument: &IngestedDocument{DocumentType: docType, ExternalID: externalID}}, nil
+		},
+	}
+	routes := mounted(NewHandler(svc))
+
+	for _, path := range []string{"/ingest/documents", "/ingest/documents/", "/ingest/documents/pokemon/25", "/ingest/documents/pokemon/25/"} {
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+	}
 }
@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"cyrene/internal/cobblemon"
 	"cyrene/internal/platform/config"
 	"cyrene/internal/platform/kafka"
 	platformqdrant "cyrene/internal/platform/qdrant"
@@ -32,10 +33,20 @@ const (
 
 type stubEmbedService struct{}
 
-func (s *stubEmbedService) Embed(ctx context.Context, texts ...string) ([][]float32, error) {
+func (s *stubEmbedService) Embed(ctx context.Context, dimensions int, texts ...string) ([][]float32, error) {
 	return [][]float32{{1.0, 0.0, 0.0, 0.0}}, nil
 }
 
+func (s *stubEmbedService) EmbedModel() string {
+	return "embed/stub"
+}
+
+type stubAnswerCache struct{}
+
+func (s *stubAnswerCache) InvalidateReferences(ctx context.Context, references ...string) error {
+	return nil
+}
+
 type stubPokemonService struct {
 	calledWith string
 }
@@ -43,11 +54,45 @@ type stubPokemonService struct {
 func (s *stubPokemonService) GetPokemonByID(ctx context.Context, id string) (*pokemon.Pokemon, error) {
 	s.calledWith = id
 	return &pokemon.Pokemon{
-		ID:      id,
-		RawJSON: `{"id":25,"name":"pikachu"}`,
+		ID:         id,
+		Identifier: "pikachu",
+		RawJSON:    `{"id":25,"name":"pikachu"}`,
+	}, nil
+}
+
+func (s *stubPokemonService) GetMoveByID(ctx context.Context, id string) (*pokemon.Move, error) {
+	s.calledWith = id
+	return &pokemon.Move{
+		ID:         id,
+		Identifier: "thunderbolt",
+		RawJSON:    `{"id":85,"name":"thunderbolt"}`,
 	}, nil
 }
 
+func (s *stubPokemonService) GetAbilityByID(ctx context.Context, id string) (*pokemon.Ability, error) {
+	s.calledWith = id
+	return &pokemon.Ability{ID: id, Identifier: "static"}, nil
+}
+
+func (s *stubPokemonService) GetItemByID(ctx context.Context, id string) (*pokemon.Item, error) {
+	s.calledWith = id
+	return &pokemon.Item{ID: id, Identifier: "leftovers"}, nil
+}
+
+func (s *stubPokemonService) GetTypeByID(ctx context.Context, id string) (*pokemon.Type, error) {
+	s.calledWith = id
+	return &pokemon.Type{ID: id, Identifier: "electric"}, nil
+}
+
+func (s *stubPokemonService) GetSpeciesByID(ctx context.Context, id string) (*pokemon.Species, error) {
+	return &pokemon.Species{ID: id, Identifier: "pikachu"}, nil
+}
+
+func (s *stubPokemonService) GetEvolutionChainByID(ctx context.Context, id string) (*pokemon.EvolutionChain, error) {
+	s.calledWith = id
+	return &pokemon.EvolutionChain{ID: id}, nil
+}
+
 func TestPipeline_ProduceConsumeIngest(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -97,7 +142,7 @@ func TestPipeline_ProduceConsumeIngest(t *testing.T) {
 	require.NoError(t, err, "create test collection")
 	defer qdrantClient.Conn().DeleteCollection(ctx, testCollection)
 
-	store := vectorstore.NewQdrantStore(qdrantClient, qdrantCfg)
+	store := vectorstore.NewQdrantStore(qdrantClient, testCollection, int(testDimension))
 
 	// Setup Repository
 	repo := NewRepository(testDB)
@@ -105,15 +150,16 @@ func TestPipeline_ProduceConsumeIngest(t *testing.T) {
 	// Setup stubs
 	embedStub := &stubEmbedService{}
 	pokemonStub := &stubPokemonService{}
+	cacheStub := &stubAnswerCache{}
 
 	// Create service and handler
-	svc := NewService(embedStub, store, pokemonStub, repo)
+	svc := NewService(embedStub, store, pokemonStub, cobblemon.NewDatapack(), repo, producer, cacheStub)
 	handler := NewHandler(svc)
 
 	// Track if handler was called
 	handlerCalled := make(chan string, 1)
 	wrappedHandler := func(ctx context.Context, payload []byte) error {
-		err := handler.HandleIngest(ctx, payload)
+		err := handler.HandleKafka(ctx, payload)
 		if err == nil {
 			var event IngestionEvent
 			json.Unmarshal(payload, &event)
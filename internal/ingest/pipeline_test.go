@@ -0,0 +1,171 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cyrene/internal/platform/config"
+	"cyrene/internal/platform/kafka"
+	"cyrene/internal/pokemon"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runPipeline consumes the ingestion topic of broker with the handler until done holds.
+func runPipeline(t *testing.T, broker *kafka.MemoryBroker, handler *Handler, done func() bool) {
+	t.Helper()
+	consumer := broker.NewConsumer(&config.KafkaConfig{
+		ConsumerGroup:     "cyrene",
+		Concurrency:       1,
+		MaxRetries:        1,
+		RetryBackoffMS:    1,
+		RetryMaxBackoffMS: 1,
+		StallTimeoutSec:   60,
+	}, map[string]kafka.Handler{
+		string(TopicIngestion): handler.HandleKafka,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- consumer.Run(ctx) }()
+
+	assert.Eventually(t, done, 5*time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-stopped)
+}
+
+func (m *mockRepository) statuses(ref string) []JobStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]JobStatus(nil), m.jobStatuses[ref]...)
+}
+
+func TestPipeline_EnqueueAndConsume(t *testing.T) {
+	pokemonGetter := &mockPokemonGetter{
+		getFn: func(ctx context.Context, id string) (*pokemon.Pokemon, error) {
+			if id == "99999" {
+				return nil, errors.New("pokemon not found")
+			}
+			return &pokemon.Pokemon{ID: id, Identifier: "pokemon-" + id}, nil
+		},
+	}
+	embedder := &mockEmbedder{
+		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+			return make([][]float32, len(texts)), nil
+		},
+	}
+	store := &mockStore{}
+	repo := &mockRepository{}
+	broker := kafka.NewMemoryBroker(2)
+
+	svc := NewService(embedder, store, pokemonGetter, &mockDatapack{}, repo, broker.Producer(), &mockCache{})
+	handler := NewHandler(svc)
+
+	_, err := svc.Enqueue(context.Background(), BatchIngestionEvent{Type: DocumentTypePokemon, IDs: []string{"25"}})
+	require.NoError(t, err)
+	_, err = svc.Enqueue(context.Background(), BatchIngestionEvent{Type: DocumentTypePokemon, IDs: []string{"99999"}})
+	require.NoError(t, err)
+
+	dlq := kafka.DeadLetterTopic(string(TopicIngestion))
+	runPipeline(t, broker, handler, func() bool {
+		return len(repo.statuses("pokemon_25")) == 2 && len(broker.Records(dlq)) == 1
+	})
+
+	assert.Equal(t, []JobStatus{JobStatusRunning, JobStatusSucceeded}, repo.statuses("pokemon_25"))
+	assert.Equal(t, []JobStatus{JobStatusRunning, JobStatusRetrying, JobStatusRunning, JobStatusFailed},
+		repo.statuses("pokemon_99999"), "the failing document is retried once")
+	failed := broker.Records(string(TopicIngestionFailed))
+	require.Len(t, failed, 1, "the failure is published once, after the last attempt")
+	assert.Equal(t, "pokemon_99999", string(failed[0].Key))
+	assert.Equal(t, "pokemon_25", store.upserted[0].Payload[referenceKey])
+
+	dead := broker.Records(dlq)[0]
+	assert.Equal(t, "pokemon_99999", string(dead.Key))
+	for _, h := range dead.Headers {
+		switch h.Key {
+		case kafka.HeaderError:
+			assert.Contains(t, string(h.Value), "pokemon not found")
+		case kafka.HeaderOriginalTopic:
+			assert.Equal(t, string(TopicIngestion), string(h.Value))
+		case kafka.HeaderAttempts:
+			assert.Equal(t, "2", string(h.Value))
+		}
+	}
+	assert.Len(t, dead.Headers, 6)
+}
+
+func TestPipeline_RetriedDocumentPublishesOnlyItsResult(t *testing.T) {
+	var calls atomic.Int32
+	pokemonGetter := &mockPokemonGetter{
+		getFn: func(ctx context.Context, id string) (*pokemon.Pokemon, error) {
+			if calls.Add(1) == 1 {
+				return nil, errors.New("pokeapi unavailable")
+			}
+			return &pokemon.Pokemon{ID: id, Identifier: "pikachu"}, nil
+		},
+	}
+	embedder := &mockEmbedder{
+		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+			return make([][]float32, len(texts)), nil
+		},
+	}
+	repo := &mockRepository{}
+	broker := kafka.NewMemoryBroker(1)
+
+	svc := NewService(embedder, &mockStore{}, pokemonGetter, &mockDatapack{}, repo, broker.Producer(), &mockCache{})
+
+	_, err := svc.Enqueue(context.Background(), BatchIngestionEvent{Type: DocumentTypePokemon, IDs: []string{"25"}})
+	require.NoError(t, err)
+
+	runPipeline(t, broker, NewHandler(svc), func() bool {
+		return len(broker.Records(string(TopicIngestionCompleted))) == 1
+	})
+
+	assert.Equal(t, []JobStatus{JobStatusRunning, JobStatusRetrying, JobStatusRunning, JobStatusSucceeded}, repo.statuses("pokemon_25"))
+	assert.Empty(t, broker.Records(string(TopicIngestionFailed)), "a failure that was retried successfully is not published")
+	assert.Empty(t, broker.Records(kafka.DeadLetterTopic(string(TopicIngestion))))
+}
+
+func TestPipeline_ReplayedDeadLetterIsIngested(t *testing.T) {
+	available := false
+	pokemonGetter := &mockPokemonGetter{
+		getFn: func(ctx context.Context, id string) (*pokemon.Pokemon, error) {
+			if !available {
+				return nil, errors.New("pokeapi unavailable")
+			}
+			return &pokemon.Pokemon{ID: id, Identifier: "pikachu"}, nil
+		},
+	}
+	embedder := &mockEmbedder{
+		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+			return make([][]float32, len(texts)), nil
+		},
+	}
+	repo := &mockRepository{}
+	broker := kafka.NewMemoryBroker(1)
+
+	svc := NewService(embedder, &mockStore{}, pokemonGetter, &mockDatapack{}, repo, broker.Producer(), &mockCache{})
+	handler := NewHandler(svc)
+
+	_, err := svc.Enqueue(context.Background(), BatchIngestionEvent{Type: DocumentTypePokemon, IDs: []string{"25"}})
+	require.NoError(t, err)
+
+	dlq := kafka.DeadLetterTopic(string(TopicIngestion))
+	runPipeline(t, broker, handler, func() bool { return len(broker.Records(dlq)) == 1 })
+
+	available = true
+	replayed, err := broker.ReplayDeadLetters(context.Background(), dlq)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+
+	runPipeline(t, broker, handler, func() bool {
+		statuses := repo.statuses("pokemon_25")
+		return len(statuses) > 0 && statuses[len(statuses)-1] == JobStatusSucceeded
+	})
+	require.NotNil(t, repo.upserted)
+	assert.Equal(t, "25", repo.upserted.ExternalID)
+}
@@ -2,7 +2,9 @@ package ingest
 
 import (
 	"context"
+	"time"
 
+	"cyrene/internal/cobblemon"
 	"cyrene/internal/platform/vectorstore"
 	"cyrene/internal/pokemon"
 
@@ -11,20 +13,54 @@ import (
 
 type Service interface {
 	Ingest(ctx context.Context, event IngestionEvent) error
+	IngestBatch(ctx context.Context, batch BatchIngestionEvent) (*BatchResult, error)
+	Enqueue(ctx context.Context, batches ...BatchIngestionEvent) (*IngestionJob, error)
+	GetJob(ctx context.Context, id uuid.UUID) (*IngestionJob, error)
+	ImportDatapack(ctx context.Context) (*IngestionJob, error)
+	Reconcile(ctx context.Context, repair bool) (*DriftReport, error)
+	Delete(ctx context.Context, docType DocumentType, externalID string) error
+	ListDocuments(ctx context.Context, filter DocumentFilter) (*DocumentPage, error)
+	GetDocument(ctx context.Context, docType DocumentType, externalID string) (*DocumentDetail, error)
 }
 
 type embedService interface {
 	Embed(ctx context.Context, dimensions int, texts ...string) ([][]float32, error)
+	EmbedModel() string
+}
+
+type answerCache interface {
+	InvalidateReferences(ctx context.Context, references ...string) error
 }
 
 type pokemonService interface {
 	GetPokemonByID(ctx context.Context, id string) (*pokemon.Pokemon, error)
+	GetMoveByID(ctx context.Context, id string) (*pokemon.Move, error)
+	GetAbilityByID(ctx context.Context, id string) (*pokemon.Ability, error)
+	GetItemByID(ctx context.Context, id string) (*pokemon.Item, error)
+	GetTypeByID(ctx context.Context, id string) (*pokemon.Type, error)
+	GetSpeciesByID(ctx context.Context, id string) (*pokemon.Species, error)
+	GetEvolutionChainByID(ctx context.Context, id string) (*pokemon.EvolutionChain, error)
+}
+
+type datapackSource interface {
+	GetSpeciesByID(ctx context.Context, id string) (*cobblemon.Species, error)
+	GetSpawnPoolByID(ctx context.Context, id string) (*cobblemon.SpawnPool, error)
+	SpeciesIDs() []string
+	SpawnPoolIDs() []string
+}
+
+type producer interface {
+	Produce(ctx context.Context, topic string, key, value []byte) error
 }
 
 type vectorStore interface {
 	Upsert(ctx context.Context, points ...vectorstore.Point) error
 	Delete(ctx context.Context, filter vectorstore.Filter) error
 	Dimensions() int
+	Count(ctx context.Context) (int, error)
+	PointsByPayload(ctx context.Context, field string) (map[string][]string, error)
+	DeleteByID(ctx context.Context, ids ...string) error
+	Scroll(ctx context.Context, filter vectorstore.Filter) ([]vectorstore.Point, error)
 }
 
 type Repository interface {
@@ -32,5 +68,20 @@ type Repository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	DeleteByRef(ctx context.Context, dt DocumentType, externalID string) error
 	FindByRef(ctx context.Context, dt DocumentType, externalID string) (*IngestedDocument, error)
+	List(ctx context.Context, after uuid.UUID, limit int) ([]*IngestedDocument, error)
+	Count(ctx context.Context) (int, error)
+	ListDocuments(ctx context.Context, filter DocumentFilter) ([]*IngestedDocument, error)
+	CountDocuments(ctx context.Context, filter DocumentFilter) (int, error)
+	FindStale(ctx context.Context, embedModel string, dim int, after uuid.UUID, limit int) ([]*IngestedDocument, error)
 	InTx(ctx context.Context, fn func(Repository) error) error
+
+	AddOutbox(ctx context.Context, entries ...*OutboxEntry) error
+	ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]*OutboxEntry, error)
+	UpdateOutbox(ctx context.Context, entry *OutboxEntry, attempts int) error
+	RequeueOutbox(ctx context.Context, reference string) error
+	PurgeOutbox(ctx context.Context, status OutboxStatus, before time.Time) (int, error)
+
+	CreateJob(ctx context.Context, job *IngestionJob) error
+	UpdateJobDocuments(ctx context.Context, jobID uuid.UUID, dt DocumentType, externalIDs []string, status JobStatus, errMsg string) error
+	FindJob(ctx context.Context, jobID uuid.UUID) (*IngestionJob, error)
 }
@@ -1,12 +1,15 @@
 package ingest
 
 import (
+	"bytes"
 	"context"
 	"cyrene/internal/platform/postgres/jet/cyrene/public/model"
 	"cyrene/internal/platform/postgres/jet/cyrene/public/table"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/go-jet/jet/v2/postgres"
@@ -38,12 +41,20 @@ func (r *postgresRepository) Upsert(ctx context.Context, doc *IngestedDocument)
 		table.IngestedDocuments.ID,
 		table.IngestedDocuments.DocumentType,
 		table.IngestedDocuments.ExternalID,
+		table.IngestedDocuments.ContentHash,
+		table.IngestedDocuments.EmbeddingModel,
+		table.IngestedDocuments.EmbeddingDim,
+		table.IngestedDocuments.Content,
 		table.IngestedDocuments.CreatedAt,
 		table.IngestedDocuments.UpdatedAt,
 	).VALUES(
 		doc.ID,
 		doc.DocumentType,
 		doc.ExternalID,
+		doc.ContentHash,
+		doc.EmbeddingModel,
+		doc.EmbeddingDim,
+		doc.Content,
 		now,
 		now,
 	).ON_CONFLICT(
@@ -51,6 +62,10 @@ func (r *postgresRepository) Upsert(ctx context.Context, doc *IngestedDocument)
 		table.IngestedDocuments.ExternalID,
 	).DO_UPDATE(
 		postgres.SET(
+			table.IngestedDocuments.ContentHash.SET(table.IngestedDocuments.EXCLUDED.ContentHash),
+			table.IngestedDocuments.EmbeddingModel.SET(table.IngestedDocuments.EXCLUDED.EmbeddingModel),
+			table.IngestedDocuments.EmbeddingDim.SET(table.IngestedDocuments.EXCLUDED.EmbeddingDim),
+			table.IngestedDocuments.Content.SET(table.IngestedDocuments.EXCLUDED.Content),
 			table.IngestedDocuments.UpdatedAt.SET(postgres.TimestampzT(now)),
 		),
 	)
@@ -105,6 +120,118 @@ func (r *postgresRepository) FindByRef(ctx context.Context, dt DocumentType, ext
 	return toDomain(&dest), nil
 }
 
+// List returns documents ordered by ID, starting after the given ID for paging.
+func (r *postgresRepository) List(ctx context.Context, after uuid.UUID, limit int) ([]*IngestedDocument, error) {
+	stmt := postgres.SELECT(table.IngestedDocuments.AllColumns).
+		FROM(table.IngestedDocuments).
+		WHERE(table.IngestedDocuments.ID.GT(postgres.UUID(after))).
+		ORDER_BY(table.IngestedDocuments.ID.ASC()).
+		LIMIT(int64(limit))
+
+	var dest []model.IngestedDocuments
+	err := stmt.QueryContext(ctx, r.db, &dest)
+	if err != nil {
+		return nil, fmt.Errorf("list documents: %w", err)
+	}
+
+	return toDomainList(dest), nil
+}
+
+func (r *postgresRepository) Count(ctx context.Context) (int, error) {
+	stmt := postgres.SELECT(postgres.COUNT(postgres.STAR).AS("count")).
+		FROM(table.IngestedDocuments)
+
+	var dest struct {
+		Count int64
+	}
+	err := stmt.QueryContext(ctx, r.db, &dest)
+	if err != nil {
+		return 0, fmt.Errorf("count documents: %w", err)
+	}
+	return int(dest.Count), nil
+}
+
+// ListDocuments returns one page of the documents matching the filter, ordered by ID.
+func (r *postgresRepository) ListDocuments(ctx context.Context, filter DocumentFilter) ([]*IngestedDocument, error) {
+	stmt := postgres.SELECT(table.IngestedDocuments.AllColumns).
+		FROM(table.IngestedDocuments).
+		WHERE(
+			documentCondition(filter).
+				AND(table.IngestedDocuments.ID.GT(postgres.UUID(filter.After))),
+		).
+		ORDER_BY(table.IngestedDocuments.ID.ASC()).
+		LIMIT(int64(filter.Limit))
+
+	var dest []model.IngestedDocuments
+	err := stmt.QueryContext(ctx, r.db, &dest)
+	if err != nil {
+		return nil, fmt.Errorf("list documents: %w", err)
+	}
+
+	return toDomainList(dest), nil
+}
+
+// CountDocuments returns how many documents match the filter, ignoring its paging fields.
+func (r *postgresRepository) CountDocuments(ctx context.Context, filter DocumentFilter) (int, error) {
+	stmt := postgres.SELECT(postgres.COUNT(postgres.STAR).AS("count")).
+		FROM(table.IngestedDocuments).
+		WHERE(documentCondition(filter))
+
+	var dest struct {
+		Count int64
+	}
+	err := stmt.QueryContext(ctx, r.db, &dest)
+	if err != nil {
+		return 0, fmt.Errorf("count documents: %w", err)
+	}
+	return int(dest.Count), nil
+}
+
+// documentCondition translates the type and time bounds of a DocumentFilter.
+func documentCondition(filter DocumentFilter) postgres.BoolExpression {
+	cond := postgres.Bool(true)
+	if filter.Type != "" {
+		cond = cond.AND(table.IngestedDocuments.DocumentType.EQ(postgres.String(string(filter.Type))))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		cond = cond.AND(table.IngestedDocuments.CreatedAt.GT(postgres.TimestampzT(filter.CreatedAfter)))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		cond = cond.AND(table.IngestedDocuments.CreatedAt.LT(postgres.TimestampzT(filter.CreatedBefore)))
+	}
+	if !filter.UpdatedAfter.IsZero() {
+		cond = cond.AND(table.IngestedDocuments.UpdatedAt.GT(postgres.TimestampzT(filter.UpdatedAfter)))
+	}
+	if !filter.UpdatedBefore.IsZero() {
+		cond = cond.AND(table.IngestedDocuments.UpdatedAt.LT(postgres.TimestampzT(filter.UpdatedBefore)))
+	}
+	return cond
+}
+
+// FindStale returns documents whose vectors were not produced by the given embedding
+// model and dimension, ordered by ID and starting after the given ID for paging.
+func (r *postgresRepository) FindStale(ctx context.Context, embedModel string, dim int, after uuid.UUID, limit int) ([]*IngestedDocument, error) {
+	stmt := postgres.SELECT(table.IngestedDocuments.AllColumns).
+		FROM(table.IngestedDocuments).
+		WHERE(
+			table.IngestedDocuments.ID.GT(postgres.UUID(after)).
+				AND(
+					table.IngestedDocuments.EmbeddingModel.NOT_EQ(postgres.String(embedModel)).
+						OR(table.IngestedDocuments.EmbeddingDim.NOT_EQ(postgres.Int(int64(dim)))),
+				),
+		).
+		ORDER_BY(table.IngestedDocuments.ID.ASC()).
+		LIMIT(int64(limit))
+
+	var dest []model.IngestedDocuments
+	err := stmt.QueryContext(ctx, r.db, &dest)
+	if err != nil {
+		return nil, fmt.Errorf("find stale documents: %w", err)
+	}
+
+	return toDomainList(dest), nil
+}
+
 func (r *postgresRepository) InTx(ctx context.Context, fn func(Repository) error) error {
 	tx, err := r.conn.BeginTx(ctx, nil)
 	if err != nil {
@@ -128,13 +255,375 @@ func (r *postgresRepository) InTx(ctx context.Context, fn func(Repository) error
 	return nil
 }
 
+func (r *postgresRepository) CreateJob(ctx context.Context, job *IngestionJob) error {
+	if len(job.Documents) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	stmt := table.IngestionJobs.INSERT(
+		table.IngestionJobs.ID,
+		table.IngestionJobs.JobID,
+		table.IngestionJobs.DocumentType,
+		table.IngestionJobs.ExternalID,
+		table.IngestionJobs.Status,
+		table.IngestionJobs.CreatedAt,
+		table.IngestionJobs.UpdatedAt,
+	)
+	for _, doc := range job.Documents {
+		stmt = stmt.VALUES(
+			uuid.Must(uuid.NewV7()),
+			job.ID,
+			doc.DocumentType,
+			doc.ExternalID,
+			doc.Status,
+			now,
+			now,
+		)
+	}
+
+	_, err := stmt.ExecContext(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("create job: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresRepository) UpdateJobDocuments(
+	ctx context.Context,
+	jobID uuid.UUID,
+	dt DocumentType,
+	externalIDs []string,
+	status JobStatus,
+	errMsg string,
+) error {
+	if len(externalIDs) == 0 {
+		return nil
+	}
+
+	ids := make([]postgres.Expression, len(externalIDs))
+	for i, id := range externalIDs {
+		ids[i] = postgres.String(id)
+	}
+
+	var errExpr postgres.Expression = postgres.NULL
+	if errMsg != "" {
+		errExpr = postgres.String(errMsg)
+	}
+
+	stmt := table.IngestionJobs.UPDATE(
+		table.IngestionJobs.Status,
+		table.IngestionJobs.Error,
+		table.IngestionJobs.UpdatedAt,
+	).SET(
+		postgres.String(string(status)),
+		errExpr,
+		postgres.TimestampzT(time.Now()),
+	).WHERE(
+		table.IngestionJobs.JobID.EQ(postgres.UUID(jobID)).
+			AND(table.IngestionJobs.DocumentType.EQ(postgres.String(string(dt)))).
+			AND(table.IngestionJobs.ExternalID.IN(ids...)),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("update job: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresRepository) FindJob(ctx context.Context, jobID uuid.UUID) (*IngestionJob, error) {
+	stmt := postgres.SELECT(table.IngestionJobs.AllColumns).
+		FROM(table.IngestionJobs).
+		WHERE(table.IngestionJobs.JobID.EQ(postgres.UUID(jobID))).
+		ORDER_BY(table.IngestionJobs.DocumentType.ASC(), table.IngestionJobs.ExternalID.ASC())
+
+	var dest []model.IngestionJobs
+	err := stmt.QueryContext(ctx, r.db, &dest)
+	if err != nil {
+		return nil, fmt.Errorf("find job: %w", err)
+	}
+	if len(dest) == 0 {
+		return nil, ErrJobNotFound
+	}
+
+	return toJobDomain(jobID, dest), nil
+}
+
+// AddOutbox records vector writes. Pending entries for the same references are
+// superseded, since each new entry fully replaces the vectors of its reference.
+func (r *postgresRepository) AddOutbox(ctx context.Context, entries ...*OutboxEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	refs := make([]postgres.Expression, len(entries))
+	for i, entry := range entries {
+		refs[i] = postgres.String(entry.Reference)
+	}
+
+	supersede := table.VectorOutbox.UPDATE(
+		table.VectorOutbox.Status,
+		table.VectorOutbox.UpdatedAt,
+	).SET(
+		postgres.String(string(OutboxSuperseded)),
+		postgres.TimestampzT(now),
+	).WHERE(
+		table.VectorOutbox.Status.EQ(postgres.String(string(OutboxPending))).
+			AND(table.VectorOutbox.Reference.IN(refs...)),
+	)
+
+	if _, err := supersede.ExecContext(ctx, r.db); err != nil {
+		return fmt.Errorf("supersede outbox: %w", err)
+	}
+
+	stmt := table.VectorOutbox.INSERT(
+		table.VectorOutbox.ID,
+		table.VectorOutbox.Reference,
+		table.VectorOutbox.Operation,
+		table.VectorOutbox.Points,
+		table.VectorOutbox.Status,
+		table.VectorOutbox.Attempts,
+		table.VectorOutbox.NextAttemptAt,
+		table.VectorOutbox.CreatedAt,
+		table.VectorOutbox.UpdatedAt,
+	)
+	for _, entry := range entries {
+		points, err := json.Marshal(entry.Points)
+		if err != nil {
+			return fmt.Errorf("marshal outbox points: %w", err)
+		}
+		stmt = stmt.VALUES(
+			entry.ID,
+			entry.Reference,
+			entry.Operation,
+			string(points),
+			entry.Status,
+			entry.Attempts,
+			entry.NextAttemptAt,
+			now,
+			now,
+		)
+	}
+
+	if _, err := stmt.ExecContext(ctx, r.db); err != nil {
+		return fmt.Errorf("add outbox: %w", err)
+	}
+	return nil
+}
+
+// ClaimOutbox leases up to limit due pending entries, oldest first, by pushing their
+// next attempt past the lease. Rows claimed by another dispatcher are skipped, and
+// the writes themselves happen outside the claiming statement.
+func (r *postgresRepository) ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]*OutboxEntry, error) {
+	now := time.Now()
+
+	due := postgres.SELECT(table.VectorOutbox.ID).
+		FROM(table.VectorOutbox).
+		WHERE(
+			table.VectorOutbox.Status.EQ(postgres.String(string(OutboxPending))).
+				AND(table.VectorOutbox.NextAttemptAt.LT_EQ(postgres.TimestampzT(now))),
+		).
+		ORDER_BY(table.VectorOutbox.ID.ASC()).
+		LIMIT(int64(limit)).
+		FOR(postgres.UPDATE().SKIP_LOCKED())
+
+	stmt := table.VectorOutbox.UPDATE(
+		table.VectorOutbox.NextAttemptAt,
+		table.VectorOutbox.UpdatedAt,
+	).SET(
+		postgres.TimestampzT(now.Add(lease)),
+		postgres.TimestampzT(now),
+	).WHERE(
+		table.VectorOutbox.ID.IN(due),
+	).RETURNING(table.VectorOutbox.AllColumns)
+
+	var dest []model.VectorOutbox
+	err := stmt.QueryContext(ctx, r.db, &dest)
+	if err != nil {
+		return nil, fmt.Errorf("claim outbox: %w", err)
+	}
+
+	entries := make([]*OutboxEntry, len(dest))
+	for i := range dest {
+		entry, err := toOutboxDomain(&dest[i])
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = entry
+	}
+	slices.SortFunc(entries, func(a, b *OutboxEntry) int {
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+	return entries, nil
+}
+
+// UpdateOutbox records the outcome of a write. It only applies while the entry is
+// still pending with the given number of attempts, and returns ErrOutboxSuperseded
+// when the entry was superseded or requeued in the meantime.
+func (r *postgresRepository) UpdateOutbox(ctx context.Context, entry *OutboxEntry, attempts int) error {
+	var lastError postgres.Expression = postgres.NULL
+	if entry.LastError != "" {
+		lastError = postgres.String(entry.LastError)
+	}
+
+	stmt := table.VectorOutbox.UPDATE(
+		table.VectorOutbox.Status,
+		table.VectorOutbox.Attempts,
+		table.VectorOutbox.LastError,
+		table.VectorOutbox.NextAttemptAt,
+		table.VectorOutbox.UpdatedAt,
+	).SET(
+		postgres.String(string(entry.Status)),
+		postgres.Int(int64(entry.Attempts)),
+		lastError,
+		postgres.TimestampzT(entry.NextAttemptAt),
+		postgres.TimestampzT(time.Now()),
+	).WHERE(
+		table.VectorOutbox.ID.EQ(postgres.UUID(entry.ID)).
+			AND(table.VectorOutbox.Status.EQ(postgres.String(string(OutboxPending)))).
+			AND(table.VectorOutbox.Attempts.EQ(postgres.Int(int64(attempts)))),
+	)
+
+	res, err := stmt.ExecContext(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("update outbox: %w", err)
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update outbox: %w", err)
+	}
+	if updated == 0 {
+		return ErrOutboxSuperseded
+	}
+	return nil
+}
+
+// RequeueOutbox makes the newest entry of the reference due again, so that its
+// write is reapplied after a stale write of an older entry. A failed newest entry
+// is left alone. The attempts are bumped so an outcome recorded for the earlier
+// write no longer applies.
+func (r *postgresRepository) RequeueOutbox(ctx context.Context, reference string) error {
+	now := time.Now()
+
+	newest := postgres.SELECT(table.VectorOutbox.ID).
+		FROM(table.VectorOutbox).
+		WHERE(table.VectorOutbox.Reference.EQ(postgres.String(reference))).
+		ORDER_BY(table.VectorOutbox.ID.DESC()).
+		LIMIT(1)
+
+	stmt := table.VectorOutbox.UPDATE(
+		table.VectorOutbox.Status,
+		table.VectorOutbox.Attempts,
+		table.VectorOutbox.NextAttemptAt,
+		table.VectorOutbox.UpdatedAt,
+	).SET(
+		postgres.String(string(OutboxPending)),
+		table.VectorOutbox.Attempts.ADD(postgres.Int(1)),
+		postgres.TimestampzT(now),
+		postgres.TimestampzT(now),
+	).WHERE(
+		table.VectorOutbox.ID.IN(newest).
+			AND(table.VectorOutbox.Status.IN(
+				postgres.String(string(OutboxPending)),
+				postgres.String(string(OutboxDone)),
+			)),
+	)
+
+	if _, err := stmt.ExecContext(ctx, r.db); err != nil {
+		return fmt.Errorf("requeue outbox: %w", err)
+	}
+	return nil
+}
+
+// PurgeOutbox deletes entries with the given status that were last updated before
+// the cutoff and returns how many were deleted.
+func (r *postgresRepository) PurgeOutbox(ctx context.Context, status OutboxStatus, before time.Time) (int, error) {
+	stmt := table.VectorOutbox.DELETE().
+		WHERE(
+			table.VectorOutbox.Status.EQ(postgres.String(string(status))).
+				AND(table.VectorOutbox.UpdatedAt.LT(postgres.TimestampzT(before))),
+		)
+
+	res, err := stmt.ExecContext(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("purge outbox: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("purge outbox: %w", err)
+	}
+	return int(deleted), nil
+}
+
 // toDomain maps ingestedDocuments Jet model to domain struct
 func toDomain(m *model.IngestedDocuments) *IngestedDocument {
 	return &IngestedDocument{
-		ID:           m.ID,
-		DocumentType: DocumentType(m.DocumentType),
-		ExternalID:   m.ExternalID,
-		CreatedAt:    m.CreatedAt,
-		UpdatedAt:    m.UpdatedAt,
+		ID:             m.ID,
+		DocumentType:   DocumentType(m.DocumentType),
+		ExternalID:     m.ExternalID,
+		ContentHash:    m.ContentHash,
+		EmbeddingModel: m.EmbeddingModel,
+		EmbeddingDim:   int(m.EmbeddingDim),
+		Content:        m.Content,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+	}
+}
+
+func toDomainList(rows []model.IngestedDocuments) []*IngestedDocument {
+	docs := make([]*IngestedDocument, len(rows))
+	for i := range rows {
+		docs[i] = toDomain(&rows[i])
+	}
+	return docs
+}
+
+// toOutboxDomain maps the VectorOutbox Jet model to a domain struct
+func toOutboxDomain(m *model.VectorOutbox) (*OutboxEntry, error) {
+	entry := &OutboxEntry{
+		ID:            m.ID,
+		Reference:     m.Reference,
+		Operation:     OutboxOperation(m.Operation),
+		Status:        OutboxStatus(m.Status),
+		Attempts:      int(m.Attempts),
+		NextAttemptAt: m.NextAttemptAt,
+		CreatedAt:     m.CreatedAt,
+	}
+	if m.LastError != nil {
+		entry.LastError = *m.LastError
+	}
+	if err := json.Unmarshal([]byte(m.Points), &entry.Points); err != nil {
+		return nil, fmt.Errorf("unmarshal outbox points %s: %w", m.ID, err)
+	}
+	return entry, nil
+}
+
+// toJobDomain maps the per-document ingestionJobs Jet rows of one job to a domain struct
+func toJobDomain(jobID uuid.UUID, rows []model.IngestionJobs) *IngestionJob {
+	job := &IngestionJob{
+		ID:        jobID,
+		Documents: make([]*JobDocument, len(rows)),
+	}
+	for i, m := range rows {
+		doc := &JobDocument{
+			DocumentType: DocumentType(m.DocumentType),
+			ExternalID:   m.ExternalID,
+			Status:       JobStatus(m.Status),
+			UpdatedAt:    m.UpdatedAt,
+		}
+		if m.Error != nil {
+			doc.Error = *m.Error
+		}
+		job.Documents[i] = doc
+
+		if i == 0 || m.CreatedAt.Before(job.CreatedAt) {
+			job.CreatedAt = m.CreatedAt
+		}
 	}
+	job.summarize()
+	return job
 }
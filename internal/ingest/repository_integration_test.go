@@ -11,6 +11,8 @@ import (
 	"testing"
 	"time"
 
+	"cyrene/internal/platform/vectorstore"
+
 	"github.com/google/uuid"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/stretchr/testify/assert"
@@ -114,9 +116,13 @@ func TestRepository_Upsert_Update(t *testing.T) {
 	time.Sleep(10 * time.Millisecond)
 
 	doc2 := &IngestedDocument{
-		ID:           uuid.Must(uuid.NewV7()),
-		DocumentType: DocumentTypePokemon,
-		ExternalID:   "test-pokemon-2",
+		ID:             uuid.Must(uuid.NewV7()),
+		DocumentType:   DocumentTypePokemon,
+		ExternalID:     "test-pokemon-2",
+		ContentHash:    ContentHash("updated"),
+		EmbeddingModel: "embed/test",
+		EmbeddingDim:   4,
+		Content:        "updated",
 	}
 	err = repo.Upsert(ctx, doc2)
 	require.NoError(t, err)
@@ -125,6 +131,8 @@ func TestRepository_Upsert_Update(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Equal(t, original.ID, updated.ID)
+	assert.True(t, updated.IsCurrent(ContentHash("updated"), "embed/test", 4))
+	assert.Equal(t, "updated", updated.Content)
 	assert.True(t, updated.UpdatedAt.After(original.UpdatedAt) || updated.UpdatedAt.Equal(original.UpdatedAt))
 }
 
@@ -250,3 +258,201 @@ func TestRepository_InTx_Rollback(t *testing.T) {
 	_, err = repo.FindByRef(ctx, DocumentTypePokemon, "test-tx-rollback")
 	assert.ErrorIs(t, err, ErrNotFound)
 }
+
+func TestRepository_Jobs(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepository(testDB)
+
+	job := &IngestionJob{
+		ID: uuid.Must(uuid.NewV7()),
+		Documents: []*JobDocument{
+			{DocumentType: DocumentTypePokemon, ExternalID: "test-job-1", Status: JobStatusQueued},
+			{DocumentType: DocumentTypePokemon, ExternalID: "test-job-2", Status: JobStatusQueued},
+		},
+	}
+	defer testDB.ExecContext(ctx, "DELETE FROM ingestion_jobs WHERE job_id = $1", job.ID)
+
+	require.NoError(t, repo.CreateJob(ctx, job))
+
+	found, err := repo.FindJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, JobStatusQueued, found.Status)
+	assert.Len(t, found.Documents, 2)
+
+	err = repo.UpdateJobDocuments(ctx, job.ID, DocumentTypePokemon, []string{"test-job-1"}, JobStatusSucceeded, "")
+	require.NoError(t, err)
+	err = repo.UpdateJobDocuments(ctx, job.ID, DocumentTypePokemon, []string{"test-job-2"}, JobStatusFailed, "boom")
+	require.NoError(t, err)
+
+	found, err = repo.FindJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, JobStatusFailed, found.Status)
+	assert.Equal(t, JobStatusSucceeded, found.Documents[0].Status)
+	assert.Equal(t, "boom", found.Documents[1].Error)
+
+	_, err = repo.FindJob(ctx, uuid.Must(uuid.NewV7()))
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
+
+func TestRepository_FindStale(t *testing.T) {
+	ids := []string{"test-stale-current", "test-stale-model", "test-stale-dim"}
+	cleanupTestData(t, ids...)
+	defer cleanupTestData(t, ids...)
+
+	ctx := context.Background()
+	repo := NewRepository(testDB)
+
+	docs := []*IngestedDocument{
+		{ExternalID: ids[0], EmbeddingModel: "embed/current", EmbeddingDim: 4},
+		{ExternalID: ids[1], EmbeddingModel: "embed/old", EmbeddingDim: 4},
+		{ExternalID: ids[2], EmbeddingModel: "embed/current", EmbeddingDim: 1536},
+	}
+	for _, doc := range docs {
+		doc.ID = uuid.Must(uuid.NewV7())
+		doc.DocumentType = DocumentTypePokemon
+		require.NoError(t, repo.Upsert(ctx, doc))
+	}
+
+	stale, err := repo.FindStale(ctx, "embed/current", 4, docs[0].ID, 10)
+	require.NoError(t, err)
+
+	var found []string
+	for _, doc := range stale {
+		found = append(found, doc.ExternalID)
+	}
+	assert.Contains(t, found, ids[1])
+	assert.Contains(t, found, ids[2])
+	assert.NotContains(t, found, ids[0])
+
+	stale, err = repo.FindStale(ctx, "embed/current", 4, docs[1].ID, 1)
+	require.NoError(t, err)
+	require.Len(t, stale, 1)
+	assert.Equal(t, ids[2], stale[0].ExternalID)
+}
+
+func TestRepository_Outbox(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepository(testDB)
+	defer testDB.ExecContext(ctx, "DELETE FROM vector_outbox WHERE reference LIKE 'test_outbox_%'")
+
+	point := vectorstore.Point{
+		ID:      uuid.Must(uuid.NewV7()).String(),
+		Vector:  []float32{0.1, 0.2},
+		Payload: map[string]any{referenceKey: "test_outbox_1"},
+	}
+	first := &OutboxEntry{
+		ID:        uuid.Must(uuid.NewV7()),
+		Reference: "test_outbox_1",
+		Operation: OutboxUpsert,
+		Points:    []vectorstore.Point{point},
+		Status:    OutboxPending,
+	}
+	require.NoError(t, repo.AddOutbox(ctx, first))
+
+	claimed, err := repo.ClaimOutbox(ctx, 100, time.Minute)
+	require.NoError(t, err)
+	found := findOutboxEntry(claimed, first.ID)
+	require.NotNil(t, found)
+	assert.Equal(t, OutboxUpsert, found.Operation)
+	require.Len(t, found.Points, 1)
+	assert.Equal(t, point.ID, found.Points[0].ID)
+	assert.Equal(t, point.Vector, found.Points[0].Vector)
+
+	claimed, err = repo.ClaimOutbox(ctx, 100, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, findOutboxEntry(claimed, first.ID), "leased by the earlier claim")
+
+	second := &OutboxEntry{
+		ID:            uuid.Must(uuid.NewV7()),
+		Reference:     "test_outbox_1",
+		Operation:     OutboxDelete,
+		Status:        OutboxPending,
+		NextAttemptAt: time.Now().Add(time.Hour),
+	}
+	require.NoError(t, repo.AddOutbox(ctx, second))
+
+	first.Status = OutboxDone
+	assert.ErrorIs(t, repo.UpdateOutbox(ctx, first, 0), ErrOutboxSuperseded, "superseded by the newer entry")
+
+	claimed, err = repo.ClaimOutbox(ctx, 100, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, findOutboxEntry(claimed, second.ID), "not yet due")
+
+	second.Attempts = 1
+	second.LastError = "boom"
+	second.NextAttemptAt = time.Now().Add(-time.Second)
+	require.NoError(t, repo.UpdateOutbox(ctx, second, 0))
+
+	claimed, err = repo.ClaimOutbox(ctx, 100, time.Minute)
+	require.NoError(t, err)
+	found = findOutboxEntry(claimed, second.ID)
+	require.NotNil(t, found)
+	assert.Equal(t, 1, found.Attempts)
+	assert.Equal(t, "boom", found.LastError)
+
+	second.Status = OutboxDone
+	require.NoError(t, repo.UpdateOutbox(ctx, second, 1))
+
+	claimed, err = repo.ClaimOutbox(ctx, 100, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, findOutboxEntry(claimed, second.ID))
+
+	require.NoError(t, repo.RequeueOutbox(ctx, "test_outbox_1"))
+	assert.ErrorIs(t, repo.UpdateOutbox(ctx, second, 1), ErrOutboxSuperseded, "the requeue bumps the attempts")
+
+	claimed, err = repo.ClaimOutbox(ctx, 100, time.Minute)
+	require.NoError(t, err)
+	found = findOutboxEntry(claimed, second.ID)
+	require.NotNil(t, found, "the newest entry is due again")
+	assert.Equal(t, 2, found.Attempts)
+	assert.Nil(t, findOutboxEntry(claimed, first.ID), "older entries are not requeued")
+
+	purged, err := repo.PurgeOutbox(ctx, OutboxSuperseded, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, purged, 1)
+
+	var remaining int
+	require.NoError(t, testDB.QueryRowContext(ctx, "SELECT count(*) FROM vector_outbox WHERE reference = 'test_outbox_1'").Scan(&remaining))
+	assert.Equal(t, 1, remaining, "only the superseded entry is purged")
+}
+
+func findOutboxEntry(entries []*OutboxEntry, id uuid.UUID) *OutboxEntry {
+	for _, entry := range entries {
+		if entry.ID == id {
+			return entry
+		}
+	}
+	return nil
+}
+
+func TestRepository_ListDocuments(t *testing.T) {
+	ids := []string{"test-list-1", "test-list-2", "test-list-3"}
+	cleanupTestData(t, ids...)
+	defer cleanupTestData(t, ids...)
+
+	ctx := context.Background()
+	repo := NewRepository(testDB)
+
+	start := time.Now().Add(-time.Second)
+	docs := make([]*IngestedDocument, len(ids))
+	for i, id := range ids {
+		docs[i] = &IngestedDocument{ID: uuid.Must(uuid.NewV7()), DocumentType: DocumentTypeMove, ExternalID: id}
+		require.NoError(t, repo.Upsert(ctx, docs[i]))
+	}
+
+	filter := DocumentFilter{Type: DocumentTypeMove, CreatedAfter: start, After: docs[0].ID, Limit: 1}
+	page, err := repo.ListDocuments(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, ids[1], page[0].ExternalID)
+
+	total, err := repo.CountDocuments(ctx, filter)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, total, len(ids), "paging fields do not narrow the count")
+
+	page, err = repo.ListDocuments(ctx, DocumentFilter{Type: DocumentTypeMove, CreatedBefore: start, Limit: 100})
+	require.NoError(t, err)
+	for _, doc := range page {
+		assert.NotContains(t, ids, doc.ExternalID)
+	}
+}
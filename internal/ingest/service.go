@@ -2,105 +2,1127 @@ package ingest
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"cyrene/internal/platform/cloudevents"
+	"cyrene/internal/platform/kafka"
 	"cyrene/internal/platform/vectorstore"
 
 	"github.com/google/uuid"
 )
 
+const (
+	// embedBatchSize caps how many texts are sent in a single Embed call during batch ingestion.
+	embedBatchSize = 32
+	// fetchConcurrency caps how many documents are fetched in parallel during batch ingestion.
+	fetchConcurrency = 8
+	// reindexPageSize caps how many stale documents are loaded per round during a reindex.
+	reindexPageSize = 500
+	// documentPageSize and maxDocumentPageSize are the default and largest page sizes for ListDocuments.
+	documentPageSize    = 50
+	maxDocumentPageSize = 500
+
+	// outboxGracePeriod delays dispatcher pickup of a new outbox entry so the
+	// ingesting call gets the first chance to apply it.
+	outboxGracePeriod = 30 * time.Second
+	// outboxBatchSize caps how many outbox entries are claimed per dispatch.
+	outboxBatchSize = 100
+	// outboxLease keeps claimed entries from being picked up by another dispatcher
+	// while their writes are in flight.
+	outboxLease = 5 * time.Minute
+	// outboxMaxAttempts is the number of failed writes after which an entry is given up on.
+	outboxMaxAttempts = 10
+	outboxBaseBackoff = 5 * time.Second
+	outboxMaxBackoff  = 10 * time.Minute
+	// outboxRetention and outboxFailedRetention are how long finished entries are
+	// kept before the dispatcher purges them.
+	outboxRetention       = time.Hour
+	outboxFailedRetention = 7 * 24 * time.Hour
+)
+
+// resultPublishTimeout bounds publishing an ingestion result, so a slow or
+// unreachable broker cannot hold up ingestion.
+var resultPublishTimeout = 5 * time.Second
+
 type service struct {
 	embedService   embedService
 	store          vectorStore
 	pokemonService pokemonService
+	datapack       datapackSource
 	repository     Repository
+	producer       producer
+	cache          answerCache
+}
+
+// document is a fetched source document ready to be embedded, one vector per chunk.
+type document struct {
+	docType     DocumentType
+	externalID  string
+	content     string
+	contentHash string
+	chunks      []chunk
+	vectors     [][]float32
 }
 
-func NewService(embedService embedService, store vectorStore, pokemonService pokemonService, repository Repository) *service {
+func NewService(
+	embedService embedService,
+	store vectorStore,
+	pokemonService pokemonService,
+	datapack datapackSource,
+	repository Repository,
+	producer producer,
+	cache answerCache,
+) *service {
 	return &service{
 		embedService:   embedService,
 		store:          store,
 		pokemonService: pokemonService,
+		datapack:       datapack,
 		repository:     repository,
+		producer:       producer,
+		cache:          cache,
 	}
 }
 
 func (s *service) Ingest(ctx context.Context, event IngestionEvent) error {
-	switch event.Type {
+	started := time.Now()
+	s.trackJob(ctx, event.JobID, event.Type, []string{event.ID}, JobStatusRunning, "")
+
+	doc, err := s.ingest(ctx, event)
+	if err != nil {
+		s.fail(ctx, event.JobID, event.Type, event.ID, started, err)
+		return err
+	}
+
+	s.trackJob(ctx, event.JobID, event.Type, []string{event.ID}, JobStatusSucceeded, "")
+	if doc != nil {
+		s.publishResult(ctx, completedResult(doc, event.JobID, started))
+	}
+	return nil
+}
+
+// ingest stores the event's document and returns it, or nil when its vectors were already current.
+func (s *service) ingest(ctx context.Context, event IngestionEvent) (*document, error) {
+	doc, err := s.fetchDocument(ctx, event.Type, event.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.isCurrent(ctx, doc) {
+		return nil, nil
+	}
+
+	if err := s.ingestDocuments(ctx, false, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Enqueue records a queued job covering every document in the batches and
+// publishes them to the ingestion topic for the consumer to process.
+// Batches are split into events of at most embedBatchSize documents.
+func (s *service) Enqueue(ctx context.Context, batches ...BatchIngestionEvent) (*IngestionJob, error) {
+	if len(batches) == 0 {
+		return nil, fmt.Errorf("%w: no documents to ingest", ErrInvalidBatch)
+	}
+
+	now := time.Now()
+	job := &IngestionJob{
+		ID:        uuid.Must(uuid.NewV7()),
+		Status:    JobStatusQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	expanded := make([][]string, len(batches))
+	for i, batch := range batches {
+		ids, err := batch.ExpandIDs()
+		if err != nil {
+			return nil, err
+		}
+		expanded[i] = ids
+
+		for _, id := range ids {
+			job.Documents = append(job.Documents, &JobDocument{
+				DocumentType: batch.Type,
+				ExternalID:   id,
+				Status:       JobStatusQueued,
+				UpdatedAt:    now,
+			})
+		}
+	}
+
+	if err := s.repository.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	for i, batch := range batches {
+		ids := expanded[i]
+		for start := 0; start < len(ids); start += embedBatchSize {
+			chunk := ids[start:min(start+embedBatchSize, len(ids))]
+			if err := s.publish(ctx, job.ID, batch.Type, chunk); err != nil {
+				s.trackJob(ctx, job.ID, batch.Type, ids[start:], JobStatusFailed, err.Error())
+				return nil, fmt.Errorf("publish job %s: %w", job.ID, err)
+			}
+		}
+	}
+
+	return job, nil
+}
+
+// publish produces a single IngestionEvent for one document, keyed by its
+// reference, or a BatchIngestionEvent for several, keyed by the job, each wrapped
+// in an ingestion request envelope.
+func (s *service) publish(ctx context.Context, jobID uuid.UUID, docType DocumentType, ids []string) error {
+	var key string
+	var event any
+	if len(ids) == 1 {
+		key = NewDocumentID(docType, ids[0])
+		event = IngestionEvent{Type: docType, ID: ids[0], JobID: jobID}
+	} else {
+		key = jobID.String()
+		event = BatchIngestionEvent{Type: docType, IDs: ids, JobID: jobID}
+	}
+
+	return s.produceEvent(ctx, TopicIngestion, EventTypeIngestionRequestedV1, key, event)
+}
+
+// produceEvent wraps data in an envelope of eventType and produces it to topic.
+// The key doubles as the event's subject.
+func (s *service) produceEvent(ctx context.Context, topic Topic, eventType, key string, data any) error {
+	envelope, err := cloudevents.New(ctx, eventType, EventSource, key, data)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	return s.producer.Produce(ctx, string(topic), []byte(key), payload)
+}
+
+// publishResult emits the result of ingesting a document to the completed or
+// failed topic within resultPublishTimeout. Failures are only logged, like job
+// tracking, so downstream consumers never hold up ingestion.
+func (s *service) publishResult(ctx context.Context, result IngestionResult) {
+	topic, eventType := TopicIngestionCompleted, EventTypeIngestionCompletedV1
+	if result.Error != "" {
+		topic, eventType = TopicIngestionFailed, EventTypeIngestionFailedV1
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resultPublishTimeout)
+	defer cancel()
+
+	if err := s.produceEvent(ctx, topic, eventType, result.Reference, result); err != nil {
+		slog.Warn("failed to publish ingestion result", "reference", result.Reference, "topic", topic, "error", err)
+	}
+}
+
+func completedResult(doc *document, jobID uuid.UUID, started time.Time) IngestionResult {
+	return IngestionResult{
+		Reference:   NewDocumentID(doc.docType, doc.externalID),
+		Type:        doc.docType,
+		ID:          doc.externalID,
+		JobID:       jobID,
+		Vectors:     len(doc.vectors),
+		ContentHash: doc.contentHash,
+		DurationMS:  time.Since(started).Milliseconds(),
+	}
+}
+
+func failedResult(docType DocumentType, externalID string, jobID uuid.UUID, started time.Time, err error) IngestionResult {
+	return IngestionResult{
+		Reference:  NewDocumentID(docType, externalID),
+		Type:       docType,
+		ID:         externalID,
+		JobID:      jobID,
+		DurationMS: time.Since(started).Milliseconds(),
+		Error:      err.Error(),
+	}
+}
+
+func (s *service) GetJob(ctx context.Context, id uuid.UUID) (*IngestionJob, error) {
+	return s.repository.FindJob(ctx, id)
+}
+
+// fail records a document that could not be ingested. While the consumer will
+// attempt its record again, the document is only marked as retrying: the failed
+// result is only published once the failure is final, so a document that succeeds
+// on a retry never had a failed result published first.
+func (s *service) fail(ctx context.Context, jobID uuid.UUID, docType DocumentType, id string, started time.Time, err error) {
+	if !kafka.LastAttempt(ctx) && !isPermanent(err) {
+		s.trackJob(ctx, jobID, docType, []string{id}, JobStatusRetrying, err.Error())
+		return
+	}
+	s.trackJob(ctx, jobID, docType, []string{id}, JobStatusFailed, err.Error())
+	s.publishResult(ctx, failedResult(docType, id, jobID, started, err))
+}
+
+// isPermanent reports whether err is one no retry can fix.
+func isPermanent(err error) bool {
+	return errors.Is(err, ErrUnsupportedType) || errors.Is(err, ErrInvalidBatch)
+}
+
+// trackJob records a status change for documents of a job. Events without a
+// job are ignored, and failures are only logged so tracking never fails ingestion.
+func (s *service) trackJob(ctx context.Context, jobID uuid.UUID, docType DocumentType, ids []string, status JobStatus, errMsg string) {
+	if jobID == uuid.Nil {
+		return
+	}
+
+	err := s.repository.UpdateJobDocuments(ctx, jobID, docType, ids, status, errMsg)
+	if err != nil {
+		slog.Warn("failed to update ingestion job", "job_id", jobID, "status", status, "error", err)
+	}
+}
+
+// IngestBatch fetches every document in the batch, embeds them in groups of
+// embedBatchSize and writes each group with a single vector store upsert.
+// Individual failures are reported in the result rather than aborting the batch.
+func (s *service) IngestBatch(ctx context.Context, batch BatchIngestionEvent) (*BatchResult, error) {
+	return s.ingestBatch(ctx, batch, false)
+}
+
+// ingestBatch implements IngestBatch. With force set, documents are re-embedded
+// even when the repository says their vectors are current, and their vectors are
+// written straight to the store, bypassing the outbox.
+func (s *service) ingestBatch(ctx context.Context, batch BatchIngestionEvent, force bool) (*BatchResult, error) {
+	ids, err := batch.ExpandIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	s.trackJob(ctx, batch.JobID, batch.Type, ids, JobStatusRunning, "")
+
+	result := &BatchResult{Requested: len(ids)}
+	fail := func(id string, err error) {
+		if result.Failed == nil {
+			result.Failed = make(map[string]string)
+		}
+		result.Failed[id] = err.Error()
+		s.fail(ctx, batch.JobID, batch.Type, id, started, err)
+	}
+
+	docs := make([]*document, len(ids))
+	errs := make([]error, len(ids))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, fetchConcurrency)
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			docs[i], errs[i] = s.fetchDocument(ctx, batch.Type, id)
+		}()
+	}
+	wg.Wait()
+
+	fetched := make([]*document, 0, len(docs))
+	var skipped []string
+	for i, doc := range docs {
+		if errs[i] != nil {
+			fail(ids[i], errs[i])
+			continue
+		}
+		if !force && s.isCurrent(ctx, doc) {
+			skipped = append(skipped, doc.externalID)
+			continue
+		}
+		fetched = append(fetched, doc)
+	}
+	result.Skipped = len(skipped)
+	s.trackJob(ctx, batch.JobID, batch.Type, skipped, JobStatusSucceeded, "")
+
+	for start := 0; start < len(fetched); start += embedBatchSize {
+		group := fetched[start:min(start+embedBatchSize, len(fetched))]
+		if err := s.ingestDocuments(ctx, force, group...); err != nil {
+			for _, doc := range group {
+				fail(doc.externalID, err)
+			}
+			continue
+		}
+		result.Ingested += len(group)
+
+		succeeded := make([]string, len(group))
+		for i, doc := range group {
+			succeeded[i] = doc.externalID
+		}
+		s.trackJob(ctx, batch.JobID, batch.Type, succeeded, JobStatusSucceeded, "")
+		for _, doc := range group {
+			s.publishResult(ctx, completedResult(doc, batch.JobID, started))
+		}
+	}
+
+	return result, nil
+}
+
+// fetchDocument loads the source data for a document and renders its embedding text.
+func (s *service) fetchDocument(ctx context.Context, docType DocumentType, externalID string) (*document, error) {
+	var content string
+	var err error
+
+	switch docType {
 	case DocumentTypePokemon:
-		return s.ingestPokemon(ctx, event.ID)
+		content, err = s.pokemonText(ctx, externalID)
+	case DocumentTypeMove:
+		content, err = s.moveText(ctx, externalID)
+	case DocumentTypeAbility:
+		content, err = s.abilityText(ctx, externalID)
+	case DocumentTypeItem:
+		content, err = s.itemText(ctx, externalID)
+	case DocumentTypeType:
+		content, err = s.typeText(ctx, externalID)
+	case DocumentTypeEvolutionChain:
+		content, err = s.evolutionChainText(ctx, externalID)
+	case DocumentTypeCobblemonSpecies:
+		content, err = s.cobblemonSpeciesText(ctx, externalID)
+	case DocumentTypeSpawn:
+		content, err = s.spawnText(ctx, externalID)
 	default:
-		return fmt.Errorf("unsupported document type: %s", event.Type)
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, docType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if content == "" {
+		return nil, fmt.Errorf("%s %s has empty embedding text", docType, externalID)
 	}
+
+	return &document{
+		docType:     docType,
+		externalID:  externalID,
+		content:     content,
+		contentHash: ContentHash(content),
+		chunks:      chunkText(content),
+	}, nil
+}
+
+// isCurrent reports whether the stored vectors for doc already match its content,
+// the configured embedding model and the vector store dimension.
+// Lookup failures are logged and treated as stale so the document is re-ingested.
+func (s *service) isCurrent(ctx context.Context, doc *document) bool {
+	existing, err := s.repository.FindByRef(ctx, doc.docType, doc.externalID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			slog.Warn("failed to look up ingested document", "reference", NewDocumentID(doc.docType, doc.externalID), "error", err)
+		}
+		return false
+	}
+
+	return existing.IsCurrent(doc.contentHash, s.embedService.EmbedModel(), s.store.Dimensions())
 }
 
-func (s *service) ingestPokemon(ctx context.Context, pokemonID string) error {
+func (s *service) pokemonText(ctx context.Context, pokemonID string) (string, error) {
 	pokemon, err := s.pokemonService.GetPokemonByID(ctx, pokemonID)
 	if err != nil {
-		return fmt.Errorf("fetch pokemon: %w", err)
+		return "", fmt.Errorf("fetch pokemon: %w", err)
+	}
+
+	species, err := s.pokemonService.GetSpeciesByID(ctx, pokemon.SpeciesName())
+	if err != nil {
+		return "", fmt.Errorf("fetch species: %w", err)
 	}
+	pokemon.Species = species
 
-	embeddingText := pokemon.EmbeddingText()
-	if embeddingText == "" {
-		return fmt.Errorf("pokemon %s has empty embedding text", pokemonID)
+	return pokemon.EmbeddingText(), nil
+}
+
+func (s *service) moveText(ctx context.Context, moveID string) (string, error) {
+	move, err := s.pokemonService.GetMoveByID(ctx, moveID)
+	if err != nil {
+		return "", fmt.Errorf("fetch move: %w", err)
 	}
 
-	vectors, err := s.embedService.Embed(ctx, s.store.Dimensions(), embeddingText)
+	return move.EmbeddingText(), nil
+}
+
+func (s *service) abilityText(ctx context.Context, abilityID string) (string, error) {
+	ability, err := s.pokemonService.GetAbilityByID(ctx, abilityID)
 	if err != nil {
-		return fmt.Errorf("embed pokemon: %w", err)
+		return "", fmt.Errorf("fetch ability: %w", err)
 	}
 
-	if len(vectors) == 0 {
-		return fmt.Errorf("no embeddings generated for pokemon %s", pokemonID)
+	return ability.EmbeddingText(), nil
+}
+
+func (s *service) itemText(ctx context.Context, itemID string) (string, error) {
+	item, err := s.pokemonService.GetItemByID(ctx, itemID)
+	if err != nil {
+		return "", fmt.Errorf("fetch item: %w", err)
 	}
 
-	return s.repository.InTx(ctx, func(repo Repository) error {
-		return s.ingestDocument(ctx, repo, DocumentTypePokemon, pokemonID, embeddingText, vectors)
-	})
+	return item.EmbeddingText(), nil
 }
 
-func (s *service) ingestDocument(
-	ctx context.Context,
-	repo Repository,
-	docType DocumentType,
-	externalID string,
-	content string,
-	vectors [][]float32,
-) error {
+func (s *service) typeText(ctx context.Context, typeID string) (string, error) {
+	t, err := s.pokemonService.GetTypeByID(ctx, typeID)
+	if err != nil {
+		return "", fmt.Errorf("fetch type: %w", err)
+	}
+
+	return t.EmbeddingText(), nil
+}
+
+func (s *service) evolutionChainText(ctx context.Context, chainID string) (string, error) {
+	chain, err := s.pokemonService.GetEvolutionChainByID(ctx, chainID)
+	if err != nil {
+		return "", fmt.Errorf("fetch evolution chain: %w", err)
+	}
+
+	return chain.EmbeddingText(), nil
+}
+
+func (s *service) cobblemonSpeciesText(ctx context.Context, speciesID string) (string, error) {
+	species, err := s.datapack.GetSpeciesByID(ctx, speciesID)
+	if err != nil {
+		return "", fmt.Errorf("load cobblemon species: %w", err)
+	}
+
+	return species.EmbeddingText(), nil
+}
+
+func (s *service) spawnText(ctx context.Context, spawnPoolID string) (string, error) {
+	pool, err := s.datapack.GetSpawnPoolByID(ctx, spawnPoolID)
+	if err != nil {
+		return "", fmt.Errorf("load spawn pool: %w", err)
+	}
+
+	return pool.EmbeddingText(), nil
+}
+
+// Delete removes a document: its row in ingested_documents, every vector carrying its
+// reference and the cached answers built from it. Deleting a document that was never
+// ingested is not an error, so a repeated tombstone is harmless.
+func (s *service) Delete(ctx context.Context, docType DocumentType, externalID string) error {
+	if !docType.Valid() {
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, docType)
+	}
+
 	reference := NewDocumentID(docType, externalID)
+	entry := &OutboxEntry{
+		ID:            uuid.Must(uuid.NewV7()),
+		Reference:     reference,
+		Operation:     OutboxDelete,
+		Status:        OutboxPending,
+		NextAttemptAt: time.Now().Add(outboxGracePeriod),
+	}
 
-	err := repo.Upsert(ctx, &IngestedDocument{
-		ID:           uuid.Must(uuid.NewV7()),
-		DocumentType: docType,
-		ExternalID:   externalID,
+	err := s.repository.InTx(ctx, func(repo Repository) error {
+		if err := repo.DeleteByRef(ctx, docType, externalID); err != nil {
+			return fmt.Errorf("delete document: %w", err)
+		}
+		return repo.AddOutbox(ctx, entry)
 	})
 	if err != nil {
-		return fmt.Errorf("upsert document: %w", err)
+		return err
+	}
+
+	entries := []*OutboxEntry{entry}
+	writeErr := s.writeVectors(ctx, entries)
+	if err := s.recordOutbox(ctx, entries, writeErr); err != nil {
+		slog.Warn("record outbox outcome", "reference", reference, "error", err)
+	}
+	if writeErr != nil {
+		slog.Warn("vector delete deferred to outbox", "reference", reference, "error", writeErr)
+	}
+
+	if err := s.cache.InvalidateReferences(ctx, reference); err != nil {
+		return err
+	}
+
+	slog.Info("document deleted", "reference", reference)
+	return nil
+}
+
+// ListDocuments returns one page of ingested documents matching the filter, along with
+// the total number of matches.
+func (s *service) ListDocuments(ctx context.Context, filter DocumentFilter) (*DocumentPage, error) {
+	if filter.Type != "" && !filter.Type.Valid() {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, filter.Type)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = documentPageSize
+	}
+	filter.Limit = min(filter.Limit, maxDocumentPageSize)
+
+	docs, err := s.repository.ListDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repository.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &DocumentPage{Documents: docs, Total: total}
+	if page.Documents == nil {
+		page.Documents = []*IngestedDocument{}
+	}
+	if len(docs) == filter.Limit {
+		page.Next = docs[len(docs)-1].ID
+	}
+	return page, nil
+}
+
+// GetDocument returns an ingested document with its embedding text and the points
+// the vector store holds for its reference.
+func (s *service) GetDocument(ctx context.Context, docType DocumentType, externalID string) (*DocumentDetail, error) {
+	if !docType.Valid() {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, docType)
+	}
+
+	doc, err := s.repository.FindByRef(ctx, docType, externalID)
+	if err != nil {
+		return nil, err
 	}
 
-	err = s.store.Delete(ctx, vectorstore.Filter{
+	reference := NewDocumentID(docType, externalID)
+	points, err := s.store.Scroll(ctx, vectorstore.Filter{
 		StringFilters: []vectorstore.StringFilter{
 			{Field: referenceKey, Value: reference, Op: vectorstore.FilterAND},
 		},
 	})
 	if err != nil {
+		return nil, fmt.Errorf("scroll vectors: %w", err)
+	}
+
+	detail := &DocumentDetail{
+		IngestedDocument: doc,
+		Reference:        reference,
+		PointIDs:         make([]string, len(points)),
+		Chunks:           make([]DocumentChunk, len(points)),
+	}
+	for i, point := range points {
+		detail.PointIDs[i] = point.ID
+		detail.Chunks[i] = DocumentChunk{PointID: point.ID, Index: payloadInt(point.Payload[chunkIndexKey])}
+		detail.Chunks[i].Section, _ = point.Payload[sectionKey].(string)
+		detail.Chunks[i].Content, _ = point.Payload[contentKey].(string)
+	}
+	sort.SliceStable(detail.Chunks, func(i, j int) bool { return detail.Chunks[i].Index < detail.Chunks[j].Index })
+
+	detail.Content = doc.Content
+	if detail.Content == "" {
+		// Documents ingested before their content was stored: rebuild it from the
+		// points, which carried the whole text until documents were chunked.
+		var content strings.Builder
+		for i, c := range detail.Chunks {
+			if i == 0 || c.Content != detail.Chunks[i-1].Content {
+				content.WriteString(c.Content)
+			}
+		}
+		detail.Content = content.String()
+	}
+	return detail, nil
+}
+
+// ImportDatapack enqueues every species and spawn pool in the configured datapack as one job.
+func (s *service) ImportDatapack(ctx context.Context) (*IngestionJob, error) {
+	batches := datapackBatches(s.datapack)
+	if len(batches) == 0 {
+		return nil, fmt.Errorf("%w: datapack is empty", ErrInvalidBatch)
+	}
+	return s.Enqueue(ctx, batches...)
+}
+
+// Reindex re-embeds every document whose stored vectors were produced by a different
+// embedding model or dimension than the current configuration. Documents are re-fetched
+// from their source and ingested in batches; individual failures are reported in the result.
+func (s *service) Reindex(ctx context.Context) (*BatchResult, error) {
+	embedModel, dim := s.embedService.EmbedModel(), s.store.Dimensions()
+
+	return s.reingest(ctx, false, func(after uuid.UUID) ([]*IngestedDocument, error) {
+		return s.repository.FindStale(ctx, embedModel, dim, after, reindexPageSize)
+	})
+}
+
+// Rebuild re-embeds every ingested document into the service's vector store,
+// typically a fresh collection that is not yet live. It fails if any document
+// could not be ingested or the store ends up with fewer points than documents,
+// so the caller only switches traffic to a complete collection.
+func (s *service) Rebuild(ctx context.Context) (*BatchResult, error) {
+	result, err := s.reingest(ctx, true, func(after uuid.UUID) ([]*IngestedDocument, error) {
+		return s.repository.List(ctx, after, reindexPageSize)
+	})
+	if err != nil {
+		return result, err
+	}
+	if len(result.Failed) > 0 {
+		return result, fmt.Errorf("rebuild: %d of %d documents failed", len(result.Failed), result.Requested)
+	}
+
+	documents, err := s.repository.Count(ctx)
+	if err != nil {
+		return result, err
+	}
+	points, err := s.store.Count(ctx)
+	if err != nil {
+		return result, fmt.Errorf("count vectors: %w", err)
+	}
+	if points < documents {
+		return result, fmt.Errorf("rebuild: %d vectors for %d documents", points, documents)
+	}
+
+	return result, nil
+}
+
+// reingest pages through the documents returned by next and ingests them again,
+// one batch per document type and page.
+func (s *service) reingest(ctx context.Context, force bool, next func(after uuid.UUID) ([]*IngestedDocument, error)) (*BatchResult, error) {
+	result := &BatchResult{}
+	after := uuid.Nil
+	for {
+		docs, err := next(after)
+		if err != nil {
+			return result, err
+		}
+		if len(docs) == 0 {
+			return result, nil
+		}
+		after = docs[len(docs)-1].ID
+
+		for _, batch := range typeBatches(docs) {
+			batchResult, err := s.ingestBatch(ctx, batch, force)
+			if err != nil {
+				return result, fmt.Errorf("reingest %s: %w", batch.Type, err)
+			}
+			result.add(batch.Type, batchResult)
+		}
+
+		slog.Info("reingested documents", "ingested", result.Ingested, "failed", len(result.Failed))
+	}
+}
+
+// Reconcile compares the references stored in the vector store with ingested_documents
+// and reports the drift. With repair set, documents without vectors are re-ingested and
+// points whose reference has no document row are deleted.
+func (s *service) Reconcile(ctx context.Context, repair bool) (*DriftReport, error) {
+	// Scroll before listing: a document ingested in between then shows up as
+	// missing and is harmlessly re-ingested, rather than as an orphan.
+	points, err := s.store.PointsByPayload(ctx, referenceKey)
+	if err != nil {
+		return nil, fmt.Errorf("scroll vectors: %w", err)
+	}
+
+	report := &DriftReport{
+		References: len(points),
+		Missing:    []string{},
+		Orphans:    []string{},
+	}
+
+	var missing []*IngestedDocument
+	after := uuid.Nil
+	for {
+		docs, err := s.repository.List(ctx, after, reindexPageSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(docs) == 0 {
+			break
+		}
+		after = docs[len(docs)-1].ID
+
+		for _, doc := range docs {
+			report.Documents++
+			reference := NewDocumentID(doc.DocumentType, doc.ExternalID)
+			if _, ok := points[reference]; ok {
+				delete(points, reference)
+				continue
+			}
+			report.Missing = append(report.Missing, reference)
+			missing = append(missing, doc)
+		}
+	}
+
+	for reference, ids := range points {
+		report.Orphans = append(report.Orphans, reference)
+		report.OrphanPoints += len(ids)
+	}
+	sort.Strings(report.Missing)
+	sort.Strings(report.Orphans)
+
+	if !repair || report.InSync() {
+		return report, nil
+	}
+
+	report.Repair = &BatchResult{}
+	for start := 0; start < len(missing); start += reindexPageSize {
+		page := missing[start:min(start+reindexPageSize, len(missing))]
+		for _, batch := range typeBatches(page) {
+			result, err := s.ingestBatch(ctx, batch, true)
+			if err != nil {
+				return report, fmt.Errorf("repair %s: %w", batch.Type, err)
+			}
+			report.Repair.add(batch.Type, result)
+		}
+	}
+
+	orphanIDs, err := s.orphanPoints(ctx, report.Orphans, points)
+	if err != nil {
+		return report, err
+	}
+	if len(orphanIDs) > 0 {
+		if err := s.store.DeleteByID(ctx, orphanIDs...); err != nil {
+			return report, fmt.Errorf("delete orphan vectors: %w", err)
+		}
+		report.Deleted = len(orphanIDs)
+	}
+
+	return report, nil
+}
+
+// orphanPoints returns the point IDs of orphan references that still have no
+// document row, so documents ingested since the scroll are left alone.
+func (s *service) orphanPoints(ctx context.Context, orphans []string, points map[string][]string) ([]string, error) {
+	var ids []string
+	for _, reference := range orphans {
+		docType, externalID, ok := ParseDocumentID(reference)
+		if ok {
+			_, err := s.repository.FindByRef(ctx, docType, externalID)
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, ErrNotFound) {
+				return nil, err
+			}
+		}
+		ids = append(ids, points[reference]...)
+	}
+	return ids, nil
+}
+
+// ReconcileEvery runs Reconcile on the given interval until ctx is cancelled,
+// logging any drift it finds.
+func (s *service) ReconcileEvery(ctx context.Context, interval time.Duration, repair bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		report, err := s.Reconcile(ctx, repair)
+		if err != nil {
+			slog.Error("reconcile failed", "error", err)
+			continue
+		}
+		if !report.InSync() {
+			slog.Warn("vector store drift detected",
+				"missing", len(report.Missing),
+				"orphans", len(report.Orphans),
+				"orphan_points", report.OrphanPoints,
+				"repaired", repair,
+			)
+		}
+	}
+}
+
+// typeBatches groups documents into one batch per document type.
+func typeBatches(docs []*IngestedDocument) []BatchIngestionEvent {
+	var batches []BatchIngestionEvent
+	index := make(map[DocumentType]int)
+	for _, doc := range docs {
+		i, ok := index[doc.DocumentType]
+		if !ok {
+			i = len(batches)
+			index[doc.DocumentType] = i
+			batches = append(batches, BatchIngestionEvent{Type: doc.DocumentType})
+		}
+		batches[i].IDs = append(batches[i].IDs, doc.ExternalID)
+	}
+	return batches
+}
+
+// datapackBatches returns one batch for the species and one for the spawn pools in the datapack.
+func datapackBatches(datapack datapackSource) []BatchIngestionEvent {
+	var batches []BatchIngestionEvent
+	if ids := datapack.SpeciesIDs(); len(ids) > 0 {
+		batches = append(batches, BatchIngestionEvent{Type: DocumentTypeCobblemonSpecies, IDs: ids})
+	}
+	if ids := datapack.SpawnPoolIDs(); len(ids) > 0 {
+		batches = append(batches, BatchIngestionEvent{Type: DocumentTypeSpawn, IDs: ids})
+	}
+	return batches
+}
+
+// ingestDocuments embeds the chunks of the documents with a single Embed call,
+// expecting one vector per chunk. The documents and their vector writes are committed
+// in one transaction, and the writes are then applied to the vector store, leaving a
+// failed write to the outbox dispatcher. With direct set, no outbox entries are
+// recorded and a failed write fails the documents: a rebuild targets a collection
+// that is not yet live, which the shared outbox must never dispatch into.
+func (s *service) ingestDocuments(ctx context.Context, direct bool, docs ...*document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	var texts []string
+	for _, doc := range docs {
+		for _, c := range doc.chunks {
+			texts = append(texts, c.text)
+		}
+	}
+
+	vectors, err := s.embedService.Embed(ctx, s.store.Dimensions(), texts...)
+	if err != nil {
+		return fmt.Errorf("embed %s: %w", docs[0].docType, err)
+	}
+
+	if len(vectors) == 0 {
+		return fmt.Errorf("no embeddings generated for %s %s", docs[0].docType, docs[0].externalID)
+	}
+
+	if len(vectors) != len(texts) {
+		return fmt.Errorf("expected %d embeddings, got %d", len(texts), len(vectors))
+	}
+	for _, doc := range docs {
+		doc.vectors, vectors = vectors[:len(doc.chunks)], vectors[len(doc.chunks):]
+	}
+
+	var entries []*OutboxEntry
+	err = s.repository.InTx(ctx, func(repo Repository) error {
+		entries, err = s.storeDocuments(ctx, repo, docs, !direct)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	writeErr := s.writeVectors(ctx, entries)
+	if direct {
+		return writeErr
+	}
+	if err := s.recordOutbox(ctx, entries, writeErr); err != nil {
+		slog.Warn("record outbox outcome", "type", docs[0].docType, "error", err)
+	}
+	if writeErr != nil {
+		slog.Warn("vector write deferred to outbox", "type", docs[0].docType, "documents", len(docs), "error", writeErr)
+	}
+	return nil
+}
+
+// storeDocuments records the documents in the repository and returns an outbox entry
+// per document that replaces its vectors. The entries are only persisted with outbox set.
+func (s *service) storeDocuments(ctx context.Context, repo Repository, docs []*document, outbox bool) ([]*OutboxEntry, error) {
+	entries := make([]*OutboxEntry, 0, len(docs))
+	nextAttempt := time.Now().Add(outboxGracePeriod)
+
+	for _, doc := range docs {
+		reference := NewDocumentID(doc.docType, doc.externalID)
+
+		err := repo.Upsert(ctx, &IngestedDocument{
+			ID:             uuid.Must(uuid.NewV7()),
+			DocumentType:   doc.docType,
+			ExternalID:     doc.externalID,
+			ContentHash:    doc.contentHash,
+			EmbeddingModel: s.embedService.EmbedModel(),
+			EmbeddingDim:   s.store.Dimensions(),
+			Content:        doc.content,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("upsert document: %w", err)
+		}
+
+		points := make([]vectorstore.Point, len(doc.vectors))
+		for i, vector := range doc.vectors {
+			points[i] = vectorstore.Point{
+				ID:     uuid.Must(uuid.NewV7()).String(),
+				Vector: vector,
+				Payload: map[string]any{
+					referenceKey:  reference,
+					typeKey:       string(doc.docType),
+					contentKey:    doc.chunks[i].text,
+					chunkIndexKey: i,
+					sectionKey:    doc.chunks[i].section,
+				},
+			}
+		}
+
+		entries = append(entries, &OutboxEntry{
+			ID:            uuid.Must(uuid.NewV7()),
+			Reference:     reference,
+			Operation:     OutboxUpsert,
+			Points:        points,
+			Status:        OutboxPending,
+			NextAttemptAt: nextAttempt,
+		})
+	}
+
+	if !outbox {
+		return entries, nil
+	}
+	if err := repo.AddOutbox(ctx, entries...); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// recordOutbox stores the outcome of writing the entries to the vector store. A failed
+// write is scheduled for another attempt with exponential backoff, unless the entry
+// has run out of attempts. When an entry was superseded while its
+// write was in flight, that write may have landed after the newer one, so the newest
+// entry of the reference is requeued.
+func (s *service) recordOutbox(ctx context.Context, entries []*OutboxEntry, writeErr error) error {
+	now := time.Now()
+	for _, entry := range entries {
+		attempts := entry.Attempts
+		if writeErr == nil {
+			entry.Status = OutboxDone
+			entry.LastError = ""
+		} else {
+			entry.Attempts++
+			entry.LastError = writeErr.Error()
+			entry.NextAttemptAt = now.Add(outboxBackoff(entry.Attempts))
+			if entry.Attempts >= outboxMaxAttempts {
+				entry.Status = OutboxFailed
+			}
+		}
+
+		err := s.repository.UpdateOutbox(ctx, entry, attempts)
+		if errors.Is(err, ErrOutboxSuperseded) {
+			err = s.repository.RequeueOutbox(ctx, entry.Reference)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeVectors replaces the vectors of every entry's reference with one delete
+// and one upsert against the vector store.
+func (s *service) writeVectors(ctx context.Context, entries []*OutboxEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	op := vectorstore.FilterAND
+	if len(entries) > 1 {
+		op = vectorstore.FilterOR
+	}
+
+	var points []vectorstore.Point
+	filter := vectorstore.Filter{}
+	for _, entry := range entries {
+		filter.StringFilters = append(filter.StringFilters, vectorstore.StringFilter{
+			Field: referenceKey, Value: entry.Reference, Op: op,
+		})
+		if entry.Operation == OutboxUpsert {
+			points = append(points, entry.Points...)
+		}
+	}
+
+	if err := s.store.Delete(ctx, filter); err != nil {
 		return fmt.Errorf("delete vectors: %w", err)
 	}
+	if len(points) == 0 {
+		return nil
+	}
+	if err := s.store.Upsert(ctx, points...); err != nil {
+		return fmt.Errorf("upsert vectors: %w", err)
+	}
+	return nil
+}
+
+// outboxBackoff returns the delay before the next attempt after the given number of failures.
+func outboxBackoff(attempts int) time.Duration {
+	backoff := outboxBaseBackoff
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, outboxMaxBackoff)
+}
+
+// DispatchOutbox claims due outbox entries and applies them to the vector store one
+// at a time, so a single bad entry does not hold back the others. It returns how many
+// entries were attempted; failed writes are recorded on the entries rather than returned.
+func (s *service) DispatchOutbox(ctx context.Context) (int, error) {
+	entries, err := s.repository.ClaimOutbox(ctx, outboxBatchSize, outboxLease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		batch := []*OutboxEntry{entry}
+		writeErr := s.writeVectors(ctx, batch)
+		if err := s.recordOutbox(ctx, batch, writeErr); err != nil {
+			return len(entries), err
+		}
+		if writeErr != nil {
+			slog.Warn("outbox write failed",
+				"reference", entry.Reference,
+				"attempts", entry.Attempts,
+				"status", entry.Status,
+				"error", writeErr,
+			)
+		}
+	}
+	return len(entries), nil
+}
 
-	points := make([]vectorstore.Point, len(vectors))
-	metadata := map[string]any{
-		referenceKey: reference,
-		typeKey:      string(docType),
-		contentKey:   content,
+// PurgeOutbox deletes outbox entries that finished longer ago than their retention,
+// keeping failed entries around longer for inspection.
+func (s *service) PurgeOutbox(ctx context.Context) (int, error) {
+	now := time.Now()
+	retention := map[OutboxStatus]time.Duration{
+		OutboxDone:       outboxRetention,
+		OutboxSuperseded: outboxRetention,
+		OutboxFailed:     outboxFailedRetention,
 	}
 
-	for i, vector := range vectors {
-		points[i] = vectorstore.Point{
-			ID:      uuid.Must(uuid.NewV7()).String(),
-			Vector:  vector,
-			Payload: metadata,
+	var purged int
+	for status, keep := range retention {
+		n, err := s.repository.PurgeOutbox(ctx, status, now.Add(-keep))
+		if err != nil {
+			return purged, err
 		}
+		purged += n
 	}
+	return purged, nil
+}
+
+// RunOutboxDispatcher dispatches due outbox entries on the given interval until ctx
+// is cancelled, draining full batches back to back and then purging finished entries.
+func (s *service) RunOutboxDispatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for {
+			dispatched, err := s.DispatchOutbox(ctx)
+			if err != nil {
+				slog.Error("outbox dispatch failed", "error", err)
+				break
+			}
+			if dispatched < outboxBatchSize {
+				break
+			}
+		}
 
-	return s.store.Upsert(ctx, points...)
+		if purged, err := s.PurgeOutbox(ctx); err != nil {
+			slog.Error("outbox purge failed", "error", err)
+		} else if purged > 0 {
+			slog.Info("outbox purged", "entries", purged)
+		}
+	}
 }
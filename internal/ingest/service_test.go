@@ -2,9 +2,16 @@ package ingest
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"cyrene/internal/cobblemon"
+	"cyrene/internal/platform/cloudevents"
 	"cyrene/internal/platform/vectorstore"
 	"cyrene/internal/pokemon"
 
@@ -13,31 +20,110 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+const testEmbedModel = "embed/test"
+
 type mockEmbedder struct {
 	embedFn func(ctx context.Context, texts ...string) ([][]float32, error)
 }
 
-func (m *mockEmbedder) Embed(ctx context.Context, texts ...string) ([][]float32, error) {
+func (m *mockEmbedder) Embed(ctx context.Context, dimensions int, texts ...string) ([][]float32, error) {
 	return m.embedFn(ctx, texts...)
 }
 
+func (m *mockEmbedder) EmbedModel() string {
+	return testEmbedModel
+}
+
 type mockPokemonGetter struct {
-	getFn func(ctx context.Context, id string) (*pokemon.Pokemon, error)
+	getFn        func(ctx context.Context, id string) (*pokemon.Pokemon, error)
+	getMoveFn    func(ctx context.Context, id string) (*pokemon.Move, error)
+	getAbilityFn func(ctx context.Context, id string) (*pokemon.Ability, error)
+	getItemFn    func(ctx context.Context, id string) (*pokemon.Item, error)
+	getTypeFn    func(ctx context.Context, id string) (*pokemon.Type, error)
+	getSpeciesFn func(ctx context.Context, id string) (*pokemon.Species, error)
+	getChainFn   func(ctx context.Context, id string) (*pokemon.EvolutionChain, error)
 }
 
 func (m *mockPokemonGetter) GetPokemonByID(ctx context.Context, id string) (*pokemon.Pokemon, error) {
 	return m.getFn(ctx, id)
 }
 
+func (m *mockPokemonGetter) GetMoveByID(ctx context.Context, id string) (*pokemon.Move, error) {
+	return m.getMoveFn(ctx, id)
+}
+
+func (m *mockPokemonGetter) GetAbilityByID(ctx context.Context, id string) (*pokemon.Ability, error) {
+	return m.getAbilityFn(ctx, id)
+}
+
+func (m *mockPokemonGetter) GetItemByID(ctx context.Context, id string) (*pokemon.Item, error) {
+	return m.getItemFn(ctx, id)
+}
+
+func (m *mockPokemonGetter) GetTypeByID(ctx context.Context, id string) (*pokemon.Type, error) {
+	return m.getTypeFn(ctx, id)
+}
+
+func (m *mockPokemonGetter) GetSpeciesByID(ctx context.Context, id string) (*pokemon.Species, error) {
+	if m.getSpeciesFn != nil {
+		return m.getSpeciesFn(ctx, id)
+	}
+	return &pokemon.Species{ID: id, Identifier: id}, nil
+}
+
+func (m *mockPokemonGetter) GetEvolutionChainByID(ctx context.Context, id string) (*pokemon.EvolutionChain, error) {
+	return m.getChainFn(ctx, id)
+}
+
+type mockDatapack struct {
+	species    map[string]*cobblemon.Species
+	spawnPools map[string]*cobblemon.SpawnPool
+}
+
+func (m *mockDatapack) GetSpeciesByID(ctx context.Context, id string) (*cobblemon.Species, error) {
+	if s, ok := m.species[id]; ok {
+		return s, nil
+	}
+	return nil, cobblemon.ErrNotFound
+}
+
+func (m *mockDatapack) GetSpawnPoolByID(ctx context.Context, id string) (*cobblemon.SpawnPool, error) {
+	if p, ok := m.spawnPools[id]; ok {
+		return p, nil
+	}
+	return nil, cobblemon.ErrNotFound
+}
+
+func (m *mockDatapack) SpeciesIDs() []string {
+	var ids []string
+	for id := range m.species {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (m *mockDatapack) SpawnPoolIDs() []string {
+	var ids []string
+	for id := range m.spawnPools {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
 type mockStore struct {
-	upsertFn   func(ctx context.Context, points ...vectorstore.Point) error
-	deleteFn   func(ctx context.Context, filter vectorstore.Filter) error
-	upserted   []vectorstore.Point
-	deletedRef string
+	upsertFn      func(ctx context.Context, points ...vectorstore.Point) error
+	deleteFn      func(ctx context.Context, filter vectorstore.Filter) error
+	upserted      []vectorstore.Point
+	upsertCalls   int
+	deletedRef    string
+	deleteFilters []vectorstore.Filter
+	references    map[string][]string
+	deletedIDs    []string
 }
 
 func (m *mockStore) Upsert(ctx context.Context, points ...vectorstore.Point) error {
 	m.upserted = append(m.upserted, points...)
+	m.upsertCalls++
 	if m.upsertFn != nil {
 		return m.upsertFn(ctx, points...)
 	}
@@ -48,6 +134,7 @@ func (m *mockStore) Delete(ctx context.Context, filter vectorstore.Filter) error
 	if len(filter.StringFilters) > 0 {
 		m.deletedRef = filter.StringFilters[0].Value
 	}
+	m.deleteFilters = append(m.deleteFilters, filter)
 	if m.deleteFn != nil {
 		return m.deleteFn(ctx, filter)
 	}
@@ -59,16 +146,62 @@ func (m *mockStore) Search(ctx context.Context, vector []float32, limit int, fil
 }
 
 func (m *mockStore) DeleteByID(ctx context.Context, ids ...string) error {
+	m.deletedIDs = append(m.deletedIDs, ids...)
 	return nil
 }
 
+func (m *mockStore) PointsByPayload(ctx context.Context, field string) (map[string][]string, error) {
+	points := make(map[string][]string, len(m.references))
+	for ref, ids := range m.references {
+		points[ref] = ids
+	}
+	return points, nil
+}
+
+func (m *mockStore) Scroll(ctx context.Context, filter vectorstore.Filter) ([]vectorstore.Point, error) {
+	var points []vectorstore.Point
+	for _, f := range filter.StringFilters {
+		for _, p := range m.upserted {
+			if p.Payload[f.Field] == f.Value {
+				points = append(points, vectorstore.Point{ID: p.ID, Payload: p.Payload})
+			}
+		}
+	}
+	return points, nil
+}
+
+func (m *mockStore) Dimensions() int {
+	return 3
+}
+
+func (m *mockStore) Count(ctx context.Context) (int, error) {
+	return len(m.upserted), nil
+}
+
 type mockRepository struct {
-	upsertFn func(ctx context.Context, doc *IngestedDocument) error
-	upserted *IngestedDocument
+	upsertFn    func(ctx context.Context, doc *IngestedDocument) error
+	findFn      func(ctx context.Context, dt DocumentType, externalID string) (*IngestedDocument, error)
+	listFn      func(ctx context.Context, after uuid.UUID, limit int) ([]*IngestedDocument, error)
+	listDocsFn  func(ctx context.Context, filter DocumentFilter) ([]*IngestedDocument, error)
+	count       int
+	findStaleFn func(ctx context.Context, embedModel string, dim int, after uuid.UUID, limit int) ([]*IngestedDocument, error)
+	upserted    *IngestedDocument
+	upsertCount int
+
+	mu          sync.Mutex
+	job         *IngestionJob
+	jobStatuses map[string][]JobStatus
+
+	outbox        []*OutboxEntry
+	outboxUpdates int
+	requeued      []string
+	purged        map[OutboxStatus]time.Time
+	deletedRefs   []string
 }
 
 func (m *mockRepository) Upsert(ctx context.Context, doc *IngestedDocument) error {
 	m.upserted = doc
+	m.upsertCount++
 	if m.upsertFn != nil {
 		return m.upsertFn(ctx, doc)
 	}
@@ -80,10 +213,43 @@ func (m *mockRepository) Delete(ctx context.Context, id uuid.UUID) error {
 }
 
 func (m *mockRepository) DeleteByRef(ctx context.Context, dt DocumentType, externalID string) error {
+	m.deletedRefs = append(m.deletedRefs, NewDocumentID(dt, externalID))
 	return nil
 }
 
 func (m *mockRepository) FindByRef(ctx context.Context, dt DocumentType, externalID string) (*IngestedDocument, error) {
+	if m.findFn != nil {
+		return m.findFn(ctx, dt, externalID)
+	}
+	return nil, ErrNotFound
+}
+
+func (m *mockRepository) List(ctx context.Context, after uuid.UUID, limit int) ([]*IngestedDocument, error) {
+	if m.listFn != nil {
+		return m.listFn(ctx, after, limit)
+	}
+	return nil, nil
+}
+
+func (m *mockRepository) Count(ctx context.Context) (int, error) {
+	return m.count, nil
+}
+
+func (m *mockRepository) ListDocuments(ctx context.Context, filter DocumentFilter) ([]*IngestedDocument, error) {
+	if m.listDocsFn != nil {
+		return m.listDocsFn(ctx, filter)
+	}
+	return nil, nil
+}
+
+func (m *mockRepository) CountDocuments(ctx context.Context, filter DocumentFilter) (int, error) {
+	return m.count, nil
+}
+
+func (m *mockRepository) FindStale(ctx context.Context, embedModel string, dim int, after uuid.UUID, limit int) ([]*IngestedDocument, error) {
+	if m.findStaleFn != nil {
+		return m.findStaleFn(ctx, embedModel, dim, after, limit)
+	}
 	return nil, nil
 }
 
@@ -91,15 +257,150 @@ func (m *mockRepository) InTx(ctx context.Context, fn func(Repository) error) er
 	return fn(m)
 }
 
+func (m *mockRepository) CreateJob(ctx context.Context, job *IngestionJob) error {
+	m.job = job
+	return nil
+}
+
+func (m *mockRepository) UpdateJobDocuments(ctx context.Context, jobID uuid.UUID, dt DocumentType, externalIDs []string, status JobStatus, errMsg string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range externalIDs {
+		if m.jobStatuses == nil {
+			m.jobStatuses = make(map[string][]JobStatus)
+		}
+		ref := NewDocumentID(dt, id)
+		m.jobStatuses[ref] = append(m.jobStatuses[ref], status)
+	}
+	return nil
+}
+
+func (m *mockRepository) FindJob(ctx context.Context, jobID uuid.UUID) (*IngestionJob, error) {
+	if m.job == nil || m.job.ID != jobID {
+		return nil, ErrJobNotFound
+	}
+	return m.job, nil
+}
+
+func (m *mockRepository) AddOutbox(ctx context.Context, entries ...*OutboxEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, entry := range entries {
+		for _, existing := range m.outbox {
+			if existing.Reference == entry.Reference && existing.Status == OutboxPending {
+				existing.Status = OutboxSuperseded
+			}
+		}
+	}
+	m.outbox = append(m.outbox, entries...)
+	return nil
+}
+
+func (m *mockRepository) ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]*OutboxEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	var claimed []*OutboxEntry
+	for _, entry := range m.outbox {
+		if entry.Status == OutboxPending && !entry.NextAttemptAt.After(now) && len(claimed) < limit {
+			entry.NextAttemptAt = now.Add(lease)
+			claimed = append(claimed, entry)
+		}
+	}
+	return claimed, nil
+}
+
+// UpdateOutbox reports an entry as superseded once a newer entry for its reference
+// has been added, mirroring the status check of the real repository.
+func (m *mockRepository) UpdateOutbox(ctx context.Context, entry *OutboxEntry, attempts int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.outboxUpdates++
+	newest := entry
+	for _, existing := range m.outbox {
+		if existing.Reference == entry.Reference {
+			newest = existing
+		}
+	}
+	if newest != entry {
+		return ErrOutboxSuperseded
+	}
+	return nil
+}
+
+func (m *mockRepository) RequeueOutbox(ctx context.Context, reference string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requeued = append(m.requeued, reference)
+	for i := len(m.outbox) - 1; i >= 0; i-- {
+		entry := m.outbox[i]
+		if entry.Reference != reference {
+			continue
+		}
+		if entry.Status == OutboxPending || entry.Status == OutboxDone {
+			entry.Status = OutboxPending
+			entry.Attempts++
+			entry.NextAttemptAt = time.Now()
+		}
+		return nil
+	}
+	return nil
+}
+
+func (m *mockRepository) PurgeOutbox(ctx context.Context, status OutboxStatus, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.purged == nil {
+		m.purged = make(map[OutboxStatus]time.Time)
+	}
+	m.purged[status] = before
+	return 0, nil
+}
+
+type mockCache struct {
+	invalidateFn func(ctx context.Context, references ...string) error
+	invalidated  []string
+}
+
+func (m *mockCache) InvalidateReferences(ctx context.Context, references ...string) error {
+	m.invalidated = append(m.invalidated, references...)
+	if m.invalidateFn != nil {
+		return m.invalidateFn(ctx, references...)
+	}
+	return nil
+}
+
+type producedMessage struct {
+	topic string
+	key   string
+	value []byte
+}
+
+type mockProducer struct {
+	produceFn func(ctx context.Context, topic string, key, value []byte) error
+	produced  []producedMessage
+}
+
+func (m *mockProducer) Produce(ctx context.Context, topic string, key, value []byte) error {
+	m.produced = append(m.produced, producedMessage{topic: topic, key: string(key), value: value})
+	if m.produceFn != nil {
+		return m.produceFn(ctx, topic, key, value)
+	}
+	return nil
+}
+
 func TestIngestPokemon_Success(t *testing.T) {
 	ctx := context.Background()
 	pokemonID := "25"
 	rawJSON := `{"id":25,"name":"pikachu"}`
-	vectors := [][]float32{{0.1, 0.2, 0.3}, {0.4, 0.5, 0.6}}
+	vectors := [][]float32{{0.1, 0.2, 0.3}, {0.4, 0.5, 0.6}, {0.7, 0.8, 0.9}}
 
 	embedder := &mockEmbedder{
 		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
-			assert.Equal(t, rawJSON, texts[0])
+			require.Len(t, texts, 3)
+			for _, text := range texts {
+				assert.True(t, strings.HasPrefix(text, "Pokemon: pikachu (ID: 25)\n"), "every chunk names its document")
+			}
 			return vectors, nil
 		},
 	}
@@ -111,6 +412,13 @@ func TestIngestPokemon_Success(t *testing.T) {
 				ID:         pokemonID,
 				Identifier: "pikachu",
 				RawJSON:    rawJSON,
+				Metadata: map[string]any{
+					"id":     float64(25),
+					"name":   "pikachu",
+					"types":  []any{map[string]any{"type": map[string]any{"name": "electric"}}},
+					"moves":  []any{map[string]any{"move": map[string]any{"name": "thunderbolt"}}},
+					"height": float64(4),
+				},
 			}, nil
 		},
 	}
@@ -118,23 +426,31 @@ func TestIngestPokemon_Success(t *testing.T) {
 	store := &mockStore{}
 	repo := &mockRepository{}
 
-	svc := NewService(embedder, store, pokemonGetter, repo)
+	svc := NewService(embedder, store, pokemonGetter, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
 
-	err := svc.IngestPokemon(ctx, pokemonID)
+	err := svc.Ingest(ctx, IngestionEvent{Type: DocumentTypePokemon, ID: pokemonID})
 	require.NoError(t, err)
 
 	require.NotNil(t, repo.upserted)
 	assert.Equal(t, DocumentTypePokemon, repo.upserted.DocumentType)
 	assert.Equal(t, pokemonID, repo.upserted.ExternalID)
+	assert.True(t, strings.HasPrefix(repo.upserted.Content, "Pokemon: pikachu (ID: 25)\n"))
+	assert.Equal(t, 1, strings.Count(repo.upserted.Content, "Pokemon: pikachu (ID: 25)"), "the content is stored once, not per chunk")
 
 	assert.Equal(t, "pokemon_25", store.deletedRef)
 
-	require.Len(t, store.upserted, 2)
+	require.Len(t, store.upserted, 3)
+	sections := []string{sectionStats, sectionMoves, sectionLore}
 	for i, point := range store.upserted {
 		assert.Equal(t, vectors[i], point.Vector)
 		assert.Equal(t, "pokemon_25", point.Payload[referenceKey])
 		assert.Equal(t, string(DocumentTypePokemon), point.Payload[typeKey])
+		assert.Equal(t, i, point.Payload[chunkIndexKey])
+		assert.Equal(t, sections[i], point.Payload[sectionKey])
 	}
+	assert.Contains(t, store.upserted[0].Payload[contentKey], "Types: electric\nHeight: 4.0\n")
+	assert.Contains(t, store.upserted[1].Payload[contentKey], "Moves: thunderbolt\n")
+	assert.Contains(t, store.upserted[2].Payload[contentKey], "Species: pikachu\n")
 }
 
 func TestIngestPokemon_PokemonFetchError(t *testing.T) {
@@ -147,9 +463,9 @@ func TestIngestPokemon_PokemonFetchError(t *testing.T) {
 		},
 	}
 
-	svc := NewService(&mockEmbedder{}, &mockStore{}, pokemonGetter, &mockRepository{})
+	svc := NewService(&mockEmbedder{}, &mockStore{}, pokemonGetter, &mockDatapack{}, &mockRepository{}, &mockProducer{}, &mockCache{})
 
-	err := svc.IngestPokemon(ctx, "999")
+	err := svc.Ingest(ctx, IngestionEvent{Type: DocumentTypePokemon, ID: "999"})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "fetch pokemon")
 	assert.ErrorIs(t, err, expectedErr)
@@ -171,9 +487,9 @@ func TestIngestPokemon_EmbedError(t *testing.T) {
 		},
 	}
 
-	svc := NewService(embedder, &mockStore{}, pokemonGetter, &mockRepository{})
+	svc := NewService(embedder, &mockStore{}, pokemonGetter, &mockDatapack{}, &mockRepository{}, &mockProducer{}, &mockCache{})
 
-	err := svc.IngestPokemon(ctx, "25")
+	err := svc.Ingest(ctx, IngestionEvent{Type: DocumentTypePokemon, ID: "25"})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "embed pokemon")
 	assert.ErrorIs(t, err, expectedErr)
@@ -201,9 +517,9 @@ func TestIngestPokemon_RepositoryUpsertError(t *testing.T) {
 		},
 	}
 
-	svc := NewService(embedder, &mockStore{}, pokemonGetter, repo)
+	svc := NewService(embedder, &mockStore{}, pokemonGetter, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
 
-	err := svc.IngestPokemon(ctx, "25")
+	err := svc.Ingest(ctx, IngestionEvent{Type: DocumentTypePokemon, ID: "25"})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "upsert document")
 	assert.ErrorIs(t, err, expectedErr)
@@ -231,12 +547,20 @@ func TestIngestPokemon_StoreDeleteError(t *testing.T) {
 		},
 	}
 
-	svc := NewService(embedder, store, pokemonGetter, &mockRepository{})
+	repo := &mockRepository{}
+	svc := NewService(embedder, store, pokemonGetter, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
 
-	err := svc.IngestPokemon(ctx, "25")
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "delete vectors")
-	assert.ErrorIs(t, err, expectedErr)
+	err := svc.Ingest(ctx, IngestionEvent{Type: DocumentTypePokemon, ID: "25"})
+	require.NoError(t, err, "a failed vector write is left to the outbox")
+
+	require.Len(t, repo.outbox, 1)
+	entry := repo.outbox[0]
+	assert.Equal(t, "pokemon_25", entry.Reference)
+	assert.Equal(t, OutboxPending, entry.Status)
+	assert.Equal(t, 1, entry.Attempts)
+	assert.Contains(t, entry.LastError, "delete vectors")
+	assert.Contains(t, entry.LastError, expectedErr.Error())
+	assert.Empty(t, store.upserted)
 }
 
 func TestIngestPokemon_StoreUpsertError(t *testing.T) {
@@ -261,27 +585,1408 @@ func TestIngestPokemon_StoreUpsertError(t *testing.T) {
 		},
 	}
 
-	svc := NewService(embedder, store, pokemonGetter, &mockRepository{})
+	repo := &mockRepository{}
+	svc := NewService(embedder, store, pokemonGetter, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+	start := time.Now()
+	err := svc.Ingest(ctx, IngestionEvent{Type: DocumentTypePokemon, ID: "25"})
+	require.NoError(t, err, "a failed vector write is left to the outbox")
+
+	require.Len(t, repo.outbox, 1)
+	entry := repo.outbox[0]
+	assert.Equal(t, OutboxPending, entry.Status)
+	assert.Equal(t, 1, entry.Attempts)
+	assert.Contains(t, entry.LastError, expectedErr.Error())
+	assert.False(t, entry.NextAttemptAt.Before(start.Add(outboxBaseBackoff)))
+	require.NotNil(t, repo.upserted, "the document row is committed regardless")
+}
+
+func TestIngest_OutboxAppliedInline(t *testing.T) {
+	ctx := context.Background()
+
+	embedder := &mockEmbedder{
+		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+			return [][]float32{{0.1, 0.2, 0.3}}, nil
+		},
+	}
+	pokemonGetter := &mockPokemonGetter{
+		getFn: func(ctx context.Context, id string) (*pokemon.Pokemon, error) {
+			return &pokemon.Pokemon{ID: id, RawJSON: "{}"}, nil
+		},
+	}
+
+	repo := &mockRepository{}
+	svc := NewService(embedder, &mockStore{}, pokemonGetter, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+	require.NoError(t, svc.Ingest(ctx, IngestionEvent{Type: DocumentTypePokemon, ID: "25"}))
+	require.NoError(t, svc.Ingest(ctx, IngestionEvent{Type: DocumentTypePokemon, ID: "25"}))
+
+	require.Len(t, repo.outbox, 2)
+	for _, entry := range repo.outbox {
+		assert.Equal(t, OutboxUpsert, entry.Operation)
+		assert.Equal(t, OutboxDone, entry.Status)
+		assert.Len(t, entry.Points, 1)
+	}
+}
+
+func TestDispatchOutbox_RetriesUntilApplied(t *testing.T) {
+	ctx := context.Background()
+
+	failing := true
+	store := &mockStore{
+		upsertFn: func(ctx context.Context, points ...vectorstore.Point) error {
+			if failing {
+				return errors.New("qdrant unavailable")
+			}
+			return nil
+		},
+	}
+	point := vectorstore.Point{ID: uuid.NewString(), Vector: []float32{0.1}, Payload: map[string]any{referenceKey: "pokemon_25"}}
+	repo := &mockRepository{outbox: []*OutboxEntry{
+		{ID: uuid.New(), Reference: "pokemon_25", Operation: OutboxUpsert, Points: []vectorstore.Point{point}, Status: OutboxPending},
+		{ID: uuid.New(), Reference: "pokemon_26", Operation: OutboxDelete, Status: OutboxPending},
+		{ID: uuid.New(), Reference: "pokemon_27", Operation: OutboxDelete, Status: OutboxPending, NextAttemptAt: time.Now().Add(time.Hour)},
+	}}
+	svc := NewService(&mockEmbedder{}, store, &mockPokemonGetter{}, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+	dispatched, err := svc.DispatchOutbox(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, dispatched, "entries that are not yet due are left alone")
+
+	upsert, del, later := repo.outbox[0], repo.outbox[1], repo.outbox[2]
+	assert.Equal(t, OutboxPending, upsert.Status)
+	assert.Equal(t, 1, upsert.Attempts)
+	assert.True(t, upsert.NextAttemptAt.After(time.Now()))
+	assert.Equal(t, OutboxDone, del.Status, "one failing entry does not hold back the others")
+	assert.Equal(t, OutboxPending, later.Status)
+	assert.Zero(t, later.Attempts)
+
+	failing = false
+	upsert.NextAttemptAt = time.Now()
+
+	dispatched, err = svc.DispatchOutbox(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, dispatched)
+	assert.Equal(t, OutboxDone, upsert.Status)
+	assert.Empty(t, upsert.LastError)
+	assert.Equal(t, []vectorstore.Point{point, point}, store.upserted)
+}
+
+func TestDispatchOutbox_GivesUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+
+	store := &mockStore{
+		deleteFn: func(ctx context.Context, filter vectorstore.Filter) error {
+			return errors.New("qdrant unavailable")
+		},
+	}
+	entry := &OutboxEntry{ID: uuid.New(), Reference: "pokemon_25", Operation: OutboxDelete, Status: OutboxPending, Attempts: outboxMaxAttempts - 1}
+	repo := &mockRepository{outbox: []*OutboxEntry{entry}}
+	svc := NewService(&mockEmbedder{}, store, &mockPokemonGetter{}, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+	_, err := svc.DispatchOutbox(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, OutboxFailed, entry.Status)
+	assert.Equal(t, outboxMaxAttempts, entry.Attempts)
+}
+
+func TestDispatchOutbox_RequeuesNewerEntryAfterStaleWrite(t *testing.T) {
+	ctx := context.Background()
+
+	stale := &OutboxEntry{ID: uuid.New(), Reference: "pokemon_25", Operation: OutboxDelete, Status: OutboxPending}
+	newer := &OutboxEntry{ID: uuid.New(), Reference: "pokemon_25", Operation: OutboxDelete, Status: OutboxPending, NextAttemptAt: time.Now().Add(time.Hour)}
+	repo := &mockRepository{outbox: []*OutboxEntry{stale}}
+	store := &mockStore{
+		deleteFn: func(ctx context.Context, filter vectorstore.Filter) error {
+			// A re-ingest records and applies a newer entry while the stale write is in flight.
+			repo.outbox = append(repo.outbox, newer)
+			newer.Status = OutboxDone
+			return nil
+		},
+	}
+	svc := NewService(&mockEmbedder{}, store, &mockPokemonGetter{}, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+	dispatched, err := svc.DispatchOutbox(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, dispatched)
+	assert.Equal(t, []string{"pokemon_25"}, repo.requeued)
+	assert.Equal(t, OutboxPending, newer.Status, "the newer write is reapplied over the stale one")
+	assert.Equal(t, 1, newer.Attempts)
+	assert.False(t, newer.NextAttemptAt.After(time.Now()))
+}
+
+func TestPurgeOutbox(t *testing.T) {
+	repo := &mockRepository{}
+	svc := NewService(&mockEmbedder{}, &mockStore{}, &mockPokemonGetter{}, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+	start := time.Now()
+	_, err := svc.PurgeOutbox(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, repo.purged, 3)
+	assert.WithinDuration(t, start.Add(-outboxRetention), repo.purged[OutboxDone], time.Second)
+	assert.WithinDuration(t, start.Add(-outboxRetention), repo.purged[OutboxSuperseded], time.Second)
+	assert.WithinDuration(t, start.Add(-outboxFailedRetention), repo.purged[OutboxFailed], time.Second)
+	assert.NotContains(t, repo.purged, OutboxPending)
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	assert.Equal(t, outboxBaseBackoff, outboxBackoff(1))
+	assert.Equal(t, 2*outboxBaseBackoff, outboxBackoff(2))
+	assert.Equal(t, 8*outboxBaseBackoff, outboxBackoff(4))
+	assert.Equal(t, outboxMaxBackoff, outboxBackoff(outboxMaxAttempts))
+	assert.Equal(t, outboxMaxBackoff, outboxBackoff(100))
+}
+
+func TestIngestPokemon_MergesSpecies(t *testing.T) {
+	ctx := context.Background()
+
+	pokemonGetter := &mockPokemonGetter{
+		getFn: func(ctx context.Context, id string) (*pokemon.Pokemon, error) {
+			return &pokemon.Pokemon{
+				ID:         id,
+				Identifier: "eevee",
+				Metadata: map[string]any{
+					"species": map[string]any{"name": "eevee"},
+				},
+			}, nil
+		},
+		getSpeciesFn: func(ctx context.Context, id string) (*pokemon.Species, error) {
+			assert.Equal(t, "eevee", id)
+			return &pokemon.Species{
+				ID:         "133",
+				Identifier: "eevee",
+				Metadata: map[string]any{
+					"capture_rate":    float64(45),
+					"habitat":         map[string]any{"name": "urban"},
+					"egg_groups":      []any{map[string]any{"name": "ground"}},
+					"evolution_chain": map[string]any{"url": "https://pokeapi.co/api/v2/evolution-chain/67/"},
+					"flavor_text_entries": []any{
+						map[string]any{"flavor_text": "Its genetic code\nis irregular.", "language": map[string]any{"name": "en"}},
+					},
+				},
+			}, nil
+		},
+	}
+
+	embedder := &mockEmbedder{
+		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+			assert.Contains(t, texts[0], "Pokemon: eevee (ID: 133)")
+			assert.Contains(t, texts[0], "Capture Rate: 45")
+			assert.Contains(t, texts[0], "Habitat: urban")
+			assert.Contains(t, texts[0], "Egg Groups: ground")
+			assert.Contains(t, texts[0], "Evolution Chain: 67")
+			assert.Contains(t, texts[0], "Description: Its genetic code is irregular.")
+			return [][]float32{{0.1}}, nil
+		},
+	}
+
+	svc := NewService(embedder, &mockStore{}, pokemonGetter, &mockDatapack{}, &mockRepository{}, &mockProducer{}, &mockCache{})
+
+	err := svc.Ingest(ctx, IngestionEvent{Type: DocumentTypePokemon, ID: "133"})
+	require.NoError(t, err)
+}
+
+func TestIngestPokemon_SpeciesFetchError(t *testing.T) {
+	expectedErr := errors.New("species not found")
+
+	pokemonGetter := &mockPokemonGetter{
+		getFn: func(ctx context.Context, id string) (*pokemon.Pokemon, error) {
+			return &pokemon.Pokemon{ID: id, Identifier: "missingno"}, nil
+		},
+		getSpeciesFn: func(ctx context.Context, id string) (*pokemon.Species, error) {
+			return nil, expectedErr
+		},
+	}
+
+	svc := NewService(&mockEmbedder{}, &mockStore{}, pokemonGetter, &mockDatapack{}, &mockRepository{}, &mockProducer{}, &mockCache{})
 
-	err := svc.IngestPokemon(ctx, "25")
+	err := svc.Ingest(context.Background(), IngestionEvent{Type: DocumentTypePokemon, ID: "0"})
 	require.Error(t, err)
+	assert.Contains(t, err.Error(), "fetch species")
 	assert.ErrorIs(t, err, expectedErr)
 }
 
-func TestNewDocumentID(t *testing.T) {
-	tests := []struct {
-		docType    DocumentType
-		externalID string
-		expected   string
-	}{
-		{DocumentTypePokemon, "25", "pokemon_25"},
-		{DocumentTypePokemon, "pikachu", "pokemon_pikachu"},
+func TestIngestEvolutionChain_Success(t *testing.T) {
+	ctx := context.Background()
+
+	pokemonGetter := &mockPokemonGetter{
+		getChainFn: func(ctx context.Context, id string) (*pokemon.EvolutionChain, error) {
+			return &pokemon.EvolutionChain{
+				ID: id,
+				Metadata: map[string]any{
+					"chain": map[string]any{
+						"species": map[string]any{"name": "eevee"},
+						"evolves_to": []any{
+							map[string]any{
+								"species": map[string]any{"name": "vaporeon"},
+								"evolution_details": []any{
+									map[string]any{
+										"trigger": map[string]any{"name": "use-item"},
+										"item":    map[string]any{"name": "water-stone"},
+									},
+								},
+							},
+							map[string]any{
+								"species": map[string]any{"name": "espeon"},
+								"evolution_details": []any{
+									map[string]any{
+										"trigger":       map[string]any{"name": "level-up"},
+										"min_happiness": float64(160),
+										"time_of_day":   "day",
+									},
+								},
+							},
+						},
+					},
+				},
+			}, nil
+		},
 	}
 
-	for _, tc := range tests {
-		t.Run(tc.expected, func(t *testing.T) {
-			result := NewDocumentID(tc.docType, tc.externalID)
-			assert.Equal(t, tc.expected, result)
-		})
+	embedder := &mockEmbedder{
+		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+			assert.Contains(t, texts[0], "Evolution Chain (ID: 67)")
+			assert.Contains(t, texts[0], "Members: eevee, vaporeon, espeon")
+			assert.Contains(t, texts[0], "eevee evolves into vaporeon (use-item water-stone)")
+			assert.Contains(t, texts[0], "eevee evolves into espeon (level-up with friendship 160+ during the day)")
+			return [][]float32{{0.1}}, nil
+		},
+	}
+
+	store := &mockStore{}
+	repo := &mockRepository{}
+
+	svc := NewService(embedder, store, pokemonGetter, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+	err := svc.Ingest(ctx, IngestionEvent{Type: DocumentTypeEvolutionChain, ID: "67"})
+	require.NoError(t, err)
+
+	assert.Equal(t, DocumentTypeEvolutionChain, repo.upserted.DocumentType)
+	assert.Equal(t, "evolution_chain_67", store.deletedRef)
+}
+
+func TestIngestMove_Success(t *testing.T) {
+	ctx := context.Background()
+	moveID := "85"
+	vectors := [][]float32{{0.1, 0.2, 0.3}}
+
+	embedder := &mockEmbedder{
+		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+			require.Len(t, texts, 1)
+			assert.Contains(t, texts[0], "Move: thunderbolt (ID: 85)")
+			assert.Contains(t, texts[0], "Power: 90")
+			assert.Contains(t, texts[0], "Damage Class: special")
+			assert.Contains(t, texts[0], "Effect: Has a 10% chance to paralyze the target.")
+			assert.Contains(t, texts[0], "Learned By: pikachu, raichu")
+			return vectors, nil
+		},
+	}
+
+	pokemonGetter := &mockPokemonGetter{
+		getMoveFn: func(ctx context.Context, id string) (*pokemon.Move, error) {
+			assert.Equal(t, moveID, id)
+			return &pokemon.Move{
+				ID:         moveID,
+				Identifier: "thunderbolt",
+				Metadata: map[string]any{
+					"power":         float64(90),
+					"accuracy":      float64(100),
+					"pp":            float64(15),
+					"effect_chance": float64(10),
+					"type":          map[string]any{"name": "electric"},
+					"damage_class":  map[string]any{"name": "special"},
+					"effect_entries": []any{
+						map[string]any{
+							"short_effect": "Has a $effect_chance% chance to paralyze the target.",
+							"language":     map[string]any{"name": "en"},
+						},
+					},
+					"learned_by_pokemon": []any{
+						map[string]any{"name": "pikachu"},
+						map[string]any{"name": "raichu"},
+					},
+				},
+			}, nil
+		},
 	}
+
+	store := &mockStore{}
+	repo := &mockRepository{}
+
+	svc := NewService(embedder, store, pokemonGetter, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+	err := svc.Ingest(ctx, IngestionEvent{Type: DocumentTypeMove, ID: moveID})
+	require.NoError(t, err)
+
+	require.NotNil(t, repo.upserted)
+	assert.Equal(t, DocumentTypeMove, repo.upserted.DocumentType)
+	assert.Equal(t, moveID, repo.upserted.ExternalID)
+
+	assert.Equal(t, "move_85", store.deletedRef)
+
+	require.Len(t, store.upserted, 1)
+	assert.Equal(t, "move_85", store.upserted[0].Payload[referenceKey])
+	assert.Equal(t, string(DocumentTypeMove), store.upserted[0].Payload[typeKey])
+}
+
+func TestIngestMove_FetchError(t *testing.T) {
+	ctx := context.Background()
+	expectedErr := errors.New("move not found")
+
+	pokemonGetter := &mockPokemonGetter{
+		getMoveFn: func(ctx context.Context, id string) (*pokemon.Move, error) {
+			return nil, expectedErr
+		},
+	}
+
+	svc := NewService(&mockEmbedder{}, &mockStore{}, pokemonGetter, &mockDatapack{}, &mockRepository{}, &mockProducer{}, &mockCache{})
+
+	err := svc.Ingest(ctx, IngestionEvent{Type: DocumentTypeMove, ID: "999"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "fetch move")
+	assert.ErrorIs(t, err, expectedErr)
+}
+
+func TestIngest_AbilityItemType(t *testing.T) {
+	pokemonGetter := &mockPokemonGetter{
+		getAbilityFn: func(ctx context.Context, id string) (*pokemon.Ability, error) {
+			return &pokemon.Ability{
+				ID:         id,
+				Identifier: "static",
+				Metadata: map[string]any{
+					"pokemon": []any{
+						map[string]any{"is_hidden": false, "pokemon": map[string]any{"name": "pikachu"}},
+						map[string]any{"is_hidden": true, "pokemon": map[string]any{"name": "electrode"}},
+					},
+				},
+			}, nil
+		},
+		getItemFn: func(ctx context.Context, id string) (*pokemon.Item, error) {
+			return &pokemon.Item{
+				ID:         id,
+				Identifier: "leftovers",
+				Metadata: map[string]any{
+					"category": map[string]any{"name": "held-items"},
+				},
+			}, nil
+		},
+		getTypeFn: func(ctx context.Context, id string) (*pokemon.Type, error) {
+			return &pokemon.Type{
+				ID:         id,
+				Identifier: "electric",
+				Metadata: map[string]any{
+					"damage_relations": map[string]any{
+						"double_damage_to":   []any{map[string]any{"name": "water"}, map[string]any{"name": "flying"}},
+						"double_damage_from": []any{map[string]any{"name": "ground"}},
+					},
+				},
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		docType  DocumentType
+		id       string
+		ref      string
+		contains []string
+	}{
+		{DocumentTypeAbility, "9", "ability_9", []string{"Ability: static (ID: 9)", "Pokemon: pikachu", "Hidden Ability Of: electrode"}},
+		{DocumentTypeItem, "234", "item_234", []string{"Item: leftovers (ID: 234)", "Category: held-items"}},
+		{DocumentTypeType, "13", "type_13", []string{"Type: electric (ID: 13)", "Super Effective Against: water, flying", "Weak To: ground"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.docType), func(t *testing.T) {
+			embedder := &mockEmbedder{
+				embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+					for _, c := range tc.contains {
+						assert.Contains(t, texts[0], c)
+					}
+					return [][]float32{{0.1}}, nil
+				},
+			}
+			store := &mockStore{}
+			repo := &mockRepository{}
+
+			svc := NewService(embedder, store, pokemonGetter, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+			err := svc.Ingest(context.Background(), IngestionEvent{Type: tc.docType, ID: tc.id})
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.docType, repo.upserted.DocumentType)
+			require.Len(t, store.upserted, 1)
+			assert.Equal(t, tc.ref, store.upserted[0].Payload[referenceKey])
+			assert.Equal(t, string(tc.docType), store.upserted[0].Payload[typeKey])
+		})
+	}
+}
+
+func TestIngestSpawn_Success(t *testing.T) {
+	datapack := &mockDatapack{
+		spawnPools: map[string]*cobblemon.SpawnPool{
+			"charizard": {
+				ID: "charizard",
+				Metadata: map[string]any{
+					"spawns": []any{
+						map[string]any{
+							"id":      "charizard-1",
+							"pokemon": "charizard",
+							"bucket":  "ultra-rare",
+							"level":   "36-50",
+							"condition": map[string]any{
+								"biomes":    []any{"#cobblemon:is_volcanic"},
+								"canSeeSky": true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	embedder := &mockEmbedder{
+		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+			assert.Contains(t, texts[0], "Spawn: charizard (charizard-1)")
+			assert.Contains(t, texts[0], "bucket: ultra-rare, level: 36-50")
+			assert.Contains(t, texts[0], "Conditions Biomes: #cobblemon:is_volcanic")
+			assert.Contains(t, texts[0], "Conditions: canSeeSky=true")
+			return [][]float32{{0.1}}, nil
+		},
+	}
+
+	store := &mockStore{}
+	repo := &mockRepository{}
+
+	svc := NewService(embedder, store, &mockPokemonGetter{}, datapack, repo, &mockProducer{}, &mockCache{})
+
+	err := svc.Ingest(context.Background(), IngestionEvent{Type: DocumentTypeSpawn, ID: "charizard"})
+	require.NoError(t, err)
+
+	assert.Equal(t, DocumentTypeSpawn, repo.upserted.DocumentType)
+	assert.Equal(t, "spawn_charizard", store.deletedRef)
+}
+
+func TestIngestSpawn_NotInDatapack(t *testing.T) {
+	svc := NewService(&mockEmbedder{}, &mockStore{}, &mockPokemonGetter{}, &mockDatapack{}, &mockRepository{}, &mockProducer{}, &mockCache{})
+
+	err := svc.Ingest(context.Background(), IngestionEvent{Type: DocumentTypeSpawn, ID: "missingno"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, cobblemon.ErrNotFound)
+}
+
+func TestImportDatapack(t *testing.T) {
+	datapack := &mockDatapack{
+		species: map[string]*cobblemon.Species{
+			"charizard": {ID: "charizard", Identifier: "Charizard"},
+		},
+		spawnPools: map[string]*cobblemon.SpawnPool{
+			"charizard": {ID: "charizard"},
+		},
+	}
+
+	producer := &mockProducer{}
+	repo := &mockRepository{}
+
+	svc := NewService(&mockEmbedder{}, &mockStore{}, &mockPokemonGetter{}, datapack, repo, producer, &mockCache{})
+
+	job, err := svc.ImportDatapack(context.Background())
+	require.NoError(t, err)
+	require.Len(t, job.Documents, 2)
+	assert.Same(t, job, repo.job)
+
+	require.Len(t, producer.produced, 2)
+	assert.Equal(t, "cobblemon_species_charizard", producer.produced[0].key)
+	assert.Equal(t, "spawn_charizard", producer.produced[1].key)
+}
+
+func TestImportDatapack_Empty(t *testing.T) {
+	producer := &mockProducer{}
+	svc := NewService(&mockEmbedder{}, &mockStore{}, &mockPokemonGetter{}, &mockDatapack{}, &mockRepository{}, producer, &mockCache{})
+
+	_, err := svc.ImportDatapack(context.Background())
+	require.ErrorIs(t, err, ErrInvalidBatch)
+	assert.Empty(t, producer.produced)
+}
+
+// unwrapEvent parses a published envelope and decodes its data into v.
+func unwrapEvent(t *testing.T, payload []byte, v any) *cloudevents.Event {
+	t.Helper()
+	event, err := cloudevents.Parse(payload)
+	require.NoError(t, err)
+	assert.Equal(t, EventTypeIngestionRequestedV1, event.Type)
+	assert.Equal(t, EventSource, event.Source)
+	require.NoError(t, json.Unmarshal(event.Data, v))
+	return event
+}
+
+func TestEnqueue_PublishesEvents(t *testing.T) {
+	producer := &mockProducer{}
+	repo := &mockRepository{}
+
+	svc := NewService(&mockEmbedder{}, &mockStore{}, &mockPokemonGetter{}, &mockDatapack{}, repo, producer, &mockCache{})
+
+	ctx := cloudevents.WithTraceparent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	job, err := svc.Enqueue(ctx,
+		BatchIngestionEvent{Type: DocumentTypeMove, Ranges: []string{"1-40"}},
+		BatchIngestionEvent{Type: DocumentTypePokemon, IDs: []string{"25"}},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, JobStatusQueued, job.Status)
+	require.Len(t, job.Documents, 41)
+	assert.Same(t, job, repo.job)
+
+	require.Len(t, producer.produced, 3)
+	for _, msg := range producer.produced {
+		assert.Equal(t, string(TopicIngestion), msg.topic)
+	}
+
+	var first BatchIngestionEvent
+	unwrapEvent(t, producer.produced[0].value, &first)
+	assert.Equal(t, job.ID.String(), producer.produced[0].key)
+	assert.Equal(t, job.ID, first.JobID)
+	assert.Len(t, first.IDs, embedBatchSize)
+
+	var second BatchIngestionEvent
+	unwrapEvent(t, producer.produced[1].value, &second)
+	assert.Len(t, second.IDs, 40-embedBatchSize)
+
+	var single IngestionEvent
+	envelope := unwrapEvent(t, producer.produced[2].value, &single)
+	assert.Equal(t, "pokemon_25", producer.produced[2].key)
+	assert.Equal(t, "pokemon_25", envelope.Subject)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", envelope.Traceparent)
+	assert.Equal(t, IngestionEvent{Type: DocumentTypePokemon, ID: "25", JobID: job.ID}, single)
+}
+
+func TestEnqueue_InvalidBatch(t *testing.T) {
+	producer := &mockProducer{}
+	repo := &mockRepository{}
+	svc := NewService(&mockEmbedder{}, &mockStore{}, &mockPokemonGetter{}, &mockDatapack{}, repo, producer, &mockCache{})
+
+	_, err := svc.Enqueue(context.Background(), BatchIngestionEvent{Type: DocumentTypePokemon})
+	require.ErrorIs(t, err, ErrInvalidBatch)
+	assert.Nil(t, repo.job)
+	assert.Empty(t, producer.produced)
+}
+
+func TestEnqueue_ProduceErrorFailsDocuments(t *testing.T) {
+	producer := &mockProducer{
+		produceFn: func(ctx context.Context, topic string, key, value []byte) error {
+			return errors.New("broker unavailable")
+		},
+	}
+	repo := &mockRepository{}
+	svc := NewService(&mockEmbedder{}, &mockStore{}, &mockPokemonGetter{}, &mockDatapack{}, repo, producer, &mockCache{})
+
+	_, err := svc.Enqueue(context.Background(), BatchIngestionEvent{Type: DocumentTypePokemon, IDs: []string{"1", "2"}})
+	require.Error(t, err)
+	assert.Equal(t, []JobStatus{JobStatusFailed}, repo.jobStatuses["pokemon_1"])
+	assert.Equal(t, []JobStatus{JobStatusFailed}, repo.jobStatuses["pokemon_2"])
+}
+
+func TestIngest_TracksJobStatus(t *testing.T) {
+	pokemonGetter := &mockPokemonGetter{
+		getFn: func(ctx context.Context, id string) (*pokemon.Pokemon, error) {
+			if id == "2" {
+				return nil, errors.New("pokemon not found")
+			}
+			return &pokemon.Pokemon{ID: id, Identifier: "pokemon-" + id}, nil
+		},
+	}
+	embedder := &mockEmbedder{
+		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+			return make([][]float32, len(texts)), nil
+		},
+	}
+	repo := &mockRepository{}
+	jobID := uuid.Must(uuid.NewV7())
+
+	svc := NewService(embedder, &mockStore{}, pokemonGetter, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+	err := svc.Ingest(context.Background(), IngestionEvent{Type: DocumentTypePokemon, ID: "25", JobID: jobID})
+	require.NoError(t, err)
+
+	_, err = svc.IngestBatch(context.Background(), BatchIngestionEvent{Type: DocumentTypePokemon, IDs: []string{"1", "2"}, JobID: jobID})
+	require.NoError(t, err)
+
+	assert.Equal(t, []JobStatus{JobStatusRunning, JobStatusSucceeded}, repo.jobStatuses["pokemon_25"])
+	assert.Equal(t, []JobStatus{JobStatusRunning, JobStatusSucceeded}, repo.jobStatuses["pokemon_1"])
+	assert.Equal(t, []JobStatus{JobStatusRunning, JobStatusFailed}, repo.jobStatuses["pokemon_2"])
+}
+
+func TestIngest_WithoutJobSkipsTracking(t *testing.T) {
+	pokemonGetter := &mockPokemonGetter{
+		getFn: func(ctx context.Context, id string) (*pokemon.Pokemon, error) {
+			return &pokemon.Pokemon{ID: id, Identifier: "pikachu"}, nil
+		},
+	}
+	embedder := &mockEmbedder{
+		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+			return [][]float32{{0.1}}, nil
+		},
+	}
+	repo := &mockRepository{}
+
+	svc := NewService(embedder, &mockStore{}, pokemonGetter, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+	require.NoError(t, svc.Ingest(context.Background(), IngestionEvent{Type: DocumentTypePokemon, ID: "25"}))
+	assert.Nil(t, repo.jobStatuses)
+}
+
+func TestIngest_PublishesResultEvents(t *testing.T) {
+	pokemonGetter := &mockPokemonGetter{
+		getFn: func(ctx context.Context, id string) (*pokemon.Pokemon, error) {
+			if id == "2" {
+				return nil, errors.New("pokemon not found")
+			}
+			return &pokemon.Pokemon{ID: id, Identifier: "pokemon-" + id}, nil
+		},
+	}
+	embedder := &mockEmbedder{
+		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+			return make([][]float32, len(texts)), nil
+		},
+	}
+	producer := &mockProducer{}
+	jobID := uuid.Must(uuid.NewV7())
+	svc := NewService(embedder, &mockStore{}, pokemonGetter, &mockDatapack{}, &mockRepository{}, producer, &mockCache{})
+
+	require.NoError(t, svc.Ingest(context.Background(), IngestionEvent{Type: DocumentTypePokemon, ID: "25", JobID: jobID}))
+	require.Error(t, svc.Ingest(context.Background(), IngestionEvent{Type: DocumentTypePokemon, ID: "2"}))
+	_, err := svc.IngestBatch(context.Background(), BatchIngestionEvent{Type: DocumentTypePokemon, IDs: []string{"1", "2", "3"}})
+	require.NoError(t, err)
+
+	results := make(map[string][]IngestionResult)
+	for _, msg := range producer.produced {
+		event, err := cloudevents.Parse(msg.value)
+		require.NoError(t, err)
+		var result IngestionResult
+		require.NoError(t, json.Unmarshal(event.Data, &result))
+		assert.Equal(t, result.Reference, msg.key)
+		assert.Equal(t, result.Reference, event.Subject)
+
+		switch msg.topic {
+		case string(TopicIngestionCompleted):
+			assert.Equal(t, EventTypeIngestionCompletedV1, event.Type)
+			assert.Empty(t, result.Error)
+		case string(TopicIngestionFailed):
+			assert.Equal(t, EventTypeIngestionFailedV1, event.Type)
+			assert.Contains(t, result.Error, "pokemon not found")
+		default:
+			t.Fatalf("unexpected topic %s", msg.topic)
+		}
+		results[msg.topic] = append(results[msg.topic], result)
+	}
+
+	completed := results[string(TopicIngestionCompleted)]
+	require.Len(t, completed, 3)
+	assert.Equal(t, "pokemon_25", completed[0].Reference)
+	assert.Equal(t, jobID, completed[0].JobID)
+	assert.Equal(t, 1, completed[0].Vectors)
+	assert.Equal(t, ContentHash((&pokemon.Pokemon{ID: "25", Identifier: "pokemon-25", Species: &pokemon.Species{ID: "pokemon-25", Identifier: "pokemon-25"}}).EmbeddingText()), completed[0].ContentHash)
+	assert.Equal(t, []string{"pokemon_1", "pokemon_3"}, []string{completed[1].Reference, completed[2].Reference})
+	assert.Equal(t, 1, completed[1].Vectors)
+
+	failed := results[string(TopicIngestionFailed)]
+	require.Len(t, failed, 2)
+	assert.Equal(t, "pokemon_2", failed[0].Reference)
+	assert.Equal(t, "pokemon_2", failed[1].Reference)
+	assert.Zero(t, failed[0].Vectors)
+}
+
+func TestIngest_UnchangedDocumentPublishesNoResult(t *testing.T) {
+	pokemonGetter := &mockPokemonGetter{
+		getFn: func(ctx context.Context, id string) (*pokemon.Pokemon, error) {
+			return &pokemon.Pokemon{ID: id, Identifier: "pikachu"}, nil
+		},
+	}
+	p := &pokemon.Pokemon{ID: "25", Identifier: "pikachu", Species: &pokemon.Species{ID: "pikachu", Identifier: "pikachu"}}
+	hash := ContentHash(p.EmbeddingText())
+	repo := &mockRepository{
+		findFn: func(ctx context.Context, dt DocumentType, externalID string) (*IngestedDocument, error) {
+			return &IngestedDocument{ContentHash: hash, EmbeddingModel: testEmbedModel, EmbeddingDim: 3}, nil
+		},
+	}
+	producer := &mockProducer{}
+	svc := NewService(&mockEmbedder{}, &mockStore{}, pokemonGetter, &mockDatapack{}, repo, producer, &mockCache{})
+
+	require.NoError(t, svc.Ingest(context.Background(), IngestionEvent{Type: DocumentTypePokemon, ID: "25"}))
+	assert.Empty(t, producer.produced)
+}
+
+func TestIngest_ResultPublishFailureIsIgnored(t *testing.T) {
+	pokemonGetter := &mockPokemonGetter{
+		getFn: func(ctx context.Context, id string) (*pokemon.Pokemon, error) {
+			return &pokemon.Pokemon{ID: id, Identifier: "pikachu"}, nil
+		},
+	}
+	embedder := &mockEmbedder{
+		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+			return [][]float32{{0.1}}, nil
+		},
+	}
+	producer := &mockProducer{
+		produceFn: func(ctx context.Context, topic string, key, value []byte) error {
+			return errors.New("broker unavailable")
+		},
+	}
+	svc := NewService(embedder, &mockStore{}, pokemonGetter, &mockDatapack{}, &mockRepository{}, producer, &mockCache{})
+
+	require.NoError(t, svc.Ingest(context.Background(), IngestionEvent{Type: DocumentTypePokemon, ID: "25"}))
+	assert.Len(t, producer.produced, 1)
+}
+
+func TestIngest_BlockedResultPublishTimesOut(t *testing.T) {
+	timeout := resultPublishTimeout
+	resultPublishTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { resultPublishTimeout = timeout })
+
+	pokemonGetter := &mockPokemonGetter{
+		getFn: func(ctx context.Context, id string) (*pokemon.Pokemon, error) {
+			return &pokemon.Pokemon{ID: id, Identifier: "pikachu"}, nil
+		},
+	}
+	embedder := &mockEmbedder{
+		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+			return [][]float32{{0.1}}, nil
+		},
+	}
+	producer := &mockProducer{
+		produceFn: func(ctx context.Context, topic string, key, value []byte) error {
+			// An unreachable broker: the record is never acknowledged.
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	svc := NewService(embedder, &mockStore{}, pokemonGetter, &mockDatapack{}, &mockRepository{}, producer, &mockCache{})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Ingest(context.Background(), IngestionEvent{Type: DocumentTypePokemon, ID: "25"})
+	}()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("ingestion is held up by a blocked producer")
+	}
+	assert.Len(t, producer.produced, 1)
+}
+
+func TestIngest_SkipsUnchangedDocument(t *testing.T) {
+	pokemonGetter := &mockPokemonGetter{
+		getFn: func(ctx context.Context, id string) (*pokemon.Pokemon, error) {
+			return &pokemon.Pokemon{ID: id, Identifier: "pikachu"}, nil
+		},
+	}
+	p := &pokemon.Pokemon{ID: "25", Identifier: "pikachu", Species: &pokemon.Species{ID: "pikachu", Identifier: "pikachu"}}
+	hash := ContentHash(p.EmbeddingText())
+
+	tests := map[string]struct {
+		stored    *IngestedDocument
+		wantEmbed bool
+	}{
+		"unchanged":         {&IngestedDocument{ContentHash: hash, EmbeddingModel: testEmbedModel, EmbeddingDim: 3}, false},
+		"content changed":   {&IngestedDocument{ContentHash: "stale", EmbeddingModel: testEmbedModel, EmbeddingDim: 3}, true},
+		"model changed":     {&IngestedDocument{ContentHash: hash, EmbeddingModel: "embed/old", EmbeddingDim: 3}, true},
+		"dimension changed": {&IngestedDocument{ContentHash: hash, EmbeddingModel: testEmbedModel, EmbeddingDim: 1536}, true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var embedded bool
+			embedder := &mockEmbedder{
+				embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+					embedded = true
+					return [][]float32{{0.1}}, nil
+				},
+			}
+			store := &mockStore{}
+			repo := &mockRepository{
+				findFn: func(ctx context.Context, dt DocumentType, externalID string) (*IngestedDocument, error) {
+					return tt.stored, nil
+				},
+			}
+
+			svc := NewService(embedder, store, pokemonGetter, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+			err := svc.Ingest(context.Background(), IngestionEvent{Type: DocumentTypePokemon, ID: "25"})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantEmbed, embedded)
+			if !tt.wantEmbed {
+				assert.Empty(t, store.deleteFilters)
+				assert.Empty(t, store.upserted)
+				return
+			}
+			require.NotNil(t, repo.upserted)
+			assert.Equal(t, hash, repo.upserted.ContentHash)
+			assert.Equal(t, testEmbedModel, repo.upserted.EmbeddingModel)
+			assert.Equal(t, 3, repo.upserted.EmbeddingDim)
+		})
+	}
+}
+
+func TestIngestBatch_SkipsUnchangedDocuments(t *testing.T) {
+	pokemonGetter := &mockPokemonGetter{
+		getMoveFn: func(ctx context.Context, id string) (*pokemon.Move, error) {
+			return &pokemon.Move{ID: id, Identifier: "move-" + id}, nil
+		},
+	}
+	unchanged := &pokemon.Move{ID: "1", Identifier: "move-1"}
+
+	var embedded []string
+	embedder := &mockEmbedder{
+		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+			embedded = append(embedded, texts...)
+			return make([][]float32, len(texts)), nil
+		},
+	}
+	repo := &mockRepository{
+		findFn: func(ctx context.Context, dt DocumentType, externalID string) (*IngestedDocument, error) {
+			if externalID != "1" {
+				return nil, ErrNotFound
+			}
+			return &IngestedDocument{
+				ContentHash:    ContentHash(unchanged.EmbeddingText()),
+				EmbeddingModel: testEmbedModel,
+				EmbeddingDim:   3,
+			}, nil
+		},
+	}
+
+	svc := NewService(embedder, &mockStore{}, pokemonGetter, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+	result, err := svc.IngestBatch(context.Background(), BatchIngestionEvent{Type: DocumentTypeMove, Ranges: []string{"1-3"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, &BatchResult{Requested: 3, Ingested: 2, Skipped: 1}, result)
+	assert.Len(t, embedded, 2)
+}
+
+func TestReindex(t *testing.T) {
+	stale := []*IngestedDocument{
+		{ID: uuid.Must(uuid.NewV7()), DocumentType: DocumentTypeMove, ExternalID: "1"},
+		{ID: uuid.Must(uuid.NewV7()), DocumentType: DocumentTypePokemon, ExternalID: "25"},
+		{ID: uuid.Must(uuid.NewV7()), DocumentType: DocumentTypeMove, ExternalID: "2"},
+	}
+
+	pokemonGetter := &mockPokemonGetter{
+		getFn: func(ctx context.Context, id string) (*pokemon.Pokemon, error) {
+			return &pokemon.Pokemon{ID: id, Identifier: "pikachu"}, nil
+		},
+		getMoveFn: func(ctx context.Context, id string) (*pokemon.Move, error) {
+			if id == "2" {
+				return nil, errors.New("move not found")
+			}
+			return &pokemon.Move{ID: id, Identifier: "move-" + id}, nil
+		},
+	}
+	embedder := &mockEmbedder{
+		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+			return make([][]float32, len(texts)), nil
+		},
+	}
+
+	var afters []uuid.UUID
+	repo := &mockRepository{
+		findStaleFn: func(ctx context.Context, embedModel string, dim int, after uuid.UUID, limit int) ([]*IngestedDocument, error) {
+			assert.Equal(t, testEmbedModel, embedModel)
+			assert.Equal(t, 3, dim)
+			afters = append(afters, after)
+			if after == uuid.Nil {
+				return stale, nil
+			}
+			return nil, nil
+		},
+	}
+	store := &mockStore{}
+
+	svc := NewService(embedder, store, pokemonGetter, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+	result, err := svc.Reindex(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []uuid.UUID{uuid.Nil, stale[2].ID}, afters)
+	assert.Equal(t, 3, result.Requested)
+	assert.Equal(t, 2, result.Ingested)
+	assert.Equal(t, map[string]string{"move_2": "fetch move: move not found"}, result.Failed)
+	assert.Equal(t, 2, store.upsertCalls)
+}
+
+func TestRebuild(t *testing.T) {
+	docs := []*IngestedDocument{
+		{ID: uuid.Must(uuid.NewV7()), DocumentType: DocumentTypeMove, ExternalID: "1"},
+		{ID: uuid.Must(uuid.NewV7()), DocumentType: DocumentTypeMove, ExternalID: "2"},
+	}
+	current := &IngestedDocument{
+		ContentHash:    ContentHash((&pokemon.Move{ID: "1", Identifier: "move-1"}).EmbeddingText()),
+		EmbeddingModel: testEmbedModel,
+		EmbeddingDim:   3,
+	}
+
+	tests := map[string]struct {
+		failID  string
+		count   int
+		wantErr string
+	}{
+		"complete":        {count: 2},
+		"failed document": {failID: "2", count: 2, wantErr: "1 of 2 documents failed"},
+		"missing vectors": {count: 3, wantErr: "2 vectors for 3 documents"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			pokemonGetter := &mockPokemonGetter{
+				getMoveFn: func(ctx context.Context, id string) (*pokemon.Move, error) {
+					if id == tt.failID {
+						return nil, errors.New("move not found")
+					}
+					return &pokemon.Move{ID: id, Identifier: "move-" + id}, nil
+				},
+			}
+			embedder := &mockEmbedder{
+				embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+					return make([][]float32, len(texts)), nil
+				},
+			}
+			repo := &mockRepository{
+				count: tt.count,
+				listFn: func(ctx context.Context, after uuid.UUID, limit int) ([]*IngestedDocument, error) {
+					if after == uuid.Nil {
+						return docs, nil
+					}
+					return nil, nil
+				},
+				findFn: func(ctx context.Context, dt DocumentType, externalID string) (*IngestedDocument, error) {
+					return current, nil
+				},
+			}
+			store := &mockStore{}
+
+			svc := NewService(embedder, store, pokemonGetter, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+			result, err := svc.Rebuild(context.Background())
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 2, result.Ingested)
+			assert.Zero(t, result.Skipped, "rebuild must not skip documents that are current in the live collection")
+			assert.Empty(t, repo.outbox, "rebuild writes must not go through the shared outbox")
+			assert.Len(t, store.upserted, 2)
+		})
+	}
+}
+
+func TestIngestionJob_Summarize(t *testing.T) {
+	tests := map[string]struct {
+		statuses []JobStatus
+		want     JobStatus
+	}{
+		"all queued":        {[]JobStatus{JobStatusQueued, JobStatusQueued}, JobStatusQueued},
+		"partly started":    {[]JobStatus{JobStatusQueued, JobStatusSucceeded}, JobStatusRunning},
+		"running":           {[]JobStatus{JobStatusRunning, JobStatusSucceeded}, JobStatusRunning},
+		"retrying":          {[]JobStatus{JobStatusRetrying, JobStatusSucceeded}, JobStatusRunning},
+		"all succeeded":     {[]JobStatus{JobStatusSucceeded, JobStatusSucceeded}, JobStatusSucceeded},
+		"finished with err": {[]JobStatus{JobStatusSucceeded, JobStatusFailed}, JobStatusFailed},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			job := &IngestionJob{}
+			for _, status := range tt.statuses {
+				job.Documents = append(job.Documents, &JobDocument{Status: status})
+			}
+			job.summarize()
+			assert.Equal(t, tt.want, job.Status)
+		})
+	}
+}
+
+func TestIngestBatch_GroupsEmbedsAndWrites(t *testing.T) {
+	var mu sync.Mutex
+	var fetched []string
+
+	pokemonGetter := &mockPokemonGetter{
+		getMoveFn: func(ctx context.Context, id string) (*pokemon.Move, error) {
+			mu.Lock()
+			fetched = append(fetched, id)
+			mu.Unlock()
+			if id == "3" {
+				return nil, errors.New("move not found")
+			}
+			return &pokemon.Move{ID: id, Identifier: "move-" + id}, nil
+		},
+	}
+
+	var embedCalls int
+	embedder := &mockEmbedder{
+		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+			embedCalls++
+			vectors := make([][]float32, len(texts))
+			for i := range texts {
+				vectors[i] = []float32{float32(i)}
+			}
+			return vectors, nil
+		},
+	}
+
+	store := &mockStore{}
+	repo := &mockRepository{}
+
+	svc := NewService(embedder, store, pokemonGetter, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+	result, err := svc.IngestBatch(context.Background(), BatchIngestionEvent{
+		Type:   DocumentTypeMove,
+		IDs:    []string{"10", "1"},
+		Ranges: []string{"1-4"},
+	})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"10", "1", "2", "3", "4"}, fetched)
+	assert.Equal(t, 5, result.Requested)
+	assert.Equal(t, 4, result.Ingested)
+	assert.Equal(t, map[string]string{"3": "fetch move: move not found"}, result.Failed)
+
+	assert.Equal(t, 1, embedCalls)
+	assert.Equal(t, 4, repo.upsertCount)
+	assert.Equal(t, 1, store.upsertCalls)
+	require.Len(t, store.upserted, 4)
+
+	require.Len(t, store.deleteFilters, 1)
+	require.Len(t, store.deleteFilters[0].StringFilters, 4)
+	for _, f := range store.deleteFilters[0].StringFilters {
+		assert.Equal(t, vectorstore.FilterOR, f.Op)
+	}
+}
+
+func TestIngestBatch_SplitsEmbedCalls(t *testing.T) {
+	pokemonGetter := &mockPokemonGetter{
+		getMoveFn: func(ctx context.Context, id string) (*pokemon.Move, error) {
+			return &pokemon.Move{ID: id, Identifier: "move-" + id}, nil
+		},
+	}
+
+	var batchSizes []int
+	embedder := &mockEmbedder{
+		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+			batchSizes = append(batchSizes, len(texts))
+			return make([][]float32, len(texts)), nil
+		},
+	}
+
+	store := &mockStore{}
+
+	svc := NewService(embedder, store, pokemonGetter, &mockDatapack{}, &mockRepository{}, &mockProducer{}, &mockCache{})
+
+	result, err := svc.IngestBatch(context.Background(), BatchIngestionEvent{
+		Type:   DocumentTypeMove,
+		Ranges: []string{"1-70"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 70, result.Ingested)
+	assert.Equal(t, []int{embedBatchSize, embedBatchSize, 70 - 2*embedBatchSize}, batchSizes)
+	assert.Equal(t, 3, store.upsertCalls)
+}
+
+func TestBatchIngestionEvent_ExpandIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		batch    BatchIngestionEvent
+		expected []string
+		wantErr  bool
+	}{
+		{"ids", BatchIngestionEvent{Type: DocumentTypePokemon, IDs: []string{"pikachu", "25"}}, []string{"pikachu", "25"}, false},
+		{"range", BatchIngestionEvent{Type: DocumentTypePokemon, Ranges: []string{"1-3"}}, []string{"1", "2", "3"}, false},
+		{"dedupe", BatchIngestionEvent{Type: DocumentTypePokemon, IDs: []string{"2"}, Ranges: []string{"1-3", "3-4"}}, []string{"2", "1", "3", "4"}, false},
+		{"empty", BatchIngestionEvent{Type: DocumentTypePokemon}, nil, true},
+		{"malformed range", BatchIngestionEvent{Type: DocumentTypePokemon, Ranges: []string{"1..151"}}, nil, true},
+		{"reversed range", BatchIngestionEvent{Type: DocumentTypePokemon, Ranges: []string{"151-1"}}, nil, true},
+		{"too large", BatchIngestionEvent{Type: DocumentTypePokemon, Ranges: []string{"1-100000"}}, nil, true},
+		{"unknown type", BatchIngestionEvent{Type: "unknown", IDs: []string{"1"}}, nil, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ids, err := tc.batch.ExpandIDs()
+			if tc.wantErr {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrInvalidBatch)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+}
+
+func TestIngest_UnsupportedType(t *testing.T) {
+	svc := NewService(&mockEmbedder{}, &mockStore{}, &mockPokemonGetter{}, &mockDatapack{}, &mockRepository{}, &mockProducer{}, &mockCache{})
+
+	err := svc.Ingest(context.Background(), IngestionEvent{Type: "unknown", ID: "1"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported document type")
+}
+
+func TestNewDocumentID(t *testing.T) {
+	tests := []struct {
+		docType    DocumentType
+		externalID string
+		expected   string
+	}{
+		{DocumentTypePokemon, "25", "pokemon_25"},
+		{DocumentTypePokemon, "pikachu", "pokemon_pikachu"},
+		{DocumentTypeMove, "85", "move_85"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expected, func(t *testing.T) {
+			result := NewDocumentID(tc.docType, tc.externalID)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestParseDocumentID(t *testing.T) {
+	tests := []struct {
+		reference  string
+		docType    DocumentType
+		externalID string
+		ok         bool
+	}{
+		{"pokemon_25", DocumentTypePokemon, "25", true},
+		{"evolution_chain_67", DocumentTypeEvolutionChain, "67", true},
+		{"cobblemon_species_mr_mime", DocumentTypeCobblemonSpecies, "mr_mime", true},
+		{"type_fire", DocumentTypeType, "fire", true},
+		{"unknown_1", "", "", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.reference, func(t *testing.T) {
+			docType, externalID, ok := ParseDocumentID(tc.reference)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.docType, docType)
+			assert.Equal(t, tc.externalID, externalID)
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	docs := []*IngestedDocument{
+		{ID: uuid.Must(uuid.NewV7()), DocumentType: DocumentTypeMove, ExternalID: "1"},
+		{ID: uuid.Must(uuid.NewV7()), DocumentType: DocumentTypeMove, ExternalID: "2"},
+	}
+	newDocs := func() *mockRepository {
+		return &mockRepository{
+			listFn: func(ctx context.Context, after uuid.UUID, limit int) ([]*IngestedDocument, error) {
+				if after == uuid.Nil {
+					return docs, nil
+				}
+				return nil, nil
+			},
+			findFn: func(ctx context.Context, dt DocumentType, externalID string) (*IngestedDocument, error) {
+				if externalID == "3" {
+					// ingested after the scroll started
+					return &IngestedDocument{DocumentType: dt, ExternalID: externalID}, nil
+				}
+				return nil, ErrNotFound
+			},
+		}
+	}
+	newStore := func() *mockStore {
+		return &mockStore{
+			references: map[string][]string{
+				"move_1":  {"p1"},
+				"move_3":  {"p3"},
+				"move_99": {"p99a", "p99b"},
+			},
+		}
+	}
+	pokemonGetter := &mockPokemonGetter{
+		getMoveFn: func(ctx context.Context, id string) (*pokemon.Move, error) {
+			return &pokemon.Move{ID: id, Identifier: "move-" + id}, nil
+		},
+	}
+	embedder := &mockEmbedder{
+		embedFn: func(ctx context.Context, texts ...string) ([][]float32, error) {
+			return make([][]float32, len(texts)), nil
+		},
+	}
+
+	t.Run("report only", func(t *testing.T) {
+		store := newStore()
+		svc := NewService(embedder, store, pokemonGetter, &mockDatapack{}, newDocs(), &mockProducer{}, &mockCache{})
+
+		report, err := svc.Reconcile(context.Background(), false)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, report.Documents)
+		assert.Equal(t, 3, report.References)
+		assert.Equal(t, []string{"move_2"}, report.Missing)
+		assert.Equal(t, []string{"move_3", "move_99"}, report.Orphans)
+		assert.Equal(t, 3, report.OrphanPoints)
+		assert.Nil(t, report.Repair)
+		assert.Empty(t, store.upserted)
+		assert.Empty(t, store.deletedIDs)
+	})
+
+	t.Run("repair", func(t *testing.T) {
+		store := newStore()
+		svc := NewService(embedder, store, pokemonGetter, &mockDatapack{}, newDocs(), &mockProducer{}, &mockCache{})
+
+		report, err := svc.Reconcile(context.Background(), true)
+		require.NoError(t, err)
+
+		require.NotNil(t, report.Repair)
+		assert.Equal(t, 1, report.Repair.Ingested)
+		require.Len(t, store.upserted, 1)
+		assert.Equal(t, "move_2", store.upserted[0].Payload[referenceKey])
+
+		assert.ElementsMatch(t, []string{"p99a", "p99b"}, store.deletedIDs)
+		assert.Equal(t, 2, report.Deleted)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	ctx := context.Background()
+
+	store := &mockStore{}
+	repo := &mockRepository{}
+	cache := &mockCache{}
+	svc := NewService(&mockEmbedder{}, store, &mockPokemonGetter{}, &mockDatapack{}, repo, &mockProducer{}, cache)
+
+	require.NoError(t, svc.Delete(ctx, DocumentTypeCobblemonSpecies, "fakemon"))
+
+	assert.Equal(t, []string{"cobblemon_species_fakemon"}, repo.deletedRefs)
+	assert.Equal(t, "cobblemon_species_fakemon", store.deletedRef)
+	assert.Zero(t, store.upsertCalls)
+	assert.Equal(t, []string{"cobblemon_species_fakemon"}, cache.invalidated)
+
+	require.Len(t, repo.outbox, 1)
+	assert.Equal(t, OutboxDelete, repo.outbox[0].Operation)
+	assert.Equal(t, OutboxDone, repo.outbox[0].Status)
+}
+
+func TestDelete_VectorStoreDown(t *testing.T) {
+	ctx := context.Background()
+
+	store := &mockStore{
+		deleteFn: func(ctx context.Context, filter vectorstore.Filter) error {
+			return errors.New("qdrant unavailable")
+		},
+	}
+	repo := &mockRepository{}
+	cache := &mockCache{}
+	svc := NewService(&mockEmbedder{}, store, &mockPokemonGetter{}, &mockDatapack{}, repo, &mockProducer{}, cache)
+
+	require.NoError(t, svc.Delete(ctx, DocumentTypePokemon, "25"), "the vector delete is left to the outbox")
+
+	require.Len(t, repo.outbox, 1)
+	assert.Equal(t, OutboxPending, repo.outbox[0].Status)
+	assert.Equal(t, 1, repo.outbox[0].Attempts)
+	assert.Equal(t, []string{"pokemon_25"}, cache.invalidated)
+}
+
+func TestDelete_UnsupportedType(t *testing.T) {
+	repo := &mockRepository{}
+	svc := NewService(&mockEmbedder{}, &mockStore{}, &mockPokemonGetter{}, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+	err := svc.Delete(context.Background(), DocumentType("widget"), "1")
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+	assert.Empty(t, repo.deletedRefs)
+}
+
+func TestListDocuments(t *testing.T) {
+	ctx := context.Background()
+
+	docs := make([]*IngestedDocument, 3)
+	for i := range docs {
+		docs[i] = &IngestedDocument{ID: uuid.Must(uuid.NewV7()), DocumentType: DocumentTypeMove, ExternalID: fmt.Sprint(i + 1)}
+	}
+
+	var got DocumentFilter
+	repo := &mockRepository{
+		count: 7,
+		listDocsFn: func(ctx context.Context, filter DocumentFilter) ([]*IngestedDocument, error) {
+			got = filter
+			return docs[:min(filter.Limit, len(docs))], nil
+		},
+	}
+	svc := NewService(&mockEmbedder{}, &mockStore{}, &mockPokemonGetter{}, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+	page, err := svc.ListDocuments(ctx, DocumentFilter{Type: DocumentTypeMove, Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 7, page.Total)
+	assert.Len(t, page.Documents, 3)
+	assert.Equal(t, docs[2].ID, page.Next, "a full page carries a cursor")
+
+	page, err = svc.ListDocuments(ctx, DocumentFilter{Limit: 10000})
+	require.NoError(t, err)
+	assert.Equal(t, maxDocumentPageSize, got.Limit)
+	assert.Equal(t, uuid.Nil, page.Next, "a short page is the last one")
+
+	_, err = svc.ListDocuments(ctx, DocumentFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, documentPageSize, got.Limit)
+
+	_, err = svc.ListDocuments(ctx, DocumentFilter{Type: "widget"})
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
+
+func TestGetDocument(t *testing.T) {
+	ctx := context.Background()
+
+	doc := &IngestedDocument{ID: uuid.Must(uuid.NewV7()), DocumentType: DocumentTypePokemon, ExternalID: "25"}
+	repo := &mockRepository{
+		findFn: func(ctx context.Context, dt DocumentType, externalID string) (*IngestedDocument, error) {
+			if externalID != "25" {
+				return nil, ErrNotFound
+			}
+			return doc, nil
+		},
+	}
+	store := &mockStore{upserted: []vectorstore.Point{
+		{ID: "p1", Payload: map[string]any{referenceKey: "pokemon_25", contentKey: "Pokemon: pikachu"}},
+		{ID: "p2", Payload: map[string]any{referenceKey: "pokemon_25", contentKey: "Pokemon: pikachu"}},
+		{ID: "p3", Payload: map[string]any{referenceKey: "pokemon_26", contentKey: "Pokemon: raichu"}},
+	}}
+	svc := NewService(&mockEmbedder{}, store, &mockPokemonGetter{}, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+	detail, err := svc.GetDocument(ctx, DocumentTypePokemon, "25")
+	require.NoError(t, err)
+	assert.Equal(t, doc, detail.IngestedDocument)
+	assert.Equal(t, "pokemon_25", detail.Reference)
+	assert.Equal(t, "Pokemon: pikachu", detail.Content)
+	assert.Equal(t, []string{"p1", "p2"}, detail.PointIDs)
+
+	_, err = svc.GetDocument(ctx, DocumentTypePokemon, "26000")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestGetDocument_Chunks(t *testing.T) {
+	repo := &mockRepository{
+		findFn: func(ctx context.Context, dt DocumentType, externalID string) (*IngestedDocument, error) {
+			return &IngestedDocument{DocumentType: dt, ExternalID: externalID, Content: "Pokemon: pikachu\nTypes: electric\nMoves: thunderbolt\n"}, nil
+		},
+	}
+	store := &mockStore{upserted: []vectorstore.Point{
+		{ID: "p2", Payload: map[string]any{referenceKey: "pokemon_25", chunkIndexKey: int64(1), sectionKey: sectionMoves, contentKey: "Pokemon: pikachu\nMoves: thunderbolt\n"}},
+		{ID: "p1", Payload: map[string]any{referenceKey: "pokemon_25", chunkIndexKey: int64(0), sectionKey: sectionStats, contentKey: "Pokemon: pikachu\nTypes: electric\n"}},
+	}}
+	svc := NewService(&mockEmbedder{}, store, &mockPokemonGetter{}, &mockDatapack{}, repo, &mockProducer{}, &mockCache{})
+
+	detail, err := svc.GetDocument(context.Background(), DocumentTypePokemon, "25")
+	require.NoError(t, err)
+	assert.Equal(t, []DocumentChunk{
+		{PointID: "p1", Index: 0, Section: sectionStats, Content: "Pokemon: pikachu\nTypes: electric\n"},
+		{PointID: "p2", Index: 1, Section: sectionMoves, Content: "Pokemon: pikachu\nMoves: thunderbolt\n"},
+	}, detail.Chunks)
+	assert.Equal(t, "Pokemon: pikachu\nTypes: electric\nMoves: thunderbolt\n", detail.Content,
+		"the content is stored once rather than joined from chunks that repeat the header")
 }
@@ -4,12 +4,60 @@ import (
 	"context"
 	platformredis "cyrene/internal/platform/redis"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/firebase/genkit/go/ai"
+	"github.com/redis/go-redis/v9"
 )
 
+// titleMaxLen is the number of characters of the first prompt a conversation is
+// titled with until it is renamed.
+const titleMaxLen = 80
+
+var ErrNotFound = errors.New("conversation not found")
+
+// renameScript sets the title of a conversation only if it still exists, so a
+// conversation that expires mid-rename is not recreated without a TTL. Renaming
+// counts as an update: the conversation's TTL and index score are refreshed.
+var renameScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+redis.call("HSET", KEYS[1], "title", ARGV[1], "updated_at", ARGV[2])
+redis.call("PEXPIRE", KEYS[1], ARGV[3])
+redis.call("PEXPIRE", KEYS[2], ARGV[3])
+redis.call("ZADD", KEYS[3], ARGV[2], ARGV[4])
+redis.call("PEXPIRE", KEYS[3], ARGV[3])
+return 1
+`)
+
+// compactScript replaces the oldest messages of a conversation with a summary only
+// if the conversation still exists, so a compaction that finishes after the
+// conversation was deleted or expired does not recreate it without a TTL.
+var compactScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+redis.call("LTRIM", KEYS[2], ARGV[1], -1)
+redis.call("HSET", KEYS[1], "summary", ARGV[2])
+redis.call("PEXPIRE", KEYS[1], ARGV[3])
+redis.call("PEXPIRE", KEYS[2], ARGV[3])
+return 1
+`)
+
+// Conversation describes one of a user's conversations.
+type Conversation struct {
+	ID           string
+	Title        string
+	Summary      string
+	MessageCount int
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
 type ChatStore struct {
 	client     *platformredis.Client
 	maxMessage int
@@ -24,14 +72,24 @@ func NewChatStore(client *platformredis.Client, maxMessage int, ttl time.Duratio
 	}
 }
 
-func (c *ChatStore) key(user string) string {
-	return fmt.Sprintf("chat:history:%s", user)
+// key holds the messages of a conversation, metaKey its title and timestamps and
+// indexKey the IDs of a user's conversations scored by when they were last updated.
+func (c *ChatStore) key(user, conversation string) string {
+	return fmt.Sprintf("chat:history:%s:%s", user, conversation)
+}
+
+func (c *ChatStore) metaKey(user, conversation string) string {
+	return fmt.Sprintf("chat:conversation:%s:%s", user, conversation)
+}
+
+func (c *ChatStore) indexKey(user string) string {
+	return fmt.Sprintf("chat:conversations:%s", user)
 }
 
 // Technically, I should make my own message type but i cba
 
-func (c *ChatStore) Get(ctx context.Context, user string) ([]*ai.Message, error) {
-	data, err := c.client.Client.LRange(ctx, c.key(user), 0, -1).Result()
+func (c *ChatStore) Get(ctx context.Context, user, conversation string) ([]*ai.Message, error) {
+	data, err := c.client.Client.LRange(ctx, c.key(user, conversation), 0, -1).Result()
 	if err != nil {
 		return nil, err
 	}
@@ -47,8 +105,15 @@ func (c *ChatStore) Get(ctx context.Context, user string) ([]*ai.Message, error)
 	return messages, nil
 }
 
-func (c *ChatStore) Append(ctx context.Context, user string, messages ...*ai.Message) error {
-	key := c.key(user)
+// Append adds messages to a conversation, creating it on first use with the first
+// user message as its title. The conversation expires ttl after its last update.
+// Conversations are expected to be compacted well before they reach maxMessage;
+// beyond that the oldest messages are dropped.
+func (c *ChatStore) Append(ctx context.Context, user, conversation string, messages ...*ai.Message) error {
+	key := c.key(user, conversation)
+	metaKey := c.metaKey(user, conversation)
+	indexKey := c.indexKey(user)
+	now := time.Now()
 	pipe := c.client.Client.Pipeline()
 
 	for _, msg := range messages {
@@ -62,10 +127,136 @@ func (c *ChatStore) Append(ctx context.Context, user string, messages ...*ai.Mes
 	pipe.LTrim(ctx, key, int64(-c.maxMessage), -1)
 	pipe.Expire(ctx, key, c.ttl)
 
+	pipe.HSetNX(ctx, metaKey, "title", title(messages))
+	pipe.HSetNX(ctx, metaKey, "created_at", now.UnixMilli())
+	pipe.HSet(ctx, metaKey, "updated_at", now.UnixMilli())
+	pipe.Expire(ctx, metaKey, c.ttl)
+
+	pipe.ZAdd(ctx, indexKey, redis.Z{Score: float64(now.UnixMilli()), Member: conversation})
+	pipe.ZRemRangeByScore(ctx, indexKey, "-inf", strconv.FormatInt(now.Add(-c.ttl).UnixMilli(), 10))
+	pipe.Expire(ctx, indexKey, c.ttl)
+
 	_, err := pipe.Exec(ctx)
 	return err
 }
 
-func (c *ChatStore) Clear(ctx context.Context, user string) error {
-	return c.client.Client.Del(ctx, c.key(user)).Err()
+// Summary returns the summary of the messages compacted out of a conversation, or
+// an empty string if there is none.
+func (c *ChatStore) Summary(ctx context.Context, user, conversation string) (string, error) {
+	summary, err := c.client.Client.HGet(ctx, c.metaKey(user, conversation), "summary").Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return summary, err
+}
+
+// Compact replaces the oldest n messages of a conversation with summary, or returns
+// ErrNotFound. Messages appended in the meantime are kept, since they are added at
+// the other end.
+func (c *ChatStore) Compact(ctx context.Context, user, conversation, summary string, n int) error {
+	keys := []string{c.metaKey(user, conversation), c.key(user, conversation)}
+	compacted, err := compactScript.Run(ctx, c.client.Client, keys, n, summary, c.ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if compacted == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, conversation)
+	}
+	return nil
+}
+
+// List returns the user's conversations that have not expired, most recently
+// updated first.
+func (c *ChatStore) List(ctx context.Context, user string) ([]Conversation, error) {
+	indexKey := c.indexKey(user)
+	expired := strconv.FormatInt(time.Now().Add(-c.ttl).UnixMilli(), 10)
+	if err := c.client.Client.ZRemRangeByScore(ctx, indexKey, "-inf", expired).Err(); err != nil {
+		return nil, err
+	}
+	ids, err := c.client.Client.ZRevRange(ctx, indexKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	conversations := make([]Conversation, 0, len(ids))
+	for _, id := range ids {
+		conv, err := c.Conversation(ctx, user, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, *conv)
+	}
+	return conversations, nil
+}
+
+// Conversation returns a conversation of the user, or ErrNotFound.
+func (c *ChatStore) Conversation(ctx context.Context, user, conversation string) (*Conversation, error) {
+	pipe := c.client.Client.Pipeline()
+	meta := pipe.HGetAll(ctx, c.metaKey(user, conversation))
+	length := pipe.LLen(ctx, c.key(user, conversation))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	fields := meta.Val()
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, conversation)
+	}
+	created, _ := strconv.ParseInt(fields["created_at"], 10, 64)
+	updated, _ := strconv.ParseInt(fields["updated_at"], 10, 64)
+	return &Conversation{
+		ID:           conversation,
+		Title:        fields["title"],
+		Summary:      fields["summary"],
+		MessageCount: int(length.Val()),
+		CreatedAt:    time.UnixMilli(created).UTC(),
+		UpdatedAt:    time.UnixMilli(updated).UTC(),
+	}, nil
+}
+
+// Rename sets the title of a conversation, or returns ErrNotFound.
+func (c *ChatStore) Rename(ctx context.Context, user, conversation, title string) error {
+	keys := []string{c.metaKey(user, conversation), c.key(user, conversation), c.indexKey(user)}
+	renamed, err := renameScript.Run(ctx, c.client.Client, keys,
+		title, time.Now().UnixMilli(), c.ttl.Milliseconds(), conversation,
+	).Int()
+	if err != nil {
+		return err
+	}
+	if renamed == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, conversation)
+	}
+	return nil
+}
+
+// Delete removes a conversation and its messages, or returns ErrNotFound.
+func (c *ChatStore) Delete(ctx context.Context, user, conversation string) error {
+	pipe := c.client.Client.TxPipeline()
+	deleted := pipe.Del(ctx, c.key(user, conversation), c.metaKey(user, conversation))
+	pipe.ZRem(ctx, c.indexKey(user), conversation)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	if deleted.Val() == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, conversation)
+	}
+	return nil
+}
+
+// title returns the start of the first user message among messages.
+func title(messages []*ai.Message) string {
+	for _, msg := range messages {
+		if msg.Role != ai.RoleUser {
+			continue
+		}
+		text := []rune(msg.Text())
+		if len(text) > titleMaxLen {
+			return string(text[:titleMaxLen]) + "..."
+		}
+		return string(text)
+	}
+	return ""
 }
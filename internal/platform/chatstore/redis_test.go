@@ -0,0 +1,179 @@
+package chatstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	platformredis "cyrene/internal/platform/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTTL = 5 * time.Minute
+
+func newTestStore(t *testing.T) (*ChatStore, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewChatStore(&platformredis.Client{Client: client}, 100, testTTL), server
+}
+
+func turn(prompt, answer string) []*ai.Message {
+	return []*ai.Message{ai.NewUserTextMessage(prompt), ai.NewModelTextMessage(answer)}
+}
+
+func TestAppend_CreatesConversation(t *testing.T) {
+	ctx := context.Background()
+	store, server := newTestStore(t)
+
+	require.NoError(t, store.Append(ctx, "ash", "web-1", turn("how fast is pikachu?", "90 speed.")...))
+	require.NoError(t, store.Append(ctx, "ash", "web-1", turn("and raichu?", "110 speed.")...))
+
+	messages, err := store.Get(ctx, "ash", "web-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 4)
+	assert.Equal(t, "and raichu?", messages[2].Text())
+
+	conv, err := store.Conversation(ctx, "ash", "web-1")
+	require.NoError(t, err)
+	assert.Equal(t, "how fast is pikachu?", conv.Title, "titled after the first prompt")
+	assert.Equal(t, 4, conv.MessageCount)
+	assert.False(t, conv.UpdatedAt.Before(conv.CreatedAt))
+	assert.Equal(t, testTTL, server.TTL(store.metaKey("ash", "web-1")))
+}
+
+func TestList_PrunesExpiredConversations(t *testing.T) {
+	ctx := context.Background()
+	store, server := newTestStore(t)
+
+	require.NoError(t, store.Append(ctx, "ash", "web-1", turn("hi", "hello")...))
+	require.NoError(t, store.Append(ctx, "ash", "web-2", turn("hey", "hello again")...))
+	_, err := server.ZAdd(store.indexKey("ash"), float64(time.Now().Add(-2*testTTL).UnixMilli()), "old")
+	require.NoError(t, err)
+	// The index entry of web-1 outlives its expired meta hash.
+	server.Del(store.metaKey("ash", "web-1"))
+
+	conversations, err := store.List(ctx, "ash")
+	require.NoError(t, err)
+	require.Len(t, conversations, 1)
+	assert.Equal(t, "web-2", conversations[0].ID)
+
+	members, err := server.ZMembers(store.indexKey("ash"))
+	require.NoError(t, err)
+	assert.NotContains(t, members, "old", "entries older than the TTL are removed from the index")
+}
+
+func TestCompact(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestStore(t)
+
+	require.NoError(t, store.Append(ctx, "ash", "web-1", turn("I want a Gyarados", "Catch a Magikarp.")...))
+	require.NoError(t, store.Append(ctx, "ash", "web-1", turn("where?", "In rivers.")...))
+	require.NoError(t, store.Append(ctx, "ash", "web-1", turn("what level?", "Level 20.")...))
+
+	require.NoError(t, store.Compact(ctx, "ash", "web-1", "The player is hunting Gyarados.", 4))
+
+	messages, err := store.Get(ctx, "ash", "web-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "what level?", messages[0].Text())
+
+	summary, err := store.Summary(ctx, "ash", "web-1")
+	require.NoError(t, err)
+	assert.Equal(t, "The player is hunting Gyarados.", summary)
+
+	conv, err := store.Conversation(ctx, "ash", "web-1")
+	require.NoError(t, err)
+	assert.Equal(t, "The player is hunting Gyarados.", conv.Summary)
+	assert.Equal(t, "I want a Gyarados", conv.Title)
+}
+
+func TestCompact_RefreshesTTL(t *testing.T) {
+	ctx := context.Background()
+	store, server := newTestStore(t)
+
+	require.NoError(t, store.Append(ctx, "ash", "web-1", turn("I want a Gyarados", "Catch a Magikarp.")...))
+	server.FastForward(testTTL / 2)
+
+	require.NoError(t, store.Compact(ctx, "ash", "web-1", "The player is hunting Gyarados.", 1))
+	assert.Equal(t, testTTL, server.TTL(store.metaKey("ash", "web-1")))
+	assert.Equal(t, testTTL, server.TTL(store.key("ash", "web-1")))
+}
+
+func TestCompact_DeletedConversation(t *testing.T) {
+	ctx := context.Background()
+	store, server := newTestStore(t)
+
+	require.NoError(t, store.Append(ctx, "ash", "web-1", turn("hi", "hello")...))
+	require.NoError(t, store.Delete(ctx, "ash", "web-1"))
+
+	err := store.Compact(ctx, "ash", "web-1", "The player said hi.", 2)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.False(t, server.Exists(store.metaKey("ash", "web-1")), "a deleted conversation is not recreated")
+}
+
+func TestSummary_NoSummary(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	summary, err := store.Summary(context.Background(), "ash", "web-1")
+	require.NoError(t, err)
+	assert.Empty(t, summary)
+}
+
+func TestRename(t *testing.T) {
+	ctx := context.Background()
+	store, server := newTestStore(t)
+
+	require.NoError(t, store.Append(ctx, "ash", "web-1", turn("hi", "hello")...))
+	server.FastForward(testTTL / 2)
+
+	require.NoError(t, store.Rename(ctx, "ash", "web-1", "Team building"))
+
+	conv, err := store.Conversation(ctx, "ash", "web-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Team building", conv.Title)
+	assert.Equal(t, testTTL, server.TTL(store.metaKey("ash", "web-1")), "renaming refreshes the TTL")
+	assert.Equal(t, testTTL, server.TTL(store.key("ash", "web-1")))
+}
+
+func TestRename_Expired(t *testing.T) {
+	ctx := context.Background()
+	store, server := newTestStore(t)
+
+	require.NoError(t, store.Append(ctx, "ash", "web-1", turn("hi", "hello")...))
+	server.FastForward(testTTL + time.Second)
+
+	assert.ErrorIs(t, store.Rename(ctx, "ash", "web-1", "Team building"), ErrNotFound)
+	assert.False(t, server.Exists(store.metaKey("ash", "web-1")), "an expired conversation is not recreated")
+}
+
+func TestDelete(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestStore(t)
+
+	assert.ErrorIs(t, store.Delete(ctx, "ash", "web-1"), ErrNotFound)
+
+	require.NoError(t, store.Append(ctx, "ash", "web-1", turn("hi", "hello")...))
+	require.NoError(t, store.Delete(ctx, "ash", "web-1"))
+
+	_, err := store.Conversation(ctx, "ash", "web-1")
+	assert.ErrorIs(t, err, ErrNotFound)
+	conversations, err := store.List(ctx, "ash")
+	require.NoError(t, err)
+	assert.Empty(t, conversations)
+
+	assert.ErrorIs(t, store.Delete(ctx, "ash", "web-1"), ErrNotFound)
+}
+
+func TestTitle(t *testing.T) {
+	long := string(make([]rune, titleMaxLen+10))
+	assert.Equal(t, "hi", title(turn("hi", "hello")))
+	assert.Equal(t, titleMaxLen+3, len([]rune(title(turn(long, "")))))
+	assert.Empty(t, title([]*ai.Message{ai.NewModelTextMessage("hello")}))
+}
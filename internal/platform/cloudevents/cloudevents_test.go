@@ -0,0 +1,129 @@
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tests := map[string]struct {
+		ctx         context.Context
+		traceparent string
+	}{
+		"without trace context": {context.Background(), ""},
+		"with trace context": {
+			WithTraceparent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"),
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			start := time.Now()
+			event, err := New(tt.ctx, "cyrene.ingestion.requested.v1", "/ingest", "pokemon_25", map[string]string{"id": "25"})
+			require.NoError(t, err)
+
+			id, err := uuid.Parse(event.ID)
+			require.NoError(t, err)
+			assert.Equal(t, uuid.Version(7), id.Version())
+			assert.Equal(t, SpecVersion, event.SpecVersion)
+			assert.Equal(t, ContentTypeJSON, event.DataContentType)
+			assert.Equal(t, "pokemon_25", event.Subject)
+			assert.Equal(t, tt.traceparent, event.Traceparent)
+			assert.WithinDuration(t, start, event.Time, time.Second)
+			assert.JSONEq(t, `{"id":"25"}`, string(event.Data))
+
+			payload, err := json.Marshal(event)
+			require.NoError(t, err)
+			assert.True(t, IsEnvelope(payload))
+			parsed, err := Parse(payload)
+			require.NoError(t, err)
+			assert.Equal(t, event.ID, parsed.ID)
+			assert.Equal(t, event.Traceparent, parsed.Traceparent)
+			assert.JSONEq(t, string(event.Data), string(parsed.Data))
+		})
+	}
+}
+
+func TestNew_UnmarshalableData(t *testing.T) {
+	_, err := New(context.Background(), "cyrene.ingestion.requested.v1", "/ingest", "", make(chan int))
+	assert.ErrorContains(t, err, "marshal cyrene.ingestion.requested.v1 data")
+}
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		payload string
+		wantErr bool
+	}{
+		"valid": {
+			payload: `{"specversion":"1.0","id":"1","type":"cyrene.ingestion.requested.v1","source":"/x","datacontenttype":"application/json","data":{"id":"25"}}`,
+		},
+		"minor spec version": {
+			payload: `{"specversion":"1.1","id":"1","type":"cyrene.ingestion.requested.v1","source":"/x"}`,
+		},
+		"json content type with charset": {
+			payload: `{"specversion":"1.0","id":"1","type":"cyrene.ingestion.requested.v1","source":"/x","datacontenttype":"application/json; charset=utf-8"}`,
+		},
+		"missing specversion": {
+			payload: `{"id":"1","type":"cyrene.ingestion.requested.v1","source":"/x"}`,
+			wantErr: true,
+		},
+		"unsupported specversion": {
+			payload: `{"specversion":"2.0","id":"1","type":"cyrene.ingestion.requested.v1","source":"/x"}`,
+			wantErr: true,
+		},
+		"missing type": {
+			payload: `{"specversion":"1.0","id":"1","source":"/x"}`,
+			wantErr: true,
+		},
+		"missing id": {
+			payload: `{"specversion":"1.0","type":"cyrene.ingestion.requested.v1","source":"/x"}`,
+			wantErr: true,
+		},
+		"non-json data": {
+			payload: `{"specversion":"1.0","id":"1","type":"cyrene.ingestion.requested.v1","source":"/x","datacontenttype":"application/avro","data":"AAE="}`,
+			wantErr: true,
+		},
+		"malformed envelope": {
+			payload: `{"specversion":"1.0","id":`,
+			wantErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			event, err := Parse([]byte(tt.payload))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidEvent)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "cyrene.ingestion.requested.v1", event.Type)
+		})
+	}
+}
+
+func TestIsEnvelope(t *testing.T) {
+	tests := map[string]struct {
+		payload string
+		want    bool
+	}{
+		"envelope":       {`{"specversion":"1.0","type":"x"}`, true},
+		"legacy payload": {`{"type":"pokemon","id":"25"}`, false},
+		"not json":       {`pokemon 25`, false},
+		"json array":     {`[{"specversion":"1.0"}]`, false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsEnvelope([]byte(tt.payload)))
+		})
+	}
+}
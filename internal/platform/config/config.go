@@ -19,10 +19,13 @@ type Config struct {
 	Genkit     GenkitConfig
 	PokemonAPI PokemonAPIConfig
 	ChatStore  ChatStoreConfig
+	Cobblemon  CobblemonConfig
+	Ingest     IngestConfig
 }
 
 type ServerConfig struct {
-	Addr string
+	Addr        string
+	AdminAPIKey string `mapstructure:"ADMIN_API_KEY"`
 }
 
 type DBConfig struct {
@@ -42,8 +45,14 @@ type RedisConfig struct {
 }
 
 type KafkaConfig struct {
-	Brokers       []string `mapstructure:"KAFKA_BROKERS"`
-	ConsumerGroup string   `mapstructure:"KAFKA_CONSUMER_GROUP"`
+	Mode              string   `mapstructure:"KAFKA_MODE"`
+	Brokers           []string `mapstructure:"KAFKA_BROKERS"`
+	ConsumerGroup     string   `mapstructure:"KAFKA_CONSUMER_GROUP"`
+	MaxRetries        int      `mapstructure:"KAFKA_MAX_RETRIES"`
+	RetryBackoffMS    int      `mapstructure:"KAFKA_RETRY_BACKOFF_MS"`
+	RetryMaxBackoffMS int      `mapstructure:"KAFKA_RETRY_MAX_BACKOFF_MS"`
+	Concurrency       int      `mapstructure:"KAFKA_CONCURRENCY"`
+	StallTimeoutSec   int      `mapstructure:"KAFKA_STALL_TIMEOUT_SECONDS"`
 }
 
 type QdrantConfig struct {
@@ -72,8 +81,19 @@ type PokemonAPIConfig struct {
 }
 
 type ChatStoreConfig struct {
-	MaxMessages int `mapstructure:"CHATSTORE_MAX_MESSAGES"`
-	TTLMinutes  int `mapstructure:"CHATSTORE_TTL_MINUTES"`
+	MaxMessages   int `mapstructure:"CHATSTORE_MAX_MESSAGES"`
+	TTLMinutes    int `mapstructure:"CHATSTORE_TTL_MINUTES"`
+	HistoryTokens int `mapstructure:"CHATSTORE_HISTORY_TOKENS"`
+}
+
+type CobblemonConfig struct {
+	DatapackPath string `mapstructure:"COBBLEMON_DATAPACK_PATH"`
+}
+
+type IngestConfig struct {
+	ReconcileIntervalMinutes int  `mapstructure:"RECONCILE_INTERVAL_MINUTES"`
+	ReconcileRepair          bool `mapstructure:"RECONCILE_REPAIR"`
+	OutboxIntervalSeconds    int  `mapstructure:"OUTBOX_INTERVAL_SECONDS"`
 }
 
 var cfg Config
@@ -94,8 +114,14 @@ func Load() {
 	viper.SetDefault("REDIS_HOST", "localhost")
 	viper.SetDefault("REDIS_PORT", "6379")
 	viper.SetDefault("REDIS_DB", 0)
+	viper.SetDefault("KAFKA_MODE", "kafka")
 	viper.SetDefault("KAFKA_BROKERS", []string{"localhost:19092"})
 	viper.SetDefault("KAFKA_CONSUMER_GROUP", "cyrene")
+	viper.SetDefault("KAFKA_MAX_RETRIES", 3)
+	viper.SetDefault("KAFKA_RETRY_BACKOFF_MS", 500)
+	viper.SetDefault("KAFKA_RETRY_MAX_BACKOFF_MS", 30000)
+	viper.SetDefault("KAFKA_CONCURRENCY", 8)
+	viper.SetDefault("KAFKA_STALL_TIMEOUT_SECONDS", 300)
 	viper.SetDefault("QDRANT_HOST", "localhost")
 	viper.SetDefault("QDRANT_PORT", 6334)
 	viper.SetDefault("QDRANT_API_KEY", "")
@@ -109,8 +135,12 @@ func Load() {
 	viper.SetDefault("AGENT_MODEL", "openai/gpt-oss-120b:exacto")
 	viper.SetDefault("FAST_MODEL", "openai/gpt-oss-120b")
 	//viper.SetDefault("POKEMON_API_KEY", "")
-	viper.SetDefault("CHATSTORE_MAX_MESSAGES", 5)
+	viper.SetDefault("CHATSTORE_MAX_MESSAGES", 100)
 	viper.SetDefault("CHATSTORE_TTL_MINUTES", 5)
+	viper.SetDefault("CHATSTORE_HISTORY_TOKENS", 4000)
+	viper.SetDefault("RECONCILE_INTERVAL_MINUTES", 0)
+	viper.SetDefault("RECONCILE_REPAIR", false)
+	viper.SetDefault("OUTBOX_INTERVAL_SECONDS", 5)
 
 	if err := viper.ReadInConfig(); err != nil {
 		var configFileNotFoundError viper.ConfigFileNotFoundError
@@ -122,7 +152,8 @@ func Load() {
 	cfg = Config{
 		AppEnv: viper.GetString("APP_ENV"),
 		Server: ServerConfig{
-			Addr: fmt.Sprintf(":%d", viper.GetInt("PORT")),
+			Addr:        fmt.Sprintf(":%d", viper.GetInt("PORT")),
+			AdminAPIKey: viper.GetString("ADMIN_API_KEY"),
 		},
 		DB: DBConfig{
 			Host:     viper.GetString("DB_HOST"),
@@ -139,8 +170,14 @@ func Load() {
 			DB:       viper.GetInt("REDIS_DB"),
 		},
 		Kafka: KafkaConfig{
-			Brokers:       viper.GetStringSlice("KAFKA_BROKERS"),
-			ConsumerGroup: viper.GetString("KAFKA_CONSUMER_GROUP"),
+			Mode:              viper.GetString("KAFKA_MODE"),
+			Brokers:           viper.GetStringSlice("KAFKA_BROKERS"),
+			ConsumerGroup:     viper.GetString("KAFKA_CONSUMER_GROUP"),
+			MaxRetries:        viper.GetInt("KAFKA_MAX_RETRIES"),
+			RetryBackoffMS:    viper.GetInt("KAFKA_RETRY_BACKOFF_MS"),
+			RetryMaxBackoffMS: viper.GetInt("KAFKA_RETRY_MAX_BACKOFF_MS"),
+			Concurrency:       viper.GetInt("KAFKA_CONCURRENCY"),
+			StallTimeoutSec:   viper.GetInt("KAFKA_STALL_TIMEOUT_SECONDS"),
 		},
 		Qdrant: QdrantConfig{
 			Host:               viper.GetString("QDRANT_HOST"),
@@ -164,8 +201,17 @@ func Load() {
 			BaseURL: viper.GetString("POKEMON_BASE_URL"),
 		},
 		ChatStore: ChatStoreConfig{
-			MaxMessages: viper.GetInt("CHATSTORE_MAX_MESSAGES"),
-			TTLMinutes:  viper.GetInt("CHATSTORE_TTL_MINUTES"),
+			MaxMessages:   viper.GetInt("CHATSTORE_MAX_MESSAGES"),
+			TTLMinutes:    viper.GetInt("CHATSTORE_TTL_MINUTES"),
+			HistoryTokens: viper.GetInt("CHATSTORE_HISTORY_TOKENS"),
+		},
+		Cobblemon: CobblemonConfig{
+			DatapackPath: viper.GetString("COBBLEMON_DATAPACK_PATH"),
+		},
+		Ingest: IngestConfig{
+			ReconcileIntervalMinutes: viper.GetInt("RECONCILE_INTERVAL_MINUTES"),
+			ReconcileRepair:          viper.GetBool("RECONCILE_REPAIR"),
+			OutboxIntervalSeconds:    viper.GetInt("OUTBOX_INTERVAL_SECONDS"),
 		},
 	}
 }
@@ -193,3 +239,7 @@ func GetQdrant() *QdrantConfig {
 func GetGenkit() *GenkitConfig { return &cfg.Genkit }
 
 func GetChatStore() *ChatStoreConfig { return &cfg.ChatStore }
+
+func GetCobblemon() *CobblemonConfig { return &cfg.Cobblemon }
+
+func GetIngest() *IngestConfig { return &cfg.Ingest }
@@ -0,0 +1,136 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"cyrene/internal/platform/config"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Headers set on dead-lettered records.
+const (
+	HeaderError             = "x-error"
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+	HeaderAttempts          = "x-attempts"
+	HeaderFailedAt          = "x-failed-at"
+)
+
+const deadLetterSuffix = ".dlq"
+
+// replayIdleTimeout is how long a replay waits for more dead letters before it
+// considers the topic drained.
+const replayIdleTimeout = 5 * time.Second
+
+// DeadLetterTopic returns the dead-letter topic for records that failed on topic.
+func DeadLetterTopic(topic string) string {
+	return topic + deadLetterSuffix
+}
+
+// deadLetter publishes a copy of the record to its dead-letter topic, keeping the
+// key and headers and describing the failure in extra headers.
+func (c *consumer) deadLetter(ctx context.Context, record *kgo.Record, attempts int, cause error) error {
+	headers := append([]kgo.RecordHeader{}, record.Headers...)
+	headers = append(headers,
+		kgo.RecordHeader{Key: HeaderError, Value: []byte(cause.Error())},
+		kgo.RecordHeader{Key: HeaderOriginalTopic, Value: []byte(record.Topic)},
+		kgo.RecordHeader{Key: HeaderOriginalPartition, Value: []byte(strconv.Itoa(int(record.Partition)))},
+		kgo.RecordHeader{Key: HeaderOriginalOffset, Value: []byte(strconv.FormatInt(record.Offset, 10))},
+		kgo.RecordHeader{Key: HeaderAttempts, Value: []byte(strconv.Itoa(attempts))},
+		kgo.RecordHeader{Key: HeaderFailedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+	)
+
+	err := c.source.produce(ctx, &kgo.Record{
+		Topic:   DeadLetterTopic(record.Topic),
+		Key:     record.Key,
+		Value:   record.Value,
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("publish dead letter for %s/%d/%d: %w", record.Topic, record.Partition, record.Offset, err)
+	}
+	return nil
+}
+
+// ReplayDeadLetters publishes the records of a dead-letter topic back to the topics
+// they failed on, without the failure headers, and returns how many were replayed.
+// Progress is committed under a dedicated consumer group, so each dead letter is
+// replayed once; the replay stops when no new dead letters arrive for a few seconds.
+func ReplayDeadLetters(ctx context.Context, cfg *config.KafkaConfig, dlqTopic string) (int, error) {
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(cfg.Brokers...),
+		kgo.ConsumerGroup(cfg.ConsumerGroup+"-replay"),
+		kgo.ConsumeTopics(dlqTopic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+		kgo.DisableAutoCommit(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("create kafka client: %w", err)
+	}
+	src := &kafkaSource{client: client}
+	defer src.close()
+
+	return replay(ctx, src, dlqTopic, replayIdleTimeout)
+}
+
+// replay drains dlqTopic from src, treating an idle poll as the end of the topic.
+func replay(ctx context.Context, src source, dlqTopic string, idle time.Duration) (int, error) {
+	replayed := 0
+	for {
+		pollCtx, cancel := context.WithTimeout(ctx, idle)
+		records, err := src.poll(pollCtx)
+		cancel()
+
+		if err := ctx.Err(); err != nil {
+			return replayed, err
+		}
+		if errors.Is(err, context.DeadlineExceeded) || (err == nil && len(records) == 0) {
+			return replayed, nil
+		}
+		if err != nil {
+			return replayed, fmt.Errorf("kafka fetch errors: %w", err)
+		}
+
+		for _, record := range records {
+			if err := src.produce(ctx, replayRecord(record)); err != nil {
+				return replayed, fmt.Errorf("replay %s/%d/%d: %w", record.Topic, record.Partition, record.Offset, err)
+			}
+			replayed++
+		}
+		if err := src.commit(ctx, records...); err != nil {
+			return replayed, fmt.Errorf("commit replayed records: %w", err)
+		}
+		slog.Info("replayed dead letters", "topic", dlqTopic, "replayed", replayed)
+	}
+}
+
+// replayRecord rebuilds the original record from a dead letter.
+func replayRecord(record *kgo.Record) *kgo.Record {
+	topic := strings.TrimSuffix(record.Topic, deadLetterSuffix)
+
+	var headers []kgo.RecordHeader
+	for _, h := range record.Headers {
+		switch h.Key {
+		case HeaderOriginalTopic:
+			topic = string(h.Value)
+		case HeaderError, HeaderOriginalPartition, HeaderOriginalOffset, HeaderAttempts, HeaderFailedAt:
+		default:
+			headers = append(headers, h)
+		}
+	}
+
+	return &kgo.Record{
+		Topic:   topic,
+		Key:     record.Key,
+		Value:   record.Value,
+		Headers: headers,
+	}
+}
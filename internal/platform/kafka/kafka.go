@@ -2,7 +2,12 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"cyrene/internal/platform/config"
 
@@ -13,30 +18,132 @@ import (
 
 type Handler func(ctx context.Context, payload []byte) error
 
-// Producer wraps the Kafka producer client.
-type Producer struct {
+// permanentError is a handler error that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler error that retrying cannot fix, such as a malformed
+// payload, so the record is sent to the dead-letter topic without retries.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked by Permanent.
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+type lastAttemptKey struct{}
+
+// LastAttempt reports whether a handler runs its record for the last time, so a
+// failure sends the record to the dead-letter topic rather than being retried.
+// Outside a consumer every call is the last attempt.
+func LastAttempt(ctx context.Context) bool {
+	last, ok := ctx.Value(lastAttemptKey{}).(bool)
+	return !ok || last
+}
+
+// Producer publishes records to a topic.
+type Producer interface {
+	Produce(ctx context.Context, topic string, key, value []byte) error
+	Close()
+}
+
+// Consumer routes the records of its topics to their handlers.
+type Consumer interface {
+	Run(ctx context.Context) error
+	Status() ConsumerStatus
+	Close(ctx context.Context) error
+}
+
+// kafkaProducer wraps the Kafka producer client.
+type kafkaProducer struct {
 	client *kgo.Client
 }
 
-// Consumer wraps the Kafka consumer client.
-type Consumer struct {
-	client   *kgo.Client
+// consumer implements Consumer on top of a record source: a Kafka cluster or a MemoryBroker.
+type consumer struct {
+	source   source
 	handlers map[string]Handler
+	retry    RetryPolicy
+	// concurrency caps how many keys are handled in parallel.
+	concurrency int
+
+	status  *statusTracker
+	running atomic.Bool
+	done    chan struct{}
+}
+
+// source is where a consumer polls records from, commits its progress to and
+// publishes dead letters to.
+type source interface {
+	// poll blocks until records are available or ctx is done.
+	poll(ctx context.Context) ([]*kgo.Record, error)
+	// allowRebalance lets the group rebalance once the last poll has been processed.
+	allowRebalance()
+	// rewind moves each record's partition back so the record is polled again.
+	rewind(records []*kgo.Record)
+	commit(ctx context.Context, records ...*kgo.Record) error
+	produce(ctx context.Context, record *kgo.Record) error
+	close()
+}
+
+// kafkaSource is a source backed by a consumer group client.
+type kafkaSource struct {
+	client *kgo.Client
+}
+
+// commitTimeout bounds the final offset commit of a poll interrupted by shutdown.
+const commitTimeout = 10 * time.Second
+
+// deliveryTimeout bounds how long a produced record is retried before it fails,
+// so a producer does not wait on an unreachable cluster indefinitely.
+const deliveryTimeout = 30 * time.Second
+
+// pollRetry is the backoff between attempts after a failed poll or a poll whose
+// records could not all be handled.
+var pollRetry = RetryPolicy{Backoff: time.Second, MaxBackoff: time.Minute}
+
+// RetryPolicy controls how often a failing record is handled again before it is
+// sent to the dead-letter topic. The delay doubles after every retry up to MaxBackoff.
+type RetryPolicy struct {
+	MaxRetries int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// delay returns how long to wait before the given retry, counting from 1.
+func (p RetryPolicy) delay(retry int) time.Duration {
+	backoff := p.Backoff
+	for i := 1; i < retry && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, p.MaxBackoff)
 }
 
 // NewProducer creates a new Kafka producer.
-func NewProducer(cfg *config.KafkaConfig) (*Producer, error) {
+func NewProducer(cfg *config.KafkaConfig) (Producer, error) {
 	client, err := kgo.NewClient(
 		kgo.SeedBrokers(cfg.Brokers...),
+		kgo.RecordDeliveryTimeout(deliveryTimeout),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("create kafka producer: %w", err)
 	}
-	return &Producer{client: client}, nil
+	return &kafkaProducer{client: client}, nil
 }
 
 // Produce sends a message to the specified topic synchronously.
-func (p *Producer) Produce(ctx context.Context, topic string, key, value []byte) error {
+func (p *kafkaProducer) Produce(ctx context.Context, topic string, key, value []byte) error {
 	record := &kgo.Record{
 		Topic: topic,
 		Key:   key,
@@ -47,14 +154,14 @@ func (p *Producer) Produce(ctx context.Context, topic string, key, value []byte)
 }
 
 // Close closes the producer client.
-func (p *Producer) Close() {
+func (p *kafkaProducer) Close() {
 	p.client.Close()
 }
 
 // NewConsumer creates a new Kafka consumer.
 // handlers maps topic names to their handler functions.
 // Topics are derived from the handler map keys.
-func NewConsumer(cfg *config.KafkaConfig, handlers map[string]Handler) (*Consumer, error) {
+func NewConsumer(cfg *config.KafkaConfig, handlers map[string]Handler) (Consumer, error) {
 	topics := make([]string, 0, len(handlers))
 	for topic := range handlers {
 		topics = append(topics, topic)
@@ -64,42 +171,313 @@ func NewConsumer(cfg *config.KafkaConfig, handlers map[string]Handler) (*Consume
 		kgo.SeedBrokers(cfg.Brokers...),
 		kgo.ConsumerGroup(cfg.ConsumerGroup),
 		kgo.ConsumeTopics(topics...),
+		kgo.DisableAutoCommit(),
+		kgo.BlockRebalanceOnPoll(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("create kafka client: %w", err)
 	}
 
-	return &Consumer{
-		client:   client,
-		handlers: handlers,
-	}, nil
+	return newConsumer(cfg, handlers, &kafkaSource{client: client}), nil
+}
+
+func newConsumer(cfg *config.KafkaConfig, handlers map[string]Handler, src source) *consumer {
+	return &consumer{
+		source:      src,
+		handlers:    handlers,
+		concurrency: max(cfg.Concurrency, 1),
+		status:      newStatusTracker(time.Duration(cfg.StallTimeoutSec) * time.Second),
+		done:        make(chan struct{}),
+		retry: RetryPolicy{
+			MaxRetries: cfg.MaxRetries,
+			Backoff:    time.Duration(cfg.RetryBackoffMS) * time.Millisecond,
+			MaxBackoff: time.Duration(cfg.RetryMaxBackoffMS) * time.Millisecond,
+		},
+	}
 }
 
 // Run starts the consumer loop, routing messages to handlers based on topic.
-func (c *Consumer) Run(ctx context.Context) error {
+// The records of each poll are handled concurrently, and their offsets are
+// committed once handled; rebalances wait until a poll is fully processed.
+// Transient fetch errors and polls that could not be fully handled are retried
+// with backoff, rewinding to the first unhandled record. Run only returns when
+// ctx is cancelled or on an error it cannot recover from.
+func (c *consumer) Run(ctx context.Context) (err error) {
+	c.running.Store(true)
+	defer close(c.done)
+	defer func() { c.status.stopped(err) }()
+
+	failures := 0
+	retry := func(err error) bool {
+		failures++
+		c.status.failed(err)
+		delay := pollRetry.delay(failures)
+		slog.Warn("kafka consumer error, retrying", "attempt", failures, "retry_in", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+			return true
+		}
+	}
+
 	for {
-		fetches := c.client.PollFetches(ctx)
-		if err := ctx.Err(); err != nil {
+		records, err := c.source.poll(ctx)
+		if ctx.Err() != nil {
+			c.source.allowRebalance()
+			return nil // graceful shutdown
+		}
+
+		if err != nil {
+			c.source.allowRebalance()
+			if !isTransient(err) {
+				return fmt.Errorf("kafka fetch: %w", err)
+			}
+			if !retry(err) {
+				return nil
+			}
+			continue
+		}
+
+		c.status.polled()
+		rewind, err := c.process(ctx, records)
+		if err != nil && ctx.Err() == nil {
+			c.source.rewind(rewind)
+		}
+		c.source.allowRebalance()
+		if ctx.Err() != nil {
 			return nil // graceful shutdown
 		}
+		if err != nil {
+			if !retry(err) {
+				return nil
+			}
+			continue
+		}
+
+		failures = 0
+		c.status.succeeded()
+	}
+}
+
+// Status returns a snapshot of the consumer's state.
+func (c *consumer) Status() ConsumerStatus {
+	return c.status.snapshot()
+}
 
-		if errs := fetches.Errors(); len(errs) > 0 {
-			return fmt.Errorf("kafka fetch errors: %v", errs)
+// Close waits for Run to return, at most until ctx is done, and closes the client.
+func (c *consumer) Close(ctx context.Context) error {
+	var err error
+	if c.running.Load() {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			err = fmt.Errorf("wait for kafka consumer: %w", ctx.Err())
 		}
+	}
+	c.source.close()
+	return err
+}
+
+// process handles the records of one poll with up to c.concurrency goroutines, one
+// per key, so records with the same key run in order while other keys run in parallel.
+// A key stops at its first failure. Offsets are then committed up to the last record
+// of each partition that was handled along with everything before it, and the first
+// unhandled record of every other partition is returned so it can be fetched again.
+func (c *consumer) process(ctx context.Context, records []*kgo.Record) ([]*kgo.Record, error) {
+	if len(records) == 0 {
+		return nil, nil
+	}
 
-		iter := fetches.RecordIter()
-		for !iter.Done() {
-			record := iter.Next()
-			handler, ok := c.handlers[record.Topic]
-			if !ok {
-				// TODO: log no handler for topic
-				continue
+	handled := make([]bool, len(records))
+	var mu sync.Mutex
+	var errs []error
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.concurrency)
+	for _, group := range keyGroups(records) {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			for _, i := range group {
+				if err := c.handle(ctx, records[i]); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+					return
+				}
+				handled[i] = true
 			}
-			if err := handler(ctx, record.Value); err != nil {
-				// TODO: log and continue
+		}()
+	}
+	wg.Wait()
+
+	commitCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), commitTimeout)
+	defer cancel()
+	committable, unhandled := splitHandled(records, handled)
+	if len(committable) > 0 {
+		if err := c.source.commit(commitCtx, committable...); err != nil {
+			errs = append(errs, fmt.Errorf("commit offsets: %w", err))
+		}
+	}
+
+	return unhandled, errors.Join(errs...)
+}
+
+// keyGroups splits the records, given in fetch order, into groups that must be
+// handled sequentially: one per topic and key. Records without a key are grouped
+// by partition to keep their partition order.
+func keyGroups(records []*kgo.Record) [][]int {
+	type groupKey struct {
+		topic     string
+		key       string
+		partition int32
+	}
+
+	var groups [][]int
+	index := make(map[groupKey]int)
+	for i, record := range records {
+		k := groupKey{topic: record.Topic, key: string(record.Key), partition: -1}
+		if len(record.Key) == 0 {
+			k.partition = record.Partition
+		}
+		g, ok := index[k]
+		if !ok {
+			g = len(groups)
+			index[k] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
+
+// splitHandled returns, per partition, the last record before the first one that
+// was not handled, and that first unhandled record. Records are in fetch order,
+// which is offset order within a partition.
+func splitHandled(records []*kgo.Record, handled []bool) (committable, unhandled []*kgo.Record) {
+	type partition struct {
+		topic     string
+		partition int32
+	}
+
+	last := make(map[partition]*kgo.Record)
+	blocked := make(map[partition]bool)
+	var order []partition
+	for i, record := range records {
+		p := partition{record.Topic, record.Partition}
+		if blocked[p] {
+			continue
+		}
+		if !handled[i] {
+			blocked[p] = true
+			unhandled = append(unhandled, record)
+			continue
+		}
+		if _, ok := last[p]; !ok {
+			order = append(order, p)
+		}
+		last[p] = record
+	}
+
+	for _, p := range order {
+		committable = append(committable, last[p])
+	}
+	return committable, unhandled
+}
+
+// handle runs the record's handler, retrying with backoff, and dead-letters the
+// record once the retries are used up or the handler fails permanently. It only fails when the dead letter could
+// not be published or ctx is cancelled, so a failed record is never dropped silently.
+func (c *consumer) handle(ctx context.Context, record *kgo.Record) error {
+	handler, ok := c.handlers[record.Topic]
+	if !ok {
+		slog.Warn("no kafka handler for topic", "topic", record.Topic)
+		return nil
+	}
+
+	attempts := 0
+	for {
+		attempts++
+		err := handler(context.WithValue(ctx, lastAttemptKey{}, attempts > c.retry.MaxRetries), record.Value)
+		if err == nil {
+			c.status.update(func(s *ConsumerStatus) { s.Handled++ })
+			return nil
+		}
+		if attempts > c.retry.MaxRetries || IsPermanent(err) {
+			slog.Error("kafka record failed, sending to dead-letter topic",
+				"topic", record.Topic,
+				"partition", record.Partition,
+				"offset", record.Offset,
+				"attempts", attempts,
+				"permanent", IsPermanent(err),
+				"error", err,
+			)
+			if err := c.deadLetter(ctx, record, attempts, err); err != nil {
+				return err
 			}
+			c.status.update(func(s *ConsumerStatus) { s.DeadLettered++ })
+			return nil
+		}
+
+		c.status.update(func(s *ConsumerStatus) { s.Retried++ })
+		delay := c.retry.delay(attempts)
+		slog.Warn("kafka handler failed, retrying",
+			"topic", record.Topic,
+			"offset", record.Offset,
+			"attempt", attempts,
+			"retry_in", delay,
+			"error", err,
+		)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (s *kafkaSource) poll(ctx context.Context) ([]*kgo.Record, error) {
+	fetches := s.client.PollFetches(ctx)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := fetchError(fetches); err != nil {
+		return nil, err
+	}
+	return fetches.Records(), nil
+}
+
+func (s *kafkaSource) allowRebalance() {
+	s.client.AllowRebalance()
+}
+
+func (s *kafkaSource) rewind(records []*kgo.Record) {
+	if len(records) == 0 {
+		return
+	}
+	offsets := make(map[string]map[int32]kgo.EpochOffset)
+	for _, record := range records {
+		if offsets[record.Topic] == nil {
+			offsets[record.Topic] = make(map[int32]kgo.EpochOffset)
 		}
+		offsets[record.Topic][record.Partition] = kgo.EpochOffset{Epoch: -1, Offset: record.Offset}
 	}
+	s.client.SetOffsets(offsets)
+}
+
+func (s *kafkaSource) commit(ctx context.Context, records ...*kgo.Record) error {
+	return s.client.CommitRecords(ctx, records...)
+}
+
+func (s *kafkaSource) produce(ctx context.Context, record *kgo.Record) error {
+	return s.client.ProduceSync(ctx, record).FirstErr()
+}
+
+func (s *kafkaSource) close() {
+	s.client.Close()
 }
 
 func EnsureTopics(ctx context.Context, brokers []string, topics []string) error {
@@ -0,0 +1,326 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cyrene/internal/platform/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// stubSource records what a consumer publishes and commits.
+type stubSource struct {
+	mu         sync.Mutex
+	produced   []*kgo.Record
+	produceErr error
+	committed  []*kgo.Record
+}
+
+func (s *stubSource) poll(ctx context.Context) ([]*kgo.Record, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (s *stubSource) allowRebalance() {}
+
+func (s *stubSource) rewind(records []*kgo.Record) {}
+
+func (s *stubSource) commit(ctx context.Context, records ...*kgo.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.committed = append(s.committed, records...)
+	return nil
+}
+
+func (s *stubSource) produce(ctx context.Context, record *kgo.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.produced = append(s.produced, record)
+	return s.produceErr
+}
+
+func (s *stubSource) close() {}
+
+func newTestConsumer(handler Handler, produceErr error) (*consumer, *stubSource) {
+	src := &stubSource{produceErr: produceErr}
+	c := newConsumer(&config.KafkaConfig{
+		Concurrency:       4,
+		MaxRetries:        2,
+		RetryBackoffMS:    1,
+		RetryMaxBackoffMS: 2,
+		StallTimeoutSec:   60,
+	}, map[string]Handler{"ingestion": handler}, src)
+	return c, src
+}
+
+func header(record *kgo.Record, key string) string {
+	for _, h := range record.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func TestConsumer_RetriesThenSucceeds(t *testing.T) {
+	calls := 0
+	c, src := newTestConsumer(func(ctx context.Context, payload []byte) error {
+		calls++
+		if calls < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	}, nil)
+
+	err := c.handle(context.Background(), &kgo.Record{Topic: "ingestion", Value: []byte("{}")})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Empty(t, src.produced)
+}
+
+func TestConsumer_FlagsLastAttempt(t *testing.T) {
+	var last []bool
+	c, _ := newTestConsumer(func(ctx context.Context, payload []byte) error {
+		last = append(last, LastAttempt(ctx))
+		return errors.New("pokemon not found")
+	}, nil)
+
+	require.NoError(t, c.handle(context.Background(), &kgo.Record{Topic: "ingestion"}))
+	assert.Equal(t, []bool{false, false, true}, last)
+	assert.True(t, LastAttempt(context.Background()), "a call outside a consumer is not retried")
+}
+
+func TestConsumer_DeadLettersAfterRetries(t *testing.T) {
+	calls := 0
+	c, src := newTestConsumer(func(ctx context.Context, payload []byte) error {
+		calls++
+		return errors.New("pokemon not found")
+	}, nil)
+
+	record := &kgo.Record{
+		Topic:     "ingestion",
+		Partition: 2,
+		Offset:    41,
+		Key:       []byte("pokemon_99999"),
+		Value:     []byte(`{"type":"pokemon","id":"99999"}`),
+		Headers:   []kgo.RecordHeader{{Key: "traceparent", Value: []byte("00-abc-def-01")}},
+	}
+	require.NoError(t, c.handle(context.Background(), record))
+	assert.Equal(t, 3, calls, "one attempt plus MaxRetries retries")
+	status := c.Status()
+	assert.EqualValues(t, 2, status.Retried)
+	assert.EqualValues(t, 1, status.DeadLettered)
+	assert.Zero(t, status.Handled)
+
+	require.Len(t, src.produced, 1)
+	dead := src.produced[0]
+	assert.Equal(t, "ingestion.dlq", dead.Topic)
+	assert.Equal(t, record.Key, dead.Key)
+	assert.Equal(t, record.Value, dead.Value)
+	assert.Equal(t, "pokemon not found", header(dead, HeaderError))
+	assert.Equal(t, "ingestion", header(dead, HeaderOriginalTopic))
+	assert.Equal(t, "2", header(dead, HeaderOriginalPartition))
+	assert.Equal(t, "41", header(dead, HeaderOriginalOffset))
+	assert.Equal(t, "3", header(dead, HeaderAttempts))
+	assert.Equal(t, "00-abc-def-01", header(dead, "traceparent"))
+
+	replay := replayRecord(dead)
+	assert.Equal(t, "ingestion", replay.Topic)
+	assert.Equal(t, record.Value, replay.Value)
+	assert.Equal(t, record.Headers, replay.Headers)
+}
+
+func TestConsumer_DeadLettersPermanentErrorWithoutRetries(t *testing.T) {
+	calls := 0
+	c, src := newTestConsumer(func(ctx context.Context, payload []byte) error {
+		calls++
+		return fmt.Errorf("decode: %w", Permanent(errors.New("invalid character 'x'")))
+	}, nil)
+
+	require.NoError(t, c.handle(context.Background(), &kgo.Record{Topic: "ingestion", Value: []byte("x")}))
+	assert.Equal(t, 1, calls)
+	status := c.Status()
+	assert.Zero(t, status.Retried)
+	assert.EqualValues(t, 1, status.DeadLettered)
+
+	require.Len(t, src.produced, 1)
+	assert.Equal(t, "decode: invalid character 'x'", header(src.produced[0], HeaderError))
+	assert.Equal(t, "1", header(src.produced[0], HeaderAttempts))
+}
+
+func TestConsumer_DeadLetterPublishFails(t *testing.T) {
+	c, src := newTestConsumer(func(ctx context.Context, payload []byte) error {
+		return errors.New("boom")
+	}, errors.New("broker unavailable"))
+
+	err := c.handle(context.Background(), &kgo.Record{Topic: "ingestion"})
+	assert.ErrorContains(t, err, "broker unavailable")
+	assert.Len(t, src.produced, 1)
+}
+
+func TestConsumer_StopsRetryingOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c, src := newTestConsumer(func(ctx context.Context, payload []byte) error {
+		cancel()
+		return errors.New("boom")
+	}, nil)
+	c.retry.Backoff = time.Hour
+	c.retry.MaxBackoff = time.Hour
+
+	err := c.handle(ctx, &kgo.Record{Topic: "ingestion"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, src.produced, "records are not dead-lettered on shutdown")
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := RetryPolicy{Backoff: 500 * time.Millisecond, MaxBackoff: 3 * time.Second}
+	assert.Equal(t, 500*time.Millisecond, p.delay(1))
+	assert.Equal(t, time.Second, p.delay(2))
+	assert.Equal(t, 2*time.Second, p.delay(3))
+	assert.Equal(t, 3*time.Second, p.delay(4))
+	assert.Equal(t, 3*time.Second, p.delay(10))
+}
+
+func TestConsumer_ProcessKeepsPerKeyOrder(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[string][]string)
+	var running, peak atomic.Int32
+
+	c, src := newTestConsumer(func(ctx context.Context, payload []byte) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		var key, seq string
+		fmt.Sscanf(string(payload), "%s %s", &key, &seq)
+		mu.Lock()
+		seen[key] = append(seen[key], seq)
+		mu.Unlock()
+		return nil
+	}, nil)
+
+	var records []*kgo.Record
+	for seq := range 3 {
+		for _, key := range []string{"pokemon_1", "pokemon_2", "pokemon_3", "pokemon_4"} {
+			records = append(records, &kgo.Record{
+				Topic:  "ingestion",
+				Key:    []byte(key),
+				Value:  fmt.Appendf(nil, "%s %d", key, seq),
+				Offset: int64(len(records)),
+			})
+		}
+	}
+
+	rewind, err := c.process(context.Background(), records)
+	require.NoError(t, err)
+	assert.Empty(t, rewind)
+
+	for key, order := range seen {
+		assert.Equal(t, []string{"0", "1", "2"}, order, key)
+	}
+	assert.Greater(t, peak.Load(), int32(1), "independent keys run in parallel")
+	assert.LessOrEqual(t, peak.Load(), int32(c.concurrency))
+	require.Len(t, src.committed, 1)
+	assert.Equal(t, records[len(records)-1], src.committed[0])
+}
+
+func TestConsumer_ProcessCommitsOnlyHandledPrefix(t *testing.T) {
+	c, src := newTestConsumer(func(ctx context.Context, payload []byte) error {
+		if string(payload) == "fail" {
+			return errors.New("boom")
+		}
+		return nil
+	}, errors.New("broker unavailable"))
+
+	records := []*kgo.Record{
+		{Topic: "ingestion", Partition: 0, Offset: 10, Key: []byte("a"), Value: []byte("ok")},
+		{Topic: "ingestion", Partition: 0, Offset: 11, Key: []byte("b"), Value: []byte("fail")},
+		{Topic: "ingestion", Partition: 0, Offset: 12, Key: []byte("c"), Value: []byte("ok")},
+		{Topic: "ingestion", Partition: 1, Offset: 5, Key: []byte("d"), Value: []byte("ok")},
+		{Topic: "ingestion", Partition: 1, Offset: 6, Key: []byte("e"), Value: []byte("ok")},
+	}
+
+	rewind, err := c.process(context.Background(), records)
+	assert.ErrorContains(t, err, "broker unavailable")
+	assert.ElementsMatch(t, []*kgo.Record{records[0], records[4]}, src.committed,
+		"partition 0 stops before the record that could not be dead-lettered")
+	assert.Equal(t, []*kgo.Record{records[1]}, rewind, "partition 0 resumes at the failed record")
+}
+
+func TestKeyGroups(t *testing.T) {
+	records := []*kgo.Record{
+		{Topic: "ingestion", Key: []byte("pokemon_25")},
+		{Topic: "ingestion", Partition: 1},
+		{Topic: "ingestion", Key: []byte("move_1")},
+		{Topic: "ingestion", Key: []byte("pokemon_25")},
+		{Topic: "ingestion", Partition: 1},
+		{Topic: "ingestion", Partition: 2},
+	}
+
+	assert.Equal(t, [][]int{{0, 3}, {1, 4}, {2}, {5}}, keyGroups(records))
+}
+
+func TestConsumerStatus(t *testing.T) {
+	now := time.Date(2025, 12, 16, 12, 0, 0, 0, time.UTC)
+	tracker := newStatusTracker(5 * time.Minute)
+	tracker.now = func() time.Time { return now }
+
+	assert.True(t, tracker.snapshot().Healthy(), "a starting consumer is healthy")
+
+	tracker.polled()
+	tracker.succeeded()
+	status := tracker.snapshot()
+	assert.Equal(t, ConsumerRunning, status.State)
+	assert.True(t, status.Healthy())
+
+	tracker.failed(errors.New("broker down"))
+	now = now.Add(time.Minute)
+	tracker.failed(errors.New("broker down"))
+	status = tracker.snapshot()
+	assert.Equal(t, ConsumerRetrying, status.State)
+	assert.Equal(t, 2, status.ConsecutiveErrors)
+	assert.True(t, status.Healthy(), "a short error streak is not a stall")
+
+	now = now.Add(5 * time.Minute)
+	status = tracker.snapshot()
+	assert.True(t, status.Stalled)
+	assert.False(t, status.Healthy())
+
+	tracker.polled()
+	tracker.succeeded()
+	status = tracker.snapshot()
+	assert.False(t, status.Stalled)
+	assert.Zero(t, status.ConsecutiveErrors)
+	assert.Empty(t, status.LastError)
+
+	tracker.polled()
+	now = now.Add(6 * time.Minute)
+	assert.True(t, tracker.snapshot().Stalled, "a poll that never finishes is a stall")
+
+	tracker.stopped(errors.New("not authorized"))
+	status = tracker.snapshot()
+	assert.Equal(t, ConsumerFailed, status.State)
+	assert.False(t, status.Healthy())
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, isTransient(errors.New("dial tcp: connection refused")))
+	assert.True(t, isTransient(fmt.Errorf("fetch: %w", kerr.NotLeaderForPartition)))
+	assert.False(t, isTransient(kerr.TopicAuthorizationFailed))
+	assert.False(t, isTransient(kgo.ErrClientClosed))
+}
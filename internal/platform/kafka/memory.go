@@ -0,0 +1,238 @@
+package kafka
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"cyrene/internal/platform/config"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// ModeMemory selects the in-process MemoryBroker instead of a Kafka cluster.
+const ModeMemory = "memory"
+
+// memoryPollLimit caps the records returned by a single poll of the MemoryBroker.
+const memoryPollLimit = 500
+
+// memoryReplayGroup is the consumer group dead letters are replayed under.
+const memoryReplayGroup = "dlq-replay"
+
+// memoryReplayIdleTimeout is how long a replay waits for more dead letters.
+const memoryReplayIdleTimeout = 100 * time.Millisecond
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+// MemoryBroker is an in-process stand-in for a Kafka cluster, for local
+// development and tests. Topics are created on first use with a fixed number of
+// partitions; keyed records are hashed to a partition and keyless ones are spread
+// round-robin. Consumer groups commit offsets like Kafka does, but consumers of one
+// group share every partition instead of being assigned a subset, and a new
+// consumer resumes its group from the committed offsets.
+type MemoryBroker struct {
+	mu         sync.Mutex
+	partitions int
+	logs       map[string][][]*kgo.Record
+	committed  map[string]map[topicPartition]int64
+	positions  map[string]map[topicPartition]int64
+	roundRobin map[string]int
+	// appended is closed and replaced whenever records are published.
+	appended chan struct{}
+}
+
+// NewMemoryBroker creates an empty broker whose topics have the given number of partitions.
+func NewMemoryBroker(partitions int) *MemoryBroker {
+	return &MemoryBroker{
+		partitions: max(partitions, 1),
+		logs:       make(map[string][][]*kgo.Record),
+		committed:  make(map[string]map[topicPartition]int64),
+		positions:  make(map[string]map[topicPartition]int64),
+		roundRobin: make(map[string]int),
+		appended:   make(chan struct{}),
+	}
+}
+
+// Producer returns a producer that publishes to the broker.
+func (b *MemoryBroker) Producer() Producer {
+	return &memoryProducer{broker: b}
+}
+
+// NewConsumer creates a consumer of the handlers' topics in cfg.ConsumerGroup.
+func (b *MemoryBroker) NewConsumer(cfg *config.KafkaConfig, handlers map[string]Handler) Consumer {
+	topics := make([]string, 0, len(handlers))
+	for topic := range handlers {
+		topics = append(topics, topic)
+	}
+	return newConsumer(cfg, handlers, b.source(cfg.ConsumerGroup, topics))
+}
+
+// ReplayDeadLetters publishes the records of a dead-letter topic back to the topics
+// they failed on, like the package-level ReplayDeadLetters.
+func (b *MemoryBroker) ReplayDeadLetters(ctx context.Context, dlqTopic string) (int, error) {
+	return replay(ctx, b.source(memoryReplayGroup, []string{dlqTopic}), dlqTopic, memoryReplayIdleTimeout)
+}
+
+// Records returns every record published to topic, ordered by partition and offset.
+func (b *MemoryBroker) Records(topic string) []*kgo.Record {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var records []*kgo.Record
+	for _, log := range b.logs[topic] {
+		records = append(records, log...)
+	}
+	return records
+}
+
+// Committed returns the offset group resumes the partition from.
+func (b *MemoryBroker) Committed(group, topic string, partition int32) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.committed[group][topicPartition{topic, partition}]
+}
+
+func (b *MemoryBroker) publish(record *kgo.Record) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	log := b.topic(record.Topic)
+	var partition int
+	if record.Key != nil {
+		h := fnv.New32a()
+		h.Write(record.Key)
+		partition = int(h.Sum32() % uint32(len(log)))
+	} else {
+		partition = b.roundRobin[record.Topic] % len(log)
+		b.roundRobin[record.Topic]++
+	}
+
+	published := *record
+	published.Partition = int32(partition)
+	published.Offset = int64(len(log[partition]))
+	if published.Timestamp.IsZero() {
+		published.Timestamp = time.Now()
+	}
+	log[partition] = append(log[partition], &published)
+
+	close(b.appended)
+	b.appended = make(chan struct{})
+}
+
+// topic returns the partitions of topic, creating it if needed. b.mu must be held.
+func (b *MemoryBroker) topic(topic string) [][]*kgo.Record {
+	if _, ok := b.logs[topic]; !ok {
+		b.logs[topic] = make([][]*kgo.Record, b.partitions)
+	}
+	return b.logs[topic]
+}
+
+// source joins group, resetting its positions to the committed offsets.
+func (b *MemoryBroker) source(group string, topics []string) *memorySource {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.committed[group] == nil {
+		b.committed[group] = make(map[topicPartition]int64)
+	}
+	positions := make(map[topicPartition]int64, len(b.committed[group]))
+	for tp, offset := range b.committed[group] {
+		positions[tp] = offset
+	}
+	b.positions[group] = positions
+	return &memorySource{broker: b, group: group, topics: topics}
+}
+
+type memoryProducer struct {
+	broker *MemoryBroker
+}
+
+func (p *memoryProducer) Produce(ctx context.Context, topic string, key, value []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.broker.publish(&kgo.Record{Topic: topic, Key: key, Value: value})
+	return nil
+}
+
+func (p *memoryProducer) Close() {}
+
+// memorySource is a source reading topics of a MemoryBroker as a consumer group.
+type memorySource struct {
+	broker *MemoryBroker
+	group  string
+	topics []string
+}
+
+func (s *memorySource) poll(ctx context.Context) ([]*kgo.Record, error) {
+	for {
+		b := s.broker
+		b.mu.Lock()
+		positions := b.positions[s.group]
+		var records []*kgo.Record
+		for _, topic := range s.topics {
+			for partition, log := range b.topic(topic) {
+				tp := topicPartition{topic, int32(partition)}
+				offset := positions[tp]
+				end := min(int64(len(log)), offset+int64(memoryPollLimit-len(records)))
+				if offset >= end {
+					continue
+				}
+				records = append(records, log[offset:end]...)
+				positions[tp] = end
+			}
+		}
+		appended := b.appended
+		b.mu.Unlock()
+
+		if len(records) > 0 {
+			return records, nil
+		}
+		select {
+		case <-appended:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (s *memorySource) allowRebalance() {}
+
+func (s *memorySource) rewind(records []*kgo.Record) {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	positions := s.broker.positions[s.group]
+	for _, record := range records {
+		positions[topicPartition{record.Topic, record.Partition}] = record.Offset
+	}
+}
+
+func (s *memorySource) commit(ctx context.Context, records ...*kgo.Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	committed := s.broker.committed[s.group]
+	for _, record := range records {
+		tp := topicPartition{record.Topic, record.Partition}
+		committed[tp] = max(committed[tp], record.Offset+1)
+	}
+	return nil
+}
+
+func (s *memorySource) produce(ctx context.Context, record *kgo.Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.broker.publish(record)
+	return nil
+}
+
+func (s *memorySource) close() {}
@@ -0,0 +1,189 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"cyrene/internal/platform/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func testMemoryConfig(group string) *config.KafkaConfig {
+	return &config.KafkaConfig{
+		ConsumerGroup:     group,
+		Concurrency:       4,
+		MaxRetries:        1,
+		RetryBackoffMS:    1,
+		RetryMaxBackoffMS: 1,
+		StallTimeoutSec:   60,
+	}
+}
+
+// runUntil runs c until cond holds or the test times out.
+func runUntil(t *testing.T, c Consumer, cond func() bool) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+
+	assert.Eventually(t, cond, 5*time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+	require.NoError(t, c.Close(context.Background()))
+}
+
+func TestMemoryBroker_Partitioning(t *testing.T) {
+	broker := NewMemoryBroker(3)
+	producer := broker.Producer()
+	ctx := context.Background()
+
+	require.NoError(t, producer.Produce(ctx, "ingestion", []byte("pokemon_25"), []byte("a")))
+	require.NoError(t, producer.Produce(ctx, "ingestion", []byte("pokemon_25"), []byte("b")))
+	require.NoError(t, producer.Produce(ctx, "ingestion", nil, []byte("c")))
+	require.NoError(t, producer.Produce(ctx, "ingestion", nil, []byte("d")))
+
+	records := broker.Records("ingestion")
+	require.Len(t, records, 4)
+
+	byValue := make(map[string]*kgo.Record)
+	for _, r := range records {
+		byValue[string(r.Value)] = r
+	}
+	assert.Equal(t, byValue["a"].Partition, byValue["b"].Partition, "one key stays on one partition")
+	assert.Equal(t, byValue["a"].Offset+1, byValue["b"].Offset)
+	assert.NotEqual(t, byValue["c"].Partition, byValue["d"].Partition, "keyless records are spread")
+	assert.Empty(t, broker.Records("unknown"))
+}
+
+func TestMemoryBroker_ConsumerGroups(t *testing.T) {
+	broker := NewMemoryBroker(2)
+	producer := broker.Producer()
+	for _, key := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, producer.Produce(context.Background(), "ingestion", []byte(key), []byte(key)))
+	}
+
+	consume := func(group string) []string {
+		var mu sync.Mutex
+		var seen []string
+		c := broker.NewConsumer(testMemoryConfig(group), map[string]Handler{
+			"ingestion": func(ctx context.Context, payload []byte) error {
+				mu.Lock()
+				defer mu.Unlock()
+				seen = append(seen, string(payload))
+				return nil
+			},
+		})
+		runUntil(t, c, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(seen) == 4
+		})
+		return seen
+	}
+
+	assert.ElementsMatch(t, []string{"a", "b", "c", "d"}, consume("indexer"))
+	assert.ElementsMatch(t, []string{"a", "b", "c", "d"}, consume("auditor"), "every group reads the whole topic")
+
+	total := broker.Committed("indexer", "ingestion", 0) + broker.Committed("indexer", "ingestion", 1)
+	assert.EqualValues(t, 4, total)
+
+	// A new consumer of a group resumes from its committed offsets.
+	require.NoError(t, producer.Produce(context.Background(), "ingestion", []byte("e"), []byte("e")))
+	var seen []string
+	var mu sync.Mutex
+	c := broker.NewConsumer(testMemoryConfig("indexer"), map[string]Handler{
+		"ingestion": func(ctx context.Context, payload []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			seen = append(seen, string(payload))
+			return nil
+		},
+	})
+	runUntil(t, c, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(seen) == 1
+	})
+	assert.Equal(t, []string{"e"}, seen)
+}
+
+func TestMemoryBroker_DeadLetterAndReplay(t *testing.T) {
+	broker := NewMemoryBroker(1)
+	require.NoError(t, broker.Producer().Produce(context.Background(), "ingestion", []byte("pokemon_99999"), []byte("bad")))
+
+	var mu sync.Mutex
+	fixed := false
+	var handled []string
+	c := broker.NewConsumer(testMemoryConfig("indexer"), map[string]Handler{
+		"ingestion": func(ctx context.Context, payload []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if !fixed {
+				return errors.New("pokemon not found")
+			}
+			handled = append(handled, string(payload))
+			return nil
+		},
+	})
+	runUntil(t, c, func() bool { return len(broker.Records("ingestion.dlq")) == 1 })
+
+	dead := broker.Records("ingestion.dlq")[0]
+	assert.Equal(t, "pokemon not found", header(dead, HeaderError))
+	assert.Equal(t, "2", header(dead, HeaderAttempts))
+	assert.EqualValues(t, 1, broker.Committed("indexer", "ingestion", 0), "dead-lettered records are committed")
+
+	mu.Lock()
+	fixed = true
+	mu.Unlock()
+
+	replayed, err := broker.ReplayDeadLetters(context.Background(), "ingestion.dlq")
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+
+	replayed, err = broker.ReplayDeadLetters(context.Background(), "ingestion.dlq")
+	require.NoError(t, err)
+	assert.Zero(t, replayed, "dead letters are replayed once")
+
+	c = broker.NewConsumer(testMemoryConfig("indexer"), c.(*consumer).handlers)
+	runUntil(t, c, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(handled) == 1
+	})
+	assert.Equal(t, []string{"bad"}, handled)
+	assert.Empty(t, broker.Records("ingestion")[1].Headers, "failure headers are stripped on replay")
+}
+
+func TestMemoryBroker_RewindRedelivers(t *testing.T) {
+	broker := NewMemoryBroker(1)
+	require.NoError(t, broker.Producer().Produce(context.Background(), "ingestion", []byte("a"), []byte("a")))
+
+	var mu sync.Mutex
+	attempts := 0
+	c := broker.NewConsumer(testMemoryConfig("indexer"), map[string]Handler{
+		"ingestion": func(ctx context.Context, payload []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts++
+			return nil
+		},
+	}).(*consumer)
+	src := c.source.(*memorySource)
+	records, err := src.poll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	// The record was polled but never committed, so it is polled again after a rewind.
+	src.rewind(records)
+	runUntil(t, c, func() bool { return broker.Committed("indexer", "ingestion", 0) == 1 })
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, attempts)
+}
@@ -0,0 +1,174 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+type ConsumerState string
+
+const (
+	ConsumerStarting ConsumerState = "starting"
+	ConsumerRunning  ConsumerState = "running"
+	// ConsumerRetrying means polling or processing failed and the consumer is backing off.
+	ConsumerRetrying ConsumerState = "retrying"
+	ConsumerStopped  ConsumerState = "stopped"
+	// ConsumerFailed means the consumer hit an error it cannot recover from and has exited.
+	ConsumerFailed ConsumerState = "failed"
+)
+
+// ConsumerStatus is a snapshot of the consumer's progress for health checks and metrics.
+type ConsumerStatus struct {
+	State ConsumerState `json:"state"`
+	// Stalled is set when the consumer has been retrying, or busy with a single
+	// poll, for longer than the stall timeout.
+	Stalled           bool      `json:"stalled"`
+	ConsecutiveErrors int       `json:"consecutive_errors"`
+	LastError         string    `json:"last_error,omitempty"`
+	LastPollAt        time.Time `json:"last_poll_at,omitzero"`
+	BusySince         time.Time `json:"busy_since,omitzero"`
+	Handled           int64     `json:"handled"`
+	Retried           int64     `json:"retried"`
+	DeadLettered      int64     `json:"dead_lettered"`
+}
+
+// Healthy reports whether the consumer is up and making progress.
+func (s ConsumerStatus) Healthy() bool {
+	switch s.State {
+	case ConsumerStarting, ConsumerRunning, ConsumerRetrying:
+		return !s.Stalled
+	default:
+		return false
+	}
+}
+
+// statusTracker records the consumer's state; it is safe for concurrent use.
+type statusTracker struct {
+	mu         sync.Mutex
+	status     ConsumerStatus
+	errorSince time.Time
+	stallAfter time.Duration
+	now        func() time.Time
+}
+
+func newStatusTracker(stallAfter time.Duration) *statusTracker {
+	return &statusTracker{
+		status:     ConsumerStatus{State: ConsumerStarting},
+		stallAfter: stallAfter,
+		now:        time.Now,
+	}
+}
+
+func (t *statusTracker) snapshot() ConsumerStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := t.status
+	now := t.now()
+	if t.stallAfter > 0 {
+		retryingTooLong := !t.errorSince.IsZero() && now.Sub(t.errorSince) > t.stallAfter
+		busyTooLong := !s.BusySince.IsZero() && now.Sub(s.BusySince) > t.stallAfter
+		s.Stalled = retryingTooLong || busyTooLong
+	}
+	return s
+}
+
+func (t *statusTracker) update(fn func(s *ConsumerStatus)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fn(&t.status)
+}
+
+// polled marks the start of processing a poll.
+func (t *statusTracker) polled() {
+	t.update(func(s *ConsumerStatus) {
+		now := t.now()
+		s.LastPollAt = now
+		s.BusySince = now
+	})
+}
+
+// succeeded marks a poll as fully processed and clears any error streak.
+func (t *statusTracker) succeeded() {
+	t.update(func(s *ConsumerStatus) {
+		s.State = ConsumerRunning
+		s.BusySince = time.Time{}
+		s.ConsecutiveErrors = 0
+		s.LastError = ""
+		t.errorSince = time.Time{}
+	})
+}
+
+// failed records a recoverable error; the consumer is about to back off.
+func (t *statusTracker) failed(err error) {
+	t.update(func(s *ConsumerStatus) {
+		s.State = ConsumerRetrying
+		s.BusySince = time.Time{}
+		s.ConsecutiveErrors++
+		s.LastError = err.Error()
+		if t.errorSince.IsZero() {
+			t.errorSince = t.now()
+		}
+	})
+}
+
+// stopped records that Run returned, with the fatal error if there was one.
+func (t *statusTracker) stopped(err error) {
+	t.update(func(s *ConsumerStatus) {
+		s.State = ConsumerStopped
+		s.BusySince = time.Time{}
+		if err != nil {
+			s.State = ConsumerFailed
+			s.LastError = err.Error()
+		}
+	})
+}
+
+// pollError is a failed poll, transient when every underlying error is worth retrying.
+type pollError struct {
+	err       error
+	transient bool
+}
+
+func (e *pollError) Error() string { return e.err.Error() }
+
+func (e *pollError) Unwrap() error { return e.err }
+
+// fetchError joins the errors of a poll into a pollError, or returns nil.
+func fetchError(fetches kgo.Fetches) error {
+	transient := true
+	var errs []error
+	fetches.EachError(func(topic string, partition int32, e error) {
+		errs = append(errs, fmt.Errorf("%s/%d: %w", topic, partition, e))
+		if !isTransient(e) {
+			transient = false
+		}
+	})
+	if len(errs) == 0 {
+		return nil
+	}
+	return &pollError{err: errors.Join(errs...), transient: transient}
+}
+
+// isTransient reports whether an error is worth retrying. Broker errors are
+// retried when Kafka marks them retriable; anything else, such as a dropped
+// connection, is assumed to be transient unless the client has been closed.
+func isTransient(err error) bool {
+	var pollErr *pollError
+	if errors.As(err, &pollErr) {
+		return pollErr.transient
+	}
+	if errors.Is(err, kgo.ErrClientClosed) {
+		return false
+	}
+	var kafkaErr *kerr.Error
+	if errors.As(err, &kafkaErr) {
+		return kafkaErr.Retriable
+	}
+	return true
+}
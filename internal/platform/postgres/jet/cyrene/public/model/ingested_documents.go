@@ -13,9 +13,13 @@ import (
 )
 
 type IngestedDocuments struct {
-	ID           uuid.UUID `sql:"primary_key"`
-	DocumentType string
-	ExternalID   string
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ID             uuid.UUID `sql:"primary_key"`
+	DocumentType   string
+	ExternalID     string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	ContentHash    string
+	EmbeddingModel string
+	EmbeddingDim   int32
+	Content        string
 }
@@ -17,11 +17,15 @@ type ingestedDocumentsTable struct {
 	postgres.Table
 
 	// Columns
-	ID           postgres.ColumnString
-	DocumentType postgres.ColumnString
-	ExternalID   postgres.ColumnString
-	CreatedAt    postgres.ColumnTimestampz
-	UpdatedAt    postgres.ColumnTimestampz
+	ID             postgres.ColumnString
+	DocumentType   postgres.ColumnString
+	ExternalID     postgres.ColumnString
+	CreatedAt      postgres.ColumnTimestampz
+	UpdatedAt      postgres.ColumnTimestampz
+	ContentHash    postgres.ColumnString
+	EmbeddingModel postgres.ColumnString
+	EmbeddingDim   postgres.ColumnInteger
+	Content        postgres.ColumnString
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -63,25 +67,33 @@ func newIngestedDocumentsTable(schemaName, tableName, alias string) *IngestedDoc
 
 func newIngestedDocumentsTableImpl(schemaName, tableName, alias string) ingestedDocumentsTable {
 	var (
-		IDColumn           = postgres.StringColumn("id")
-		DocumentTypeColumn = postgres.StringColumn("document_type")
-		ExternalIDColumn   = postgres.StringColumn("external_id")
-		CreatedAtColumn    = postgres.TimestampzColumn("created_at")
-		UpdatedAtColumn    = postgres.TimestampzColumn("updated_at")
-		allColumns         = postgres.ColumnList{IDColumn, DocumentTypeColumn, ExternalIDColumn, CreatedAtColumn, UpdatedAtColumn}
-		mutableColumns     = postgres.ColumnList{DocumentTypeColumn, ExternalIDColumn, CreatedAtColumn, UpdatedAtColumn}
-		defaultColumns     = postgres.ColumnList{CreatedAtColumn, UpdatedAtColumn}
+		IDColumn             = postgres.StringColumn("id")
+		DocumentTypeColumn   = postgres.StringColumn("document_type")
+		ExternalIDColumn     = postgres.StringColumn("external_id")
+		CreatedAtColumn      = postgres.TimestampzColumn("created_at")
+		UpdatedAtColumn      = postgres.TimestampzColumn("updated_at")
+		ContentHashColumn    = postgres.StringColumn("content_hash")
+		EmbeddingModelColumn = postgres.StringColumn("embedding_model")
+		EmbeddingDimColumn   = postgres.IntegerColumn("embedding_dim")
+		ContentColumn        = postgres.StringColumn("content")
+		allColumns           = postgres.ColumnList{IDColumn, DocumentTypeColumn, ExternalIDColumn, CreatedAtColumn, UpdatedAtColumn, ContentHashColumn, EmbeddingModelColumn, EmbeddingDimColumn, ContentColumn}
+		mutableColumns       = postgres.ColumnList{DocumentTypeColumn, ExternalIDColumn, CreatedAtColumn, UpdatedAtColumn, ContentHashColumn, EmbeddingModelColumn, EmbeddingDimColumn, ContentColumn}
+		defaultColumns       = postgres.ColumnList{CreatedAtColumn, UpdatedAtColumn, ContentHashColumn, EmbeddingModelColumn, EmbeddingDimColumn, ContentColumn}
 	)
 
 	return ingestedDocumentsTable{
 		Table: postgres.NewTable(schemaName, tableName, alias, allColumns...),
 
 		//Columns
-		ID:           IDColumn,
-		DocumentType: DocumentTypeColumn,
-		ExternalID:   ExternalIDColumn,
-		CreatedAt:    CreatedAtColumn,
-		UpdatedAt:    UpdatedAtColumn,
+		ID:             IDColumn,
+		DocumentType:   DocumentTypeColumn,
+		ExternalID:     ExternalIDColumn,
+		CreatedAt:      CreatedAtColumn,
+		UpdatedAt:      UpdatedAtColumn,
+		ContentHash:    ContentHashColumn,
+		EmbeddingModel: EmbeddingModelColumn,
+		EmbeddingDim:   EmbeddingDimColumn,
+		Content:        ContentColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
@@ -10,6 +10,9 @@ package table
 // UseSchema sets a new schema name for all generated table SQL builder types. It is recommended to invoke
 // this method only once at the beginning of the program.
 func UseSchema(schema string) {
+	ChatTurns = ChatTurns.FromSchema(schema)
 	GooseDbVersion = GooseDbVersion.FromSchema(schema)
 	IngestedDocuments = IngestedDocuments.FromSchema(schema)
+	IngestionJobs = IngestionJobs.FromSchema(schema)
+	VectorOutbox = VectorOutbox.FromSchema(schema)
 }
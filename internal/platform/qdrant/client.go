@@ -3,6 +3,9 @@ package qdrant
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"cyrene/internal/platform/config"
 
@@ -57,3 +60,134 @@ func (c *Client) EnsureCollection(ctx context.Context, name string, vectorSize u
 	}
 	return nil
 }
+
+// EnsureAlias makes sure alias resolves to a collection. When neither an alias nor a
+// collection with that name exists, a first versioned collection is created behind it.
+// A plain collection named alias is left in place so existing deployments keep working.
+func (c *Client) EnsureAlias(ctx context.Context, alias string, vectorSize uint64) error {
+	target, err := c.AliasTarget(ctx, alias)
+	if err != nil {
+		return err
+	}
+	if target != "" {
+		return nil
+	}
+
+	exists, err := c.conn.CollectionExists(ctx, alias)
+	if err != nil {
+		return fmt.Errorf("check collection exists: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	name, err := c.CreateVersionedCollection(ctx, alias, vectorSize)
+	if err != nil {
+		return err
+	}
+	if err := c.conn.CreateAlias(ctx, alias, name); err != nil {
+		return fmt.Errorf("create alias: %w", err)
+	}
+	return nil
+}
+
+// versionLayout formats the creation time that suffixes a versioned collection
+// name; without its dot it is versionLen digits long.
+const (
+	versionLayout = "20060102150405.000"
+	versionLen    = 17
+)
+
+// CreateVersionedCollection creates a new collection named after alias and the
+// current time in milliseconds, e.g. "pokemon_20251215031500123", and returns its name.
+func (c *Client) CreateVersionedCollection(ctx context.Context, alias string, vectorSize uint64) (string, error) {
+	version := strings.Replace(time.Now().UTC().Format(versionLayout), ".", "", 1)
+	name := fmt.Sprintf("%s_%s", alias, version)
+
+	err := c.conn.CreateCollection(ctx, &qdrant.CreateCollection{
+		CollectionName: name,
+		VectorsConfig: qdrant.NewVectorsConfig(&qdrant.VectorParams{
+			Size:     vectorSize,
+			Distance: qdrant.Distance_Cosine,
+		}),
+	})
+	if err != nil {
+		return "", fmt.Errorf("create collection %s: %w", name, err)
+	}
+	return name, nil
+}
+
+// AliasTarget returns the collection alias points to, or "" if there is no such alias.
+func (c *Client) AliasTarget(ctx context.Context, alias string) (string, error) {
+	aliases, err := c.conn.ListAliases(ctx)
+	if err != nil {
+		return "", fmt.Errorf("list aliases: %w", err)
+	}
+	for _, a := range aliases {
+		if a.GetAliasName() == alias {
+			return a.GetCollectionName(), nil
+		}
+	}
+	return "", nil
+}
+
+// SwapAlias atomically points alias at collection. The previously targeted
+// collection is kept so the swap can be rolled back.
+func (c *Client) SwapAlias(ctx context.Context, alias, collection string) error {
+	target, err := c.AliasTarget(ctx, alias)
+	if err != nil {
+		return err
+	}
+	if target == "" {
+		exists, err := c.conn.CollectionExists(ctx, alias)
+		if err != nil {
+			return fmt.Errorf("check collection exists: %w", err)
+		}
+		if exists {
+			return fmt.Errorf("%s is a collection, not an alias; delete it before swapping to %s", alias, collection)
+		}
+	}
+
+	var actions []*qdrant.AliasOperations
+	if target != "" {
+		actions = append(actions, qdrant.NewAliasDelete(alias))
+	}
+	actions = append(actions, qdrant.NewAliasCreate(alias, collection))
+
+	if err := c.conn.UpdateAliases(ctx, actions); err != nil {
+		return fmt.Errorf("swap alias: %w", err)
+	}
+	return nil
+}
+
+// Versions returns the versioned collections created for alias, oldest first.
+func (c *Client) Versions(ctx context.Context, alias string) ([]string, error) {
+	collections, err := c.conn.ListCollections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list collections: %w", err)
+	}
+
+	var versions []string
+	for _, name := range collections {
+		if isVersion(alias, name) {
+			versions = append(versions, name)
+		}
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
+
+// isVersion reports whether name is a collection CreateVersionedCollection made for
+// alias, as opposed to another collection sharing its prefix like "pokemon_test".
+func isVersion(alias, name string) bool {
+	version, ok := strings.CutPrefix(name, alias+"_")
+	if !ok || len(version) != versionLen {
+		return false
+	}
+	for _, r := range version {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,17 @@
+package qdrant
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsVersion(t *testing.T) {
+	assert.True(t, isVersion("cobblemon", "cobblemon_20251215031500123"))
+
+	assert.False(t, isVersion("cobblemon", "cobblemon"))
+	assert.False(t, isVersion("cobblemon", "cobblemon_test"))
+	assert.False(t, isVersion("cobblemon", "cobblemon_2025121503150012"))
+	assert.False(t, isVersion("cobblemon", "cobblemon_20251215031500123_old"))
+	assert.False(t, isVersion("cobblemon", "cache_20251215031500123"))
+}
@@ -30,3 +30,11 @@ func CORSMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// HandleFunc registers handler for pattern both without and with a trailing slash.
+// Feature routes are mounted under http.StripPrefix, so the redirect the mux would
+// otherwise send from one form to the other drops the prefix.
+func HandleFunc(mux *http.ServeMux, pattern string, handler http.HandlerFunc) {
+	mux.HandleFunc(pattern, handler)
+	mux.HandleFunc(pattern+"/{$}", handler)
+}
@@ -102,6 +102,81 @@ func (s *QdrantStore) DeleteByID(ctx context.Context, ids ...string) error {
 	return err
 }
 
+// Count returns the exact number of points in the collection.
+func (s *QdrantStore) Count(ctx context.Context) (int, error) {
+	count, err := s.client.Count(ctx, &qdrant.CountPoints{
+		CollectionName: s.collection,
+		Exact:          qdrant.PtrOf(true),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// scrollPageSize is the number of points fetched per scroll request.
+const scrollPageSize = 256
+
+// PointsByPayload scrolls the whole collection and groups point IDs by the string
+// value of a payload field. Points without the field are grouped under "".
+func (s *QdrantStore) PointsByPayload(ctx context.Context, field string) (map[string][]string, error) {
+	groups := make(map[string][]string)
+
+	var offset *qdrant.PointId
+	for {
+		points, next, err := s.client.ScrollAndOffset(ctx, &qdrant.ScrollPoints{
+			CollectionName: s.collection,
+			Offset:         offset,
+			Limit:          qdrant.PtrOf(uint32(scrollPageSize)),
+			WithPayload:    qdrant.NewWithPayloadInclude(field),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range points {
+			value := p.Payload[field].GetStringValue()
+			groups[value] = append(groups[value], p.Id.GetUuid())
+		}
+
+		if next == nil {
+			return groups, nil
+		}
+		offset = next
+	}
+}
+
+// Scroll returns every point matching the filter with its payload but without its vector.
+func (s *QdrantStore) Scroll(ctx context.Context, filter Filter) ([]Point, error) {
+	var result []Point
+
+	var offset *qdrant.PointId
+	for {
+		points, next, err := s.client.ScrollAndOffset(ctx, &qdrant.ScrollPoints{
+			CollectionName: s.collection,
+			Filter:         buildFilter(filter),
+			Offset:         offset,
+			Limit:          qdrant.PtrOf(uint32(scrollPageSize)),
+			WithPayload:    qdrant.NewWithPayload(true),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range points {
+			result = append(result, Point{
+				ID:      p.Id.GetUuid(),
+				Payload: extractPayload(p.Payload),
+			})
+		}
+
+		if next == nil {
+			return result, nil
+		}
+		offset = next
+	}
+}
+
 func buildFilter(filter Filter) *qdrant.Filter {
 	var should []*qdrant.Condition
 	var must []*qdrant.Condition
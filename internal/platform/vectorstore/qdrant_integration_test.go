@@ -58,7 +58,7 @@ func TestMain(m *testing.M) {
 		os.Exit(1)
 	}
 
-	testStore = NewQdrantStore(testClient, cfg)
+	testStore = NewQdrantStore(testClient, testCollection, int(testDimension))
 
 	code := m.Run()
 
@@ -339,3 +339,90 @@ func TestQdrantStore_DeleteByID(t *testing.T) {
 	require.Len(t, results, 1)
 	assert.Equal(t, point2ID, results[0].ID)
 }
+
+func TestQdrantStore_AliasSwap(t *testing.T) {
+	ctx := context.Background()
+	alias := "test_alias_" + uuid.NewString()[:8]
+
+	require.NoError(t, testClient.EnsureAlias(ctx, alias, testDimension))
+	first, err := testClient.AliasTarget(ctx, alias)
+	require.NoError(t, err)
+	require.NotEmpty(t, first)
+	defer testClient.Conn().DeleteCollection(ctx, first)
+
+	store := NewQdrantStore(testClient, alias, int(testDimension))
+	require.NoError(t, store.Upsert(ctx, Point{
+		ID:      uuid.NewString(),
+		Vector:  []float32{1.0, 0.0, 0.0, 0.0},
+		Payload: map[string]any{"reference": "test-alias"},
+	}))
+
+	count, err := store.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	second, err := testClient.CreateVersionedCollection(ctx, alias, testDimension)
+	require.NoError(t, err)
+	defer testClient.Conn().DeleteCollection(ctx, second)
+
+	require.NoError(t, testClient.SwapAlias(ctx, alias, second))
+	defer testClient.Conn().DeleteAlias(ctx, alias)
+
+	count, err = store.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count, "alias should resolve to the new, empty collection")
+
+	versions, err := testClient.Versions(ctx, alias)
+	require.NoError(t, err)
+	assert.Equal(t, []string{first, second}, versions)
+
+	require.NoError(t, testClient.SwapAlias(ctx, alias, first))
+	count, err = store.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "rollback should restore the original collection")
+}
+
+func TestQdrantStore_PointsByPayload(t *testing.T) {
+	reference := "test-ref-scroll-" + uuid.NewString()[:8]
+	cleanupTestPoints(t, reference)
+	defer cleanupTestPoints(t, reference)
+
+	ctx := context.Background()
+
+	ids := []string{uuid.NewString(), uuid.NewString()}
+	for _, id := range ids {
+		require.NoError(t, testStore.Upsert(ctx, Point{
+			ID:      id,
+			Vector:  []float32{0.0, 1.0, 0.0, 0.0},
+			Payload: map[string]any{"reference": reference},
+		}))
+	}
+
+	groups, err := testStore.PointsByPayload(ctx, "reference")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, ids, groups[reference])
+}
+
+func TestQdrantStore_Scroll(t *testing.T) {
+	reference := "test-ref-filter-" + uuid.NewString()[:8]
+	cleanupTestPoints(t, reference)
+	defer cleanupTestPoints(t, reference)
+
+	ctx := context.Background()
+
+	id := uuid.NewString()
+	require.NoError(t, testStore.Upsert(ctx, Point{
+		ID:      id,
+		Vector:  []float32{0.0, 0.0, 1.0, 0.0},
+		Payload: map[string]any{"reference": reference, "content": "Pokemon: pikachu"},
+	}))
+
+	points, err := testStore.Scroll(ctx, Filter{
+		StringFilters: []StringFilter{{Field: "reference", Value: reference, Op: FilterAND}},
+	})
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+	assert.Equal(t, id, points[0].ID)
+	assert.Equal(t, "Pokemon: pikachu", points[0].Payload["content"])
+	assert.Empty(t, points[0].Vector)
+}
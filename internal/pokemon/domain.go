@@ -11,6 +11,7 @@ type Pokemon struct {
 	Identifier string
 	RawJSON    string
 	Metadata   map[string]any
+	Species    *Species
 }
 
 func (p *Pokemon) EmbeddingText() string {
@@ -91,10 +92,507 @@ func (p *Pokemon) EmbeddingText() string {
 		sb.WriteString(fmt.Sprintf("Weight: %.1f\n", weight))
 	}
 
+	if p.Species != nil {
+		sb.WriteString(p.Species.EmbeddingText())
+	}
+
 	return sb.String()
 }
 
+// SpeciesName returns the name of the species this Pokemon belongs to.
+// Alternate forms such as mega evolutions share the species of their base form.
+func (p *Pokemon) SpeciesName() string {
+	if name := resourceName(p.Metadata["species"]); name != "" {
+		return name
+	}
+	return p.Identifier
+}
+
 func (p *Pokemon) MetadataJSON() string {
 	b, _ := json.Marshal(p.Metadata)
 	return string(b)
 }
+
+type Move struct {
+	ID         string
+	Identifier string
+	RawJSON    string
+	Metadata   map[string]any
+}
+
+func (m *Move) EmbeddingText() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Move: %s (ID: %s)\n", m.Identifier, m.ID))
+
+	if name := resourceName(m.Metadata["type"]); name != "" {
+		sb.WriteString(fmt.Sprintf("Type: %s\n", name))
+	}
+	if name := resourceName(m.Metadata["damage_class"]); name != "" {
+		sb.WriteString(fmt.Sprintf("Damage Class: %s\n", name))
+	}
+	if power, ok := m.Metadata["power"].(float64); ok {
+		sb.WriteString(fmt.Sprintf("Power: %.0f\n", power))
+	}
+	if accuracy, ok := m.Metadata["accuracy"].(float64); ok {
+		sb.WriteString(fmt.Sprintf("Accuracy: %.0f\n", accuracy))
+	}
+	if pp, ok := m.Metadata["pp"].(float64); ok {
+		sb.WriteString(fmt.Sprintf("PP: %.0f\n", pp))
+	}
+	if priority, ok := m.Metadata["priority"].(float64); ok && priority != 0 {
+		sb.WriteString(fmt.Sprintf("Priority: %.0f\n", priority))
+	}
+
+	if effect := englishText(m.Metadata["effect_entries"], "short_effect"); effect != "" {
+		if chance, ok := m.Metadata["effect_chance"].(float64); ok {
+			effect = strings.ReplaceAll(effect, "$effect_chance", fmt.Sprintf("%.0f", chance))
+		}
+		sb.WriteString(fmt.Sprintf("Effect: %s\n", effect))
+	}
+
+	if learners := resourceNames(m.Metadata["learned_by_pokemon"]); len(learners) > 0 {
+		sb.WriteString(fmt.Sprintf("Learned By: %s\n", strings.Join(learners, ", ")))
+	}
+
+	return sb.String()
+}
+
+func (m *Move) MetadataJSON() string {
+	b, _ := json.Marshal(m.Metadata)
+	return string(b)
+}
+
+type Ability struct {
+	ID         string
+	Identifier string
+	RawJSON    string
+	Metadata   map[string]any
+}
+
+func (a *Ability) EmbeddingText() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Ability: %s (ID: %s)\n", a.Identifier, a.ID))
+
+	if generation := resourceName(a.Metadata["generation"]); generation != "" {
+		sb.WriteString(fmt.Sprintf("Generation: %s\n", generation))
+	}
+	if effect := englishText(a.Metadata["effect_entries"], "effect"); effect != "" {
+		sb.WriteString(fmt.Sprintf("Effect: %s\n", effect))
+	}
+	if flavor := englishText(a.Metadata["flavor_text_entries"], "flavor_text"); flavor != "" {
+		sb.WriteString(fmt.Sprintf("Description: %s\n", flavor))
+	}
+
+	if entries, ok := a.Metadata["pokemon"].([]any); ok {
+		var regular, hidden []string
+		for _, e := range entries {
+			em, ok := e.(map[string]any)
+			if !ok {
+				continue
+			}
+			name := resourceName(em["pokemon"])
+			if name == "" {
+				continue
+			}
+			if isHidden, _ := em["is_hidden"].(bool); isHidden {
+				hidden = append(hidden, name)
+			} else {
+				regular = append(regular, name)
+			}
+		}
+		if len(regular) > 0 {
+			sb.WriteString(fmt.Sprintf("Pokemon: %s\n", strings.Join(regular, ", ")))
+		}
+		if len(hidden) > 0 {
+			sb.WriteString(fmt.Sprintf("Hidden Ability Of: %s\n", strings.Join(hidden, ", ")))
+		}
+	}
+
+	return sb.String()
+}
+
+func (a *Ability) MetadataJSON() string {
+	b, _ := json.Marshal(a.Metadata)
+	return string(b)
+}
+
+type Item struct {
+	ID         string
+	Identifier string
+	RawJSON    string
+	Metadata   map[string]any
+}
+
+func (i *Item) EmbeddingText() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Item: %s (ID: %s)\n", i.Identifier, i.ID))
+
+	if category := resourceName(i.Metadata["category"]); category != "" {
+		sb.WriteString(fmt.Sprintf("Category: %s\n", category))
+	}
+	if cost, ok := i.Metadata["cost"].(float64); ok && cost > 0 {
+		sb.WriteString(fmt.Sprintf("Cost: %.0f\n", cost))
+	}
+	if attributes := resourceNames(i.Metadata["attributes"]); len(attributes) > 0 {
+		sb.WriteString(fmt.Sprintf("Attributes: %s\n", strings.Join(attributes, ", ")))
+	}
+	if effect := englishText(i.Metadata["effect_entries"], "effect"); effect != "" {
+		sb.WriteString(fmt.Sprintf("Effect: %s\n", effect))
+	}
+	if flavor := englishText(i.Metadata["flavor_text_entries"], "text"); flavor != "" {
+		sb.WriteString(fmt.Sprintf("Description: %s\n", flavor))
+	}
+	if holders := nestedResourceNames(i.Metadata["held_by_pokemon"], "pokemon"); len(holders) > 0 {
+		sb.WriteString(fmt.Sprintf("Held By: %s\n", strings.Join(holders, ", ")))
+	}
+
+	return sb.String()
+}
+
+func (i *Item) MetadataJSON() string {
+	b, _ := json.Marshal(i.Metadata)
+	return string(b)
+}
+
+type Type struct {
+	ID         string
+	Identifier string
+	RawJSON    string
+	Metadata   map[string]any
+}
+
+func (t *Type) EmbeddingText() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Type: %s (ID: %s)\n", t.Identifier, t.ID))
+
+	if relations, ok := t.Metadata["damage_relations"].(map[string]any); ok {
+		for _, r := range []struct {
+			key   string
+			label string
+		}{
+			{"double_damage_to", "Super Effective Against"},
+			{"half_damage_to", "Not Very Effective Against"},
+			{"no_damage_to", "No Effect Against"},
+			{"double_damage_from", "Weak To"},
+			{"half_damage_from", "Resists"},
+			{"no_damage_from", "Immune To"},
+		} {
+			if names := resourceNames(relations[r.key]); len(names) > 0 {
+				sb.WriteString(fmt.Sprintf("%s: %s\n", r.label, strings.Join(names, ", ")))
+			}
+		}
+	}
+
+	if pokemon := nestedResourceNames(t.Metadata["pokemon"], "pokemon"); len(pokemon) > 0 {
+		sb.WriteString(fmt.Sprintf("Pokemon: %s\n", strings.Join(pokemon, ", ")))
+	}
+	if moves := resourceNames(t.Metadata["moves"]); len(moves) > 0 {
+		sb.WriteString(fmt.Sprintf("Moves: %s\n", strings.Join(moves, ", ")))
+	}
+
+	return sb.String()
+}
+
+func (t *Type) MetadataJSON() string {
+	b, _ := json.Marshal(t.Metadata)
+	return string(b)
+}
+
+type Species struct {
+	ID         string
+	Identifier string
+	RawJSON    string
+	Metadata   map[string]any
+}
+
+func (s *Species) EmbeddingText() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Species: %s\n", s.Identifier))
+
+	if genus := englishText(s.Metadata["genera"], "genus"); genus != "" {
+		sb.WriteString(fmt.Sprintf("Genus: %s\n", genus))
+	}
+	if generation := resourceName(s.Metadata["generation"]); generation != "" {
+		sb.WriteString(fmt.Sprintf("Generation: %s\n", generation))
+	}
+
+	var status []string
+	if legendary, _ := s.Metadata["is_legendary"].(bool); legendary {
+		status = append(status, "legendary")
+	}
+	if mythical, _ := s.Metadata["is_mythical"].(bool); mythical {
+		status = append(status, "mythical")
+	}
+	if baby, _ := s.Metadata["is_baby"].(bool); baby {
+		status = append(status, "baby")
+	}
+	if len(status) > 0 {
+		sb.WriteString(fmt.Sprintf("Status: %s\n", strings.Join(status, ", ")))
+	}
+
+	if habitat := resourceName(s.Metadata["habitat"]); habitat != "" {
+		sb.WriteString(fmt.Sprintf("Habitat: %s\n", habitat))
+	}
+	if eggGroups := resourceNames(s.Metadata["egg_groups"]); len(eggGroups) > 0 {
+		sb.WriteString(fmt.Sprintf("Egg Groups: %s\n", strings.Join(eggGroups, ", ")))
+	}
+	if captureRate, ok := s.Metadata["capture_rate"].(float64); ok {
+		sb.WriteString(fmt.Sprintf("Capture Rate: %.0f\n", captureRate))
+	}
+	if happiness, ok := s.Metadata["base_happiness"].(float64); ok {
+		sb.WriteString(fmt.Sprintf("Base Friendship: %.0f\n", happiness))
+	}
+	if growthRate := resourceName(s.Metadata["growth_rate"]); growthRate != "" {
+		sb.WriteString(fmt.Sprintf("Growth Rate: %s\n", growthRate))
+	}
+	if genderRate, ok := s.Metadata["gender_rate"].(float64); ok {
+		if genderRate < 0 {
+			sb.WriteString("Gender: genderless\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("Gender: %.1f%% female\n", genderRate/8*100))
+		}
+	}
+	if evolvesFrom := resourceName(s.Metadata["evolves_from_species"]); evolvesFrom != "" {
+		sb.WriteString(fmt.Sprintf("Evolves From: %s\n", evolvesFrom))
+	}
+	if chainID := s.EvolutionChainID(); chainID != "" {
+		sb.WriteString(fmt.Sprintf("Evolution Chain: %s\n", chainID))
+	}
+	if flavor := englishText(s.Metadata["flavor_text_entries"], "flavor_text"); flavor != "" {
+		sb.WriteString(fmt.Sprintf("Description: %s\n", flavor))
+	}
+
+	return sb.String()
+}
+
+// EvolutionChainID returns the ID of the evolution chain this species belongs to.
+func (s *Species) EvolutionChainID() string {
+	chain, ok := s.Metadata["evolution_chain"].(map[string]any)
+	if !ok {
+		return ""
+	}
+	url, _ := chain["url"].(string)
+	return resourceIDFromURL(url)
+}
+
+func (s *Species) MetadataJSON() string {
+	b, _ := json.Marshal(s.Metadata)
+	return string(b)
+}
+
+type EvolutionChain struct {
+	ID       string
+	RawJSON  string
+	Metadata map[string]any
+}
+
+func (e *EvolutionChain) EmbeddingText() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Evolution Chain (ID: %s)\n", e.ID))
+
+	root, ok := e.Metadata["chain"].(map[string]any)
+	if !ok {
+		return sb.String()
+	}
+
+	var members []string
+	var steps []string
+	var walk func(link map[string]any)
+	walk = func(link map[string]any) {
+		from := resourceName(link["species"])
+		members = append(members, from)
+
+		next, _ := link["evolves_to"].([]any)
+		for _, n := range next {
+			child, ok := n.(map[string]any)
+			if !ok {
+				continue
+			}
+			to := resourceName(child["species"])
+
+			var methods []string
+			if details, ok := child["evolution_details"].([]any); ok {
+				for _, d := range details {
+					if dm, ok := d.(map[string]any); ok {
+						if method := describeEvolution(dm); method != "" {
+							methods = append(methods, method)
+						}
+					}
+				}
+			}
+
+			step := fmt.Sprintf("%s evolves into %s", from, to)
+			if len(methods) > 0 {
+				step += fmt.Sprintf(" (%s)", strings.Join(methods, "; or "))
+			}
+			steps = append(steps, step)
+
+			walk(child)
+		}
+	}
+	walk(root)
+
+	sb.WriteString(fmt.Sprintf("Members: %s\n", strings.Join(members, ", ")))
+	for _, step := range steps {
+		sb.WriteString(step + "\n")
+	}
+
+	return sb.String()
+}
+
+func (e *EvolutionChain) MetadataJSON() string {
+	b, _ := json.Marshal(e.Metadata)
+	return string(b)
+}
+
+// describeEvolution renders a PokeAPI evolution_details entry as a short
+// human-readable condition, e.g. "level-up at level 16" or "use-item water-stone".
+func describeEvolution(d map[string]any) string {
+	var parts []string
+
+	if trigger := resourceName(d["trigger"]); trigger != "" {
+		parts = append(parts, trigger)
+	}
+	if level, ok := d["min_level"].(float64); ok {
+		parts = append(parts, fmt.Sprintf("at level %.0f", level))
+	}
+	if item := resourceName(d["item"]); item != "" {
+		parts = append(parts, item)
+	}
+	if item := resourceName(d["held_item"]); item != "" {
+		parts = append(parts, fmt.Sprintf("holding %s", item))
+	}
+	if move := resourceName(d["known_move"]); move != "" {
+		parts = append(parts, fmt.Sprintf("knowing %s", move))
+	}
+	if moveType := resourceName(d["known_move_type"]); moveType != "" {
+		parts = append(parts, fmt.Sprintf("knowing a %s move", moveType))
+	}
+	if happiness, ok := d["min_happiness"].(float64); ok {
+		parts = append(parts, fmt.Sprintf("with friendship %.0f+", happiness))
+	}
+	if affection, ok := d["min_affection"].(float64); ok {
+		parts = append(parts, fmt.Sprintf("with affection %.0f+", affection))
+	}
+	if beauty, ok := d["min_beauty"].(float64); ok {
+		parts = append(parts, fmt.Sprintf("with beauty %.0f+", beauty))
+	}
+	if timeOfDay, ok := d["time_of_day"].(string); ok && timeOfDay != "" {
+		parts = append(parts, fmt.Sprintf("during the %s", timeOfDay))
+	}
+	if location := resourceName(d["location"]); location != "" {
+		parts = append(parts, fmt.Sprintf("at %s", location))
+	}
+	if gender, ok := d["gender"].(float64); ok {
+		if gender == 1 {
+			parts = append(parts, "if female")
+		} else {
+			parts = append(parts, "if male")
+		}
+	}
+	if species := resourceName(d["party_species"]); species != "" {
+		parts = append(parts, fmt.Sprintf("with %s in the party", species))
+	}
+	if partyType := resourceName(d["party_type"]); partyType != "" {
+		parts = append(parts, fmt.Sprintf("with a %s type in the party", partyType))
+	}
+	if species := resourceName(d["trade_species"]); species != "" {
+		parts = append(parts, fmt.Sprintf("traded for %s", species))
+	}
+	if rain, _ := d["needs_overworld_rain"].(bool); rain {
+		parts = append(parts, "while raining")
+	}
+	if upsideDown, _ := d["turn_upside_down"].(bool); upsideDown {
+		parts = append(parts, "with the console upside down")
+	}
+	if stats, ok := d["relative_physical_stats"].(float64); ok {
+		switch {
+		case stats > 0:
+			parts = append(parts, "if attack > defense")
+		case stats < 0:
+			parts = append(parts, "if attack < defense")
+		default:
+			parts = append(parts, "if attack = defense")
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// resourceName returns the name of a PokeAPI named resource ({"name": ..., "url": ...}).
+func resourceName(v any) string {
+	if m, ok := v.(map[string]any); ok {
+		if name, ok := m["name"].(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// resourceNames returns the names of a list of PokeAPI named resources.
+func resourceNames(v any) []string {
+	list, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, item := range list {
+		if name := resourceName(item); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// resourceIDFromURL returns the trailing ID of a PokeAPI resource URL,
+// e.g. "https://pokeapi.co/api/v2/evolution-chain/67/" -> "67".
+func resourceIDFromURL(url string) string {
+	trimmed := strings.TrimSuffix(url, "/")
+	if i := strings.LastIndex(trimmed, "/"); i >= 0 {
+		return trimmed[i+1:]
+	}
+	return trimmed
+}
+
+// nestedResourceNames returns the names of the named resources stored under key
+// in each element of a list, e.g. [{"pokemon": {"name": ...}}].
+func nestedResourceNames(v any, key string) []string {
+	list, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, item := range list {
+		if m, ok := item.(map[string]any); ok {
+			if name := resourceName(m[key]); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// englishText returns the given field of the first English entry in a PokeAPI
+// localized list such as effect_entries or flavor_text_entries.
+func englishText(v any, field string) string {
+	list, ok := v.([]any)
+	if !ok {
+		return ""
+	}
+	for _, item := range list {
+		entry, ok := item.(map[string]any)
+		if !ok || resourceName(entry["language"]) != "en" {
+			continue
+		}
+		if text, ok := entry[field].(string); ok {
+			return strings.Join(strings.Fields(text), " ")
+		}
+	}
+	return ""
+}
@@ -23,32 +23,134 @@ func NewService(cfg config.PokemonAPIConfig) *Service {
 }
 
 func (s *Service) GetPokemonByID(ctx context.Context, id string) (*Pokemon, error) {
-	url := fmt.Sprintf("%s/pokemon/%s", s.baseURL, id)
+	raw, rawJSON, err := s.get(ctx, "pokemon", id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pokemon{
+		ID:         id,
+		Identifier: raw["name"].(string),
+		RawJSON:    rawJSON,
+		Metadata:   raw,
+	}, nil
+}
+
+func (s *Service) GetMoveByID(ctx context.Context, id string) (*Move, error) {
+	raw, rawJSON, err := s.get(ctx, "move", id)
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := raw["name"].(string)
+	return &Move{
+		ID:         id,
+		Identifier: name,
+		RawJSON:    rawJSON,
+		Metadata:   raw,
+	}, nil
+}
+
+func (s *Service) GetAbilityByID(ctx context.Context, id string) (*Ability, error) {
+	raw, rawJSON, err := s.get(ctx, "ability", id)
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := raw["name"].(string)
+	return &Ability{
+		ID:         id,
+		Identifier: name,
+		RawJSON:    rawJSON,
+		Metadata:   raw,
+	}, nil
+}
+
+func (s *Service) GetItemByID(ctx context.Context, id string) (*Item, error) {
+	raw, rawJSON, err := s.get(ctx, "item", id)
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := raw["name"].(string)
+	return &Item{
+		ID:         id,
+		Identifier: name,
+		RawJSON:    rawJSON,
+		Metadata:   raw,
+	}, nil
+}
+
+func (s *Service) GetTypeByID(ctx context.Context, id string) (*Type, error) {
+	raw, rawJSON, err := s.get(ctx, "type", id)
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := raw["name"].(string)
+	return &Type{
+		ID:         id,
+		Identifier: name,
+		RawJSON:    rawJSON,
+		Metadata:   raw,
+	}, nil
+}
+
+func (s *Service) GetSpeciesByID(ctx context.Context, id string) (*Species, error) {
+	raw, rawJSON, err := s.get(ctx, "pokemon-species", id)
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := raw["name"].(string)
+	return &Species{
+		ID:         id,
+		Identifier: name,
+		RawJSON:    rawJSON,
+		Metadata:   raw,
+	}, nil
+}
+
+func (s *Service) GetEvolutionChainByID(ctx context.Context, id string) (*EvolutionChain, error) {
+	raw, rawJSON, err := s.get(ctx, "evolution-chain", id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EvolutionChain{
+		ID:       id,
+		RawJSON:  rawJSON,
+		Metadata: raw,
+	}, nil
+}
+
+// get fetches a PokeAPI resource and returns it both decoded and re-encoded as JSON.
+func (s *Service) get(ctx context.Context, resource string, id string) (map[string]any, string, error) {
+	url := fmt.Sprintf("%s/%s/%s", s.baseURL, resource, id)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return nil, "", fmt.Errorf("create request: %w", err)
 	}
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("fetch pokemon: %w", err)
+		return nil, "", fmt.Errorf("fetch %s: %w", resource, err)
 	}
 	defer func(Body io.ReadCloser) {
 		_ = Body.Close()
 	}(resp.Body)
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("fetch %s %s: unexpected status %d", resource, id, resp.StatusCode)
+	}
+
 	var raw map[string]any
 	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+		return nil, "", fmt.Errorf("decode response: %w", err)
 	}
 
 	rawBytes, _ := json.Marshal(raw)
 
-	return &Pokemon{
-		ID:         id,
-		Identifier: raw["name"].(string),
-		RawJSON:    string(rawBytes),
-		Metadata:   raw,
-	}, nil
+	return raw, string(rawBytes), nil
 }
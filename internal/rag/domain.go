@@ -1,6 +1,21 @@
 package rag
 
-import "time"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"cyrene/internal/platform/vectorstore"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/google/uuid"
+)
 
 const (
 	cacheScoreThreshold          = float32(0.75)
@@ -8,14 +23,319 @@ const (
 	cacheTopN                    = 5
 	cacheAnswerMaxLen            = 200
 	payloadTypeCache             = "qa_cache"
+	payloadTypeKey               = "type"
+	payloadReferenceKey          = "reference"
+	payloadReferencesKey         = "references"
+
+	// searchDefaultLimit is the number of documents searchPokemon returns when no
+	// limit is given. Documents are stored as several chunks, so searchOverfetch
+	// times as many chunks are retrieved before they are folded into documents.
+	searchDefaultLimit = 5
+	searchOverfetch    = 4
+
+	// turnPageSize and maxTurnPageSize are the default and largest page sizes for ListTurns.
+	turnPageSize    = 50
+	maxTurnPageSize = 500
+
+	// charsPerToken approximates how many characters of English text make up a
+	// token, for budgeting chat history without the model's tokenizer.
+	charsPerToken = 4
+)
+
+// DefaultConversation is the conversation of a chat request without a conversation ID.
+const DefaultConversation = "default"
+
+var (
+	ErrInvalidConversation  = errors.New("invalid conversation id")
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrTurnNotFound         = errors.New("chat turn not found")
+)
+
+// conversationIDPattern restricts conversation IDs to characters that are safe in
+// chat store keys and URLs.
+var conversationIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ValidConversationID reports whether id can name a conversation.
+func ValidConversationID(id string) bool {
+	return conversationIDPattern.MatchString(id)
+}
+
+// Conversation summarises one of a user's conversations.
+type Conversation struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	MessageCount int       `json:"message_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ConversationDetail is a conversation with its stored messages. Summary condenses
+// the earlier messages that are no longer stored.
+type ConversationDetail struct {
+	Conversation
+	Summary  string                `json:"summary,omitempty"`
+	Messages []ConversationMessage `json:"messages"`
+}
+
+// ConversationMessage is a user prompt or a model reply. Tools names the tools the
+// model called; their responses are not included.
+type ConversationMessage struct {
+	Role  string   `json:"role"`
+	Text  string   `json:"text,omitempty"`
+	Tools []string `json:"tools,omitempty"`
+}
+
+// conversationMessages converts stored history into conversation messages.
+func conversationMessages(history []*ai.Message) []ConversationMessage {
+	msgs := make([]ConversationMessage, 0, len(history))
+	for _, msg := range history {
+		if msg.Role != ai.RoleUser && msg.Role != ai.RoleModel {
+			continue
+		}
+		cm := ConversationMessage{Role: string(msg.Role), Text: msg.Text()}
+		for _, part := range msg.Content {
+			if part.IsToolRequest() {
+				cm.Tools = append(cm.Tools, part.ToolRequest.Name)
+			}
+		}
+		msgs = append(msgs, cm)
+	}
+	return msgs
+}
+
+// ChatTurn is the durable transcript of one chat request, kept for staff review
+// independently of the expiring chat history. RewrittenPrompt is the standalone
+// question the prompt was rewritten to, and Model is empty when no model wrote the
+// answer, as for cache hits and rejected prompts.
+type ChatTurn struct {
+	ID              uuid.UUID  `json:"id"`
+	User            string     `json:"user"`
+	ConversationID  string     `json:"conversation_id"`
+	Prompt          string     `json:"prompt"`
+	RewrittenPrompt string     `json:"rewritten_prompt,omitempty"`
+	Answer          string     `json:"answer"`
+	CacheHit        bool       `json:"cache_hit"`
+	Rejected        bool       `json:"rejected"`
+	ToolCalls       []ToolCall `json:"tool_calls"`
+	Model           string     `json:"model,omitempty"`
+	LatencyMS       int64      `json:"latency_ms"`
+	Error           string     `json:"error,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// ToolCall is a tool the model called while answering, with its input.
+type ToolCall struct {
+	Name  string `json:"name"`
+	Input any    `json:"input,omitempty"`
+}
+
+// TurnFilter selects chat turns. Zero fields do not filter; the time bounds are
+// exclusive. After is the ID of the last turn of the previous page.
+type TurnFilter struct {
+	User           string
+	ConversationID string
+	CreatedAfter   time.Time
+	CreatedBefore  time.Time
+	After          uuid.UUID
+	Limit          int
+}
+
+// TurnPage is one page of chat turns, oldest first. Next is the cursor for the
+// following page and is omitted on the last one.
+type TurnPage struct {
+	Turns []*ChatTurn `json:"turns"`
+	Total int         `json:"total"`
+	Next  uuid.UUID   `json:"next,omitzero"`
+}
+
+// toolCalls lists the tool requests among msgs in the order the model made them.
+func toolCalls(msgs []*ai.Message) []ToolCall {
+	calls := []ToolCall{}
+	for _, msg := range msgs {
+		for _, part := range msg.Content {
+			if part.IsToolRequest() {
+				calls = append(calls, ToolCall{Name: part.ToolRequest.Name, Input: part.ToolRequest.Input})
+			}
+		}
+	}
+	return calls
+}
+
+// ChatEventType names the events of a streamed chat answer.
+type ChatEventType string
+
+const (
+	// ChatEventToken carries the next piece of the answer text.
+	ChatEventToken ChatEventType = "token"
+	// ChatEventToolStart and ChatEventToolEnd bracket a tool call of the model.
+	ChatEventToolStart ChatEventType = "tool_start"
+	ChatEventToolEnd   ChatEventType = "tool_end"
+	// ChatEventAnswer carries the complete answer and ends the stream.
+	ChatEventAnswer ChatEventType = "answer"
+	// ChatEventError ends a stream that failed after it started.
+	ChatEventError ChatEventType = "error"
 )
 
+// ChatEvent is one event of a streamed chat answer.
+type ChatEvent struct {
+	Type  ChatEventType `json:"-"`
+	Text  string        `json:"text,omitempty"`
+	Tool  string        `json:"tool,omitempty"`
+	Input any           `json:"input,omitempty"`
+	Error string        `json:"error,omitempty"`
+}
+
+// chunkEvents converts a chunk streamed during generation into chat events. Model
+// chunks carry answer text and the tool calls it requests; tool chunks carry the
+// responses once the calls have finished.
+func chunkEvents(chunk *ai.ModelResponseChunk) []ChatEvent {
+	var events []ChatEvent
+	for _, part := range chunk.Content {
+		switch {
+		case part.IsToolRequest():
+			events = append(events, ChatEvent{Type: ChatEventToolStart, Tool: part.ToolRequest.Name, Input: part.ToolRequest.Input})
+		case part.IsToolResponse():
+			events = append(events, ChatEvent{Type: ChatEventToolEnd, Tool: part.ToolResponse.Name})
+		case part.IsText() && chunk.Role != ai.RoleTool && part.Text != "":
+			events = append(events, ChatEvent{Type: ChatEventToken, Text: part.Text})
+		}
+	}
+	return events
+}
+
+// estimateTokens approximates the number of tokens msg takes up in a prompt. Tool
+// requests and responses count with their JSON encoded input and output.
+func estimateTokens(msg *ai.Message) int {
+	chars := 0
+	for _, part := range msg.Content {
+		switch {
+		case part.IsToolRequest():
+			raw, _ := json.Marshal(part.ToolRequest.Input)
+			chars += len(part.ToolRequest.Name) + len(raw)
+		case part.IsToolResponse():
+			raw, _ := json.Marshal(part.ToolResponse.Output)
+			chars += len(part.ToolResponse.Name) + len(raw)
+		default:
+			chars += len(part.Text)
+		}
+	}
+	return (chars + charsPerToken - 1) / charsPerToken
+}
+
+// estimateTextTokens approximates the number of tokens text takes up in a prompt.
+func estimateTextTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+// trimHistory returns the most recent turns of history that fit in budget tokens.
+// A turn starts with a user message and runs up to the next one, so a tool call is
+// never kept without its request or response. A budget of zero or less keeps no
+// history.
+func trimHistory(history []*ai.Message, budget int) []*ai.Message {
+	start, tokens := len(history), 0
+	for i := len(history) - 1; i >= 0; i-- {
+		tokens += estimateTokens(history[i])
+		if tokens > budget {
+			break
+		}
+		if history[i].Role == ai.RoleUser {
+			start = i
+		}
+	}
+	return history[start:]
+}
+
+// conversationText returns the user and model text messages of history, leaving
+// out tool calls, for prompts that only need what was said.
+func conversationText(history []*ai.Message) []*ai.Message {
+	var msgs []*ai.Message
+	for _, msg := range history {
+		if msg.Role != ai.RoleUser && msg.Role != ai.RoleModel {
+			continue
+		}
+		if text := msg.Text(); text != "" {
+			msgs = append(msgs, ai.NewTextMessage(msg.Role, text))
+		}
+	}
+	return msgs
+}
+
+// turnMessages returns the messages a generation added after the system prompt and
+// the history it was given: the prompt, the tool calls made and the answer.
+func turnMessages(resp *ai.ModelResponse, history []*ai.Message) []*ai.Message {
+	msgs := resp.History()
+	for len(msgs) > 0 && msgs[0].Role == ai.RoleSystem {
+		msgs = msgs[1:]
+	}
+	if len(msgs) < len(history) {
+		return nil
+	}
+	return msgs[len(history):]
+}
+
+// summarySection is appended to the system prompt to carry the summary of the
+// earlier conversation ahead of the recent history. It is empty without a summary.
+func summarySection(summary string) string {
+	if summary == "" {
+		return ""
+	}
+	return "\n\nSummary of the earlier conversation:\n" + summary
+}
+
+// transcript writes the text of msgs as a dialogue for the summarizer.
+func transcript(msgs []*ai.Message) string {
+	var sb strings.Builder
+	for _, msg := range conversationText(msgs) {
+		speaker := "Player"
+		if msg.Role == ai.RoleModel {
+			speaker = "Cyrene"
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", speaker, msg.Text())
+	}
+	return sb.String()
+}
+
 type CachedAnswer struct {
 	Question  string
 	Answer    string
 	CreatedAt time.Time
 }
 
+// usedReferences collects the document references the tools read while answering a
+// prompt, so the cached answer can be invalidated when one of them is deleted.
+type usedReferences struct {
+	mu   sync.Mutex
+	refs []string
+}
+
+type usedReferencesKey struct{}
+
+func withUsedReferences(ctx context.Context) (context.Context, *usedReferences) {
+	used := &usedReferences{}
+	return context.WithValue(ctx, usedReferencesKey{}, used), used
+}
+
+// addUsedReferences records references on the collector in ctx, if there is one.
+func addUsedReferences(ctx context.Context, refs ...string) {
+	used, ok := ctx.Value(usedReferencesKey{}).(*usedReferences)
+	if !ok {
+		return
+	}
+	used.mu.Lock()
+	defer used.mu.Unlock()
+	for _, ref := range refs {
+		if ref != "" && !slices.Contains(used.refs, ref) {
+			used.refs = append(used.refs, ref)
+		}
+	}
+}
+
+func (u *usedReferences) list() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return slices.Clone(u.refs)
+}
+
 type cacheValidation struct {
 	MatchIndex int    `json:"match_index"`
 	Reason     string `json:"reason"`
@@ -44,6 +364,15 @@ Examples:
 - "what is a good fire type?" -> prompt: "What is a good fire type?" (no changes needed)
 - "tell me about charzard" -> prompt: "Tell me about Charizard" (typo fix only)`
 
+const summaryPrompt = `Condense a conversation between a player of a Cobblemon Minecraft server and Cyrene, the server's assistant, into a short summary that lets Cyrene continue it.
+
+Rules:
+- Start from the existing summary, if any, and fold the new messages into it
+- Keep what the player is working towards: their team, the Pokemon they are hunting or raising, their goals and stated preferences
+- Keep the facts Cyrene already gave that the player may refer back to (stats, spawn locations, evolution methods)
+- Drop greetings, small talk and anything that was superseded
+- Write plain prose in the third person, at most 200 words`
+
 const systemPrompt = `You are Cyrene, an assistant for a Cobblemon Minecraft server. Your personality is inspired by Elysia from Honkai Impact - warm, playful, and genuinely caring. You speak with gentle elegance and occasional teasing charm, but never at the expense of being helpful.
 
 Personality traits:
@@ -56,6 +385,27 @@ Rules:
 - Do not use emojis
 - Do not participate with idle chatter with the user
 
-Use searchPokemon for broad or exploratory questions. Use getPokemon when you need exact stats or details for a specific Pokemon. You can combine both: search first to find candidates, then fetch details for specific ones. Always use the tools rather than relying on general knowledge.
+Use searchPokemon for broad or exploratory questions, including questions about moves, abilities, held items, type matchups, how Pokemon evolve and where they spawn on this server. Use getPokemon when you need exact stats or details for a specific Pokemon. You can combine both: search first to find candidates, then fetch details for specific ones. Always use the tools rather than relying on general knowledge.
 
 Keep responses helpful and concise. Your charm should enhance the experience, not overshadow the information.`
+
+// dedupeByReference keeps the best scoring chunk of each document among results,
+// which are ordered by score, and returns at most limit of them. Results without a
+// reference are kept as they are.
+func dedupeByReference(results []vectorstore.SearchResult, limit int) []vectorstore.SearchResult {
+	seen := make(map[string]bool)
+	deduped := make([]vectorstore.SearchResult, 0, min(len(results), limit))
+	for _, r := range results {
+		if len(deduped) == limit {
+			break
+		}
+		if ref, ok := r.Payload[payloadReferenceKey].(string); ok {
+			if seen[ref] {
+				continue
+			}
+			seen[ref] = true
+		}
+		deduped = append(deduped, r)
+	}
+	return deduped
+}
@@ -0,0 +1,173 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+
+	"cyrene/internal/platform/vectorstore"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupeByReference(t *testing.T) {
+	result := func(id, ref string) vectorstore.SearchResult {
+		payload := map[string]any{}
+		if ref != "" {
+			payload[payloadReferenceKey] = ref
+		}
+		return vectorstore.SearchResult{ID: id, Payload: payload}
+	}
+	results := []vectorstore.SearchResult{
+		result("a-moves", "pokemon_25"),
+		result("b-stats", "pokemon_26"),
+		result("a-stats", "pokemon_25"),
+		result("legacy", ""),
+		result("c-lore", "pokemon_172"),
+	}
+
+	ids := func(rs []vectorstore.SearchResult) []string {
+		var out []string
+		for _, r := range rs {
+			out = append(out, r.ID)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"a-moves", "b-stats", "legacy", "c-lore"}, ids(dedupeByReference(results, 5)))
+	assert.Equal(t, []string{"a-moves", "b-stats"}, ids(dedupeByReference(results, 2)))
+	assert.Empty(t, dedupeByReference(nil, 5))
+}
+
+func TestChunkEvents(t *testing.T) {
+	modelChunk := &ai.ModelResponseChunk{
+		Role: ai.RoleModel,
+		Content: []*ai.Part{
+			ai.NewTextPart("Let me check. "),
+			ai.NewToolRequestPart(&ai.ToolRequest{Name: "searchPokemon", Input: map[string]any{"query": "fast electric"}}),
+		},
+	}
+	assert.Equal(t, []ChatEvent{
+		{Type: ChatEventToken, Text: "Let me check. "},
+		{Type: ChatEventToolStart, Tool: "searchPokemon", Input: map[string]any{"query": "fast electric"}},
+	}, chunkEvents(modelChunk))
+
+	toolChunk := &ai.ModelResponseChunk{
+		Role:    ai.RoleTool,
+		Content: []*ai.Part{ai.NewToolResponsePart(&ai.ToolResponse{Name: "searchPokemon", Output: []any{}})},
+	}
+	assert.Equal(t, []ChatEvent{{Type: ChatEventToolEnd, Tool: "searchPokemon"}}, chunkEvents(toolChunk))
+
+	assert.Empty(t, chunkEvents(&ai.ModelResponseChunk{Content: []*ai.Part{ai.NewTextPart("")}}))
+}
+
+func TestTrimHistory(t *testing.T) {
+	toolRequest := &ai.Message{Role: ai.RoleModel, Content: []*ai.Part{
+		ai.NewToolRequestPart(&ai.ToolRequest{Name: "getPokemon", Input: map[string]any{"id": "pikachu"}}),
+	}}
+	toolResponse := &ai.Message{Role: ai.RoleTool, Content: []*ai.Part{
+		ai.NewToolResponsePart(&ai.ToolResponse{Name: "getPokemon", Output: map[string]any{"speed": 90}}),
+	}}
+	history := []*ai.Message{
+		ai.NewUserTextMessage("tell me about pikachu"),
+		toolRequest,
+		toolResponse,
+		ai.NewModelTextMessage("Pikachu is an electric type with 90 speed."),
+		ai.NewUserTextMessage("and raichu?"),
+		ai.NewModelTextMessage("Raichu is its evolution."),
+	}
+	lastTurn := estimateTokens(history[4]) + estimateTokens(history[5])
+
+	assert.Equal(t, history, trimHistory(history, 1000))
+	assert.Equal(t, history[4:], trimHistory(history, lastTurn))
+	assert.Equal(t, history[4:], trimHistory(history, lastTurn+estimateTokens(history[3])),
+		"a turn is not kept without its prompt")
+	assert.Empty(t, trimHistory(history, lastTurn-1))
+	assert.Empty(t, trimHistory(history, 0))
+
+	assert.Equal(t, history[4:], trimHistory(history[2:], 1000), "a turn cut off by the store is dropped")
+}
+
+func TestConversationText(t *testing.T) {
+	history := []*ai.Message{
+		ai.NewUserTextMessage("tell me about pikachu"),
+		{Role: ai.RoleModel, Content: []*ai.Part{
+			ai.NewToolRequestPart(&ai.ToolRequest{Name: "getPokemon", Input: map[string]any{"id": "pikachu"}}),
+		}},
+		{Role: ai.RoleTool, Content: []*ai.Part{
+			ai.NewToolResponsePart(&ai.ToolResponse{Name: "getPokemon", Output: "{}"}),
+		}},
+		ai.NewModelTextMessage("Pikachu is an electric type."),
+	}
+
+	assert.Equal(t, []*ai.Message{
+		ai.NewUserTextMessage("tell me about pikachu"),
+		ai.NewModelTextMessage("Pikachu is an electric type."),
+	}, conversationText(history))
+}
+
+func TestTurnMessages(t *testing.T) {
+	history := []*ai.Message{ai.NewUserTextMessage("hi"), ai.NewModelTextMessage("hello")}
+	turn := []*ai.Message{
+		ai.NewUserTextMessage("how fast is pikachu?"),
+		{Role: ai.RoleModel, Content: []*ai.Part{ai.NewToolRequestPart(&ai.ToolRequest{Name: "getPokemon"})}},
+		{Role: ai.RoleTool, Content: []*ai.Part{ai.NewToolResponsePart(&ai.ToolResponse{Name: "getPokemon"})}},
+	}
+	answer := ai.NewModelTextMessage("90 speed.")
+
+	messages := append([]*ai.Message{ai.NewSystemTextMessage("system")}, history...)
+	resp := &ai.ModelResponse{
+		Request: &ai.ModelRequest{Messages: append(messages, turn...)},
+		Message: answer,
+	}
+
+	assert.Equal(t, append(turn, answer), turnMessages(resp, history))
+}
+
+func TestConversationMessages(t *testing.T) {
+	history := []*ai.Message{
+		ai.NewUserTextMessage("how fast is pikachu?"),
+		{Role: ai.RoleModel, Content: []*ai.Part{
+			ai.NewToolRequestPart(&ai.ToolRequest{Name: "getPokemon", Input: map[string]any{"id": "pikachu"}}),
+		}},
+		{Role: ai.RoleTool, Content: []*ai.Part{
+			ai.NewToolResponsePart(&ai.ToolResponse{Name: "getPokemon", Output: "{}"}),
+		}},
+		ai.NewModelTextMessage("Pikachu has 90 speed."),
+	}
+
+	assert.Equal(t, []ConversationMessage{
+		{Role: "user", Text: "how fast is pikachu?"},
+		{Role: "model", Tools: []string{"getPokemon"}},
+		{Role: "model", Text: "Pikachu has 90 speed."},
+	}, conversationMessages(history))
+}
+
+func TestValidConversationID(t *testing.T) {
+	assert.True(t, ValidConversationID(DefaultConversation))
+	assert.True(t, ValidConversationID("0192f1c2-web_1"))
+	assert.False(t, ValidConversationID(""))
+	assert.False(t, ValidConversationID("chat:history"))
+	assert.False(t, ValidConversationID("a/b"))
+	assert.False(t, ValidConversationID(strings.Repeat("a", 65)))
+}
+
+func TestToolCalls(t *testing.T) {
+	turn := []*ai.Message{
+		ai.NewUserTextMessage("compare pikachu and raichu"),
+		{Role: ai.RoleModel, Content: []*ai.Part{
+			ai.NewToolRequestPart(&ai.ToolRequest{Name: "getPokemon", Input: map[string]any{"id": "pikachu"}}),
+			ai.NewToolRequestPart(&ai.ToolRequest{Name: "getPokemon", Input: map[string]any{"id": "raichu"}}),
+		}},
+		{Role: ai.RoleTool, Content: []*ai.Part{
+			ai.NewToolResponsePart(&ai.ToolResponse{Name: "getPokemon", Output: "{}"}),
+		}},
+		ai.NewModelTextMessage("Raichu is faster."),
+	}
+
+	assert.Equal(t, []ToolCall{
+		{Name: "getPokemon", Input: map[string]any{"id": "pikachu"}},
+		{Name: "getPokemon", Input: map[string]any{"id": "raichu"}},
+	}, toolCalls(turn))
+	assert.Equal(t, []ToolCall{}, toolCalls(turn[:1]))
+}
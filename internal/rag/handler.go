@@ -1,33 +1,82 @@
 package rag
 
 import (
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"cyrene/internal/platform/server"
+
+	"github.com/google/uuid"
 )
 
+// streamWriteTimeout replaces the server's write timeout for streamed answers,
+// which can take longer than a regular response.
+const streamWriteTimeout = 5 * time.Minute
+
+// ChatRequest is a prompt of a user. Requests without a conversation ID continue
+// the user's default conversation.
 type ChatRequest struct {
-	Message string `json:"message"`
-	User    string `json:"user"`
+	Message        string `json:"message"`
+	User           string `json:"user"`
+	ConversationID string `json:"conversation_id,omitempty"`
 }
 
 type ChatResponse struct {
-	Response string `json:"response"`
+	Response       string `json:"response"`
+	ConversationID string `json:"conversation_id"`
+}
+
+type RenameConversationRequest struct {
+	Title string `json:"title"`
 }
 
 type Handler struct {
 	service Service
+	// adminKey guards the review endpoints; they are disabled while it is empty.
+	adminKey string
 }
 
-func NewHandler(service Service) *Handler {
-	return &Handler{service: service}
+func NewHandler(service Service, adminKey string) *Handler {
+	return &Handler{service: service, adminKey: adminKey}
 }
 
 func (h *Handler) RegisterRoutes() *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.HandleFunc("POST /", h.chat)
+	server.HandleFunc(mux, "POST /stream", h.chatStream)
+	server.HandleFunc(mux, "GET /conversations", h.listConversations)
+	server.HandleFunc(mux, "GET /conversations/{id}", h.getConversation)
+	server.HandleFunc(mux, "PATCH /conversations/{id}", h.renameConversation)
+	server.HandleFunc(mux, "DELETE /conversations/{id}", h.deleteConversation)
+	server.HandleFunc(mux, "GET /turns", h.requireAdmin(h.listTurns))
+	server.HandleFunc(mux, "GET /turns/{id}", h.requireAdmin(h.getTurn))
 	return mux
 }
 
+// requireAdmin passes on only the requests that carry the admin key as a bearer token.
+func (h *Handler) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.adminKey == "" {
+			http.Error(w, "admin endpoints are disabled", http.StatusForbidden)
+			return
+		}
+		key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(key), []byte(h.adminKey)) != 1 {
+			http.Error(w, "invalid admin key", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
 // @Summary      Chat with Pokemon knowledge base
 // @Description  Query the Pokemon RAG system with a message
 // @Tags         chat
@@ -35,31 +84,337 @@ func (h *Handler) RegisterRoutes() *http.ServeMux {
 // @Produce      json
 // @Param        request  body      ChatRequest   true  "Chat request"
 // @Success      200      {object}  ChatResponse
-// @Failure      400      {string}  string  "invalid request body / message is required / user is required"
+// @Failure      400      {string}  string  "invalid request body / message is required / user is required / invalid conversation id"
 // @Failure      500      {string}  string  "internal server error"
 // @Router       /chat/ [post]
 func (h *Handler) chat(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeChatRequest(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.Chat(r.Context(), req.Message, req.User, req.ConversationID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ChatResponse{Response: response, ConversationID: req.ConversationID})
+}
+
+// @Summary      Stream a chat answer
+// @Description  Answer like /chat/ as Server-Sent Events: "token" events carry answer text as it is generated, "tool_start" and "tool_end" bracket tool calls, and a final "answer" or "error" event ends the stream
+// @Tags         chat
+// @Accept       json
+// @Produce      text/event-stream
+// @Param        request  body      ChatRequest   true  "Chat request"
+// @Success      200      {object}  ChatEvent
+// @Failure      400      {string}  string  "invalid request body / message is required / user is required / invalid conversation id"
+// @Router       /chat/stream/ [post]
+func (h *Handler) chatStream(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeChatRequest(w, r)
+	if !ok {
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+		slog.Warn("failed to extend write deadline for chat stream", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	emit := func(event ChatEvent) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("marshal %s event: %w", event.Type, err)
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	answer, err := h.service.ChatStream(r.Context(), req.Message, req.User, req.ConversationID, emit)
+	if err != nil {
+		if r.Context().Err() == nil {
+			emit(ChatEvent{Type: ChatEventError, Error: err.Error()})
+		}
+		return
+	}
+	emit(ChatEvent{Type: ChatEventAnswer, Text: answer})
+}
+
+// decodeChatRequest reads and validates a chat request, answering 400 if it is invalid.
+func decodeChatRequest(w http.ResponseWriter, r *http.Request) (ChatRequest, bool) {
 	var req ChatRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid request body", http.StatusBadRequest)
-		return
+		return req, false
 	}
 
 	if req.Message == "" {
 		http.Error(w, "message is required", http.StatusBadRequest)
-		return
+		return req, false
 	}
 	if req.User == "" {
+		http.Error(w, "user is required", http.StatusBadRequest)
+		return req, false
+	}
+	if req.ConversationID == "" {
+		req.ConversationID = DefaultConversation
+	} else if !ValidConversationID(req.ConversationID) {
+		http.Error(w, ErrInvalidConversation.Error(), http.StatusBadRequest)
+		return req, false
+	}
+	return req, true
+}
+
+// @Summary      List conversations
+// @Description  List the user's conversations that have not expired, most recently updated first
+// @Tags         chat
+// @Produce      json
+// @Param        user  query     string  true  "User"
+// @Success      200   {array}   Conversation
+// @Failure      400   {string}  string  "user is required"
+// @Failure      500   {string}  string  "internal server error"
+// @Router       /chat/conversations [get]
+func (h *Handler) listConversations(w http.ResponseWriter, r *http.Request) {
+	user := r.URL.Query().Get("user")
+	if user == "" {
 		http.Error(w, "user is required", http.StatusBadRequest)
 		return
 	}
 
-	response, err := h.service.Chat(r.Context(), req.Message, req.User)
+	conversations, err := h.service.Conversations(r.Context(), user)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(ChatResponse{Response: response})
+	json.NewEncoder(w).Encode(conversations)
+}
+
+// @Summary      Get conversation
+// @Description  Return a conversation of the user with its prompts and answers
+// @Tags         chat
+// @Produce      json
+// @Param        id    path      string  true  "Conversation ID"
+// @Param        user  query     string  true  "User"
+// @Success      200   {object}  ConversationDetail
+// @Failure      400   {string}  string  "user is required / invalid conversation id"
+// @Failure      404   {string}  string  "conversation not found"
+// @Failure      500   {string}  string  "internal server error"
+// @Router       /chat/conversations/{id} [get]
+func (h *Handler) getConversation(w http.ResponseWriter, r *http.Request) {
+	user, id, ok := conversationParams(w, r)
+	if !ok {
+		return
+	}
+
+	detail, err := h.service.Conversation(r.Context(), user, id)
+	if err != nil {
+		writeConversationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+// @Summary      Rename conversation
+// @Description  Set the title of a conversation of the user
+// @Tags         chat
+// @Accept       json
+// @Param        id       path      string                     true  "Conversation ID"
+// @Param        user     query     string                     true  "User"
+// @Param        request  body      RenameConversationRequest  true  "New title"
+// @Success      204
+// @Failure      400      {string}  string  "invalid request body / title is required / user is required / invalid conversation id"
+// @Failure      404      {string}  string  "conversation not found"
+// @Failure      500      {string}  string  "internal server error"
+// @Router       /chat/conversations/{id} [patch]
+func (h *Handler) renameConversation(w http.ResponseWriter, r *http.Request) {
+	user, id, ok := conversationParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req RenameConversationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		http.Error(w, "title is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RenameConversation(r.Context(), user, id, title); err != nil {
+		writeConversationError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Delete conversation
+// @Description  Remove a conversation of the user and its messages
+// @Tags         chat
+// @Param        id    path      string  true  "Conversation ID"
+// @Param        user  query     string  true  "User"
+// @Success      204
+// @Failure      400   {string}  string  "user is required / invalid conversation id"
+// @Failure      404   {string}  string  "conversation not found"
+// @Failure      500   {string}  string  "internal server error"
+// @Router       /chat/conversations/{id} [delete]
+func (h *Handler) deleteConversation(w http.ResponseWriter, r *http.Request) {
+	user, id, ok := conversationParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteConversation(r.Context(), user, id); err != nil {
+		writeConversationError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// conversationParams reads the user query parameter and the conversation ID path
+// value, answering 400 if either is invalid.
+func conversationParams(w http.ResponseWriter, r *http.Request) (user, id string, ok bool) {
+	user = r.URL.Query().Get("user")
+	if user == "" {
+		http.Error(w, "user is required", http.StatusBadRequest)
+		return "", "", false
+	}
+	id = r.PathValue("id")
+	if !ValidConversationID(id) {
+		http.Error(w, ErrInvalidConversation.Error(), http.StatusBadRequest)
+		return "", "", false
+	}
+	return user, id, true
+}
+
+func writeConversationError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrConversationNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// @Summary      List chat turns
+// @Description  Page through the recorded transcripts of chat requests for review, oldest first, optionally filtered by user, conversation and creation time. Pass the returned next cursor as after to fetch the following page.
+// @Tags         chat
+// @Produce      json
+// @Param        user             query     string  false  "User"
+// @Param        conversation_id  query     string  false  "Conversation ID"
+// @Param        created_after    query     string  false  "Only turns created after this RFC 3339 time"
+// @Param        created_before   query     string  false  "Only turns created before this RFC 3339 time"
+// @Param        after            query     string  false  "Cursor from the previous page"
+// @Param        limit            query     int     false  "Page size (default 50, max 500)"
+// @Security     AdminKey
+// @Success      200              {object}  TurnPage
+// @Failure      400              {string}  string  "invalid query"
+// @Failure      401              {string}  string  "invalid admin key"
+// @Failure      403              {string}  string  "admin endpoints are disabled"
+// @Failure      500              {string}  string  "internal server error"
+// @Router       /chat/turns [get]
+func (h *Handler) listTurns(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTurnFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.service.ListTurns(r.Context(), filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// @Summary      Get chat turn
+// @Description  Return the recorded transcript of one chat request
+// @Tags         chat
+// @Produce      json
+// @Param        id   path      string  true  "Turn ID"
+// @Security     AdminKey
+// @Success      200  {object}  ChatTurn
+// @Failure      400  {string}  string  "invalid turn id"
+// @Failure      401  {string}  string  "invalid admin key"
+// @Failure      403  {string}  string  "admin endpoints are disabled"
+// @Failure      404  {string}  string  "chat turn not found"
+// @Failure      500  {string}  string  "internal server error"
+// @Router       /chat/turns/{id} [get]
+func (h *Handler) getTurn(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid turn id", http.StatusBadRequest)
+		return
+	}
+
+	turn, err := h.service.GetTurn(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, ErrTurnNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(turn)
+}
+
+func parseTurnFilter(query url.Values) (TurnFilter, error) {
+	filter := TurnFilter{
+		User:           query.Get("user"),
+		ConversationID: query.Get("conversation_id"),
+	}
+
+	times := map[string]*time.Time{
+		"created_after":  &filter.CreatedAfter,
+		"created_before": &filter.CreatedBefore,
+	}
+	for name, dest := range times {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s: must be an RFC 3339 time", name)
+		}
+		*dest = t
+	}
+
+	if after := query.Get("after"); after != "" {
+		id, err := uuid.Parse(after)
+		if err != nil {
+			return filter, errors.New("invalid after cursor")
+		}
+		filter.After = id
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return filter, errors.New("invalid limit")
+		}
+		filter.Limit = n
+	}
+
+	return filter, nil
 }
@@ -0,0 +1,410 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAdminKey = "admin-secret"
+
+// adminRequest returns a request carrying the admin key.
+func adminRequest(method string, target string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Authorization", "Bearer "+testAdminKey)
+	return req
+}
+
+// mounted serves the handler's routes under /chat, as cmd/api mounts them.
+func mounted(h *Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/chat/", http.StripPrefix("/chat", h.RegisterRoutes()))
+	return mux
+}
+
+type mockService struct {
+	Service
+	chatFn               func(ctx context.Context, prompt string, user string, conversation string) (string, error)
+	chatStreamFn         func(ctx context.Context, prompt string, user string, conversation string, emit func(ChatEvent) error) (string, error)
+	conversationsFn      func(ctx context.Context, user string) ([]Conversation, error)
+	conversationFn       func(ctx context.Context, user string, conversation string) (*ConversationDetail, error)
+	renameConversationFn func(ctx context.Context, user string, conversation string, title string) error
+	deleteConversationFn func(ctx context.Context, user string, conversation string) error
+	listTurnsFn          func(ctx context.Context, filter TurnFilter) (*TurnPage, error)
+	getTurnFn            func(ctx context.Context, id uuid.UUID) (*ChatTurn, error)
+}
+
+func (m *mockService) Chat(ctx context.Context, prompt string, user string, conversation string) (string, error) {
+	return m.chatFn(ctx, prompt, user, conversation)
+}
+
+func (m *mockService) ChatStream(ctx context.Context, prompt string, user string, conversation string, emit func(ChatEvent) error) (string, error) {
+	return m.chatStreamFn(ctx, prompt, user, conversation, emit)
+}
+
+func (m *mockService) Conversations(ctx context.Context, user string) ([]Conversation, error) {
+	return m.conversationsFn(ctx, user)
+}
+
+func (m *mockService) Conversation(ctx context.Context, user string, conversation string) (*ConversationDetail, error) {
+	return m.conversationFn(ctx, user, conversation)
+}
+
+func (m *mockService) RenameConversation(ctx context.Context, user string, conversation string, title string) error {
+	return m.renameConversationFn(ctx, user, conversation, title)
+}
+
+func (m *mockService) DeleteConversation(ctx context.Context, user string, conversation string) error {
+	return m.deleteConversationFn(ctx, user, conversation)
+}
+
+func (m *mockService) ListTurns(ctx context.Context, filter TurnFilter) (*TurnPage, error) {
+	return m.listTurnsFn(ctx, filter)
+}
+
+func (m *mockService) GetTurn(ctx context.Context, id uuid.UUID) (*ChatTurn, error) {
+	return m.getTurnFn(ctx, id)
+}
+
+func TestHandler_ChatStream(t *testing.T) {
+	svc := &mockService{
+		chatStreamFn: func(ctx context.Context, prompt string, user string, conversation string, emit func(ChatEvent) error) (string, error) {
+			assert.Equal(t, "how fast is pikachu?", prompt)
+			assert.Equal(t, "ash", user)
+			assert.Equal(t, DefaultConversation, conversation)
+			require.NoError(t, emit(ChatEvent{Type: ChatEventToolStart, Tool: "getPokemon", Input: map[string]any{"id": "pikachu"}}))
+			require.NoError(t, emit(ChatEvent{Type: ChatEventToolEnd, Tool: "getPokemon"}))
+			require.NoError(t, emit(ChatEvent{Type: ChatEventToken, Text: "Pikachu has "}))
+			require.NoError(t, emit(ChatEvent{Type: ChatEventToken, Text: "90 speed."}))
+			return "Pikachu has 90 speed.", nil
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/stream/", strings.NewReader(`{"message":"how fast is pikachu?","user":"ash"}`))
+	rec := httptest.NewRecorder()
+	NewHandler(svc, testAdminKey).RegisterRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, "event: tool_start\ndata: {\"tool\":\"getPokemon\",\"input\":{\"id\":\"pikachu\"}}\n\n"+
+		"event: tool_end\ndata: {\"tool\":\"getPokemon\"}\n\n"+
+		"event: token\ndata: {\"text\":\"Pikachu has \"}\n\n"+
+		"event: token\ndata: {\"text\":\"90 speed.\"}\n\n"+
+		"event: answer\ndata: {\"text\":\"Pikachu has 90 speed.\"}\n\n", rec.Body.String())
+}
+
+func TestHandler_ChatStream_MountedUnderPrefix(t *testing.T) {
+	svc := &mockService{
+		chatStreamFn: func(ctx context.Context, prompt string, user string, conversation string, emit func(ChatEvent) error) (string, error) {
+			return "Pikachu has 90 speed.", nil
+		},
+	}
+	routes := mounted(NewHandler(svc, testAdminKey))
+
+	for _, path := range []string{"/chat/stream", "/chat/stream/"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"message":"how fast is pikachu?","user":"ash"}`))
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+		assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"), path)
+		assert.Contains(t, rec.Body.String(), "event: answer\n", path)
+	}
+}
+
+func TestHandler_ChatStream_Error(t *testing.T) {
+	svc := &mockService{
+		chatStreamFn: func(ctx context.Context, prompt string, user string, conversation string, emit func(ChatEvent) error) (string, error) {
+			return "", errors.New("model unavailable")
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/stream/", strings.NewReader(`{"message":"hi","user":"ash"}`))
+	rec := httptest.NewRecorder()
+	NewHandler(svc, testAdminKey).RegisterRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "event: error\ndata: {\"error\":\"model unavailable\"}\n\n", rec.Body.String())
+}
+
+func TestHandler_ChatStream_InvalidRequest(t *testing.T) {
+	h := NewHandler(&mockService{}, testAdminKey)
+
+	for body, want := range map[string]string{
+		`{`:               "invalid request body",
+		`{"user":"ash"}`:  "message is required",
+		`{"message":"x"}`: "user is required",
+		`{"message":"x","user":"ash","conversation_id":"a:b"}`: "invalid conversation id",
+	} {
+		rec := httptest.NewRecorder()
+		h.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/stream/", strings.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+		assert.Contains(t, rec.Body.String(), want)
+	}
+}
+
+func TestHandler_Chat_Conversation(t *testing.T) {
+	svc := &mockService{
+		chatFn: func(ctx context.Context, prompt string, user string, conversation string) (string, error) {
+			assert.Equal(t, "web-1", conversation)
+			return "Pikachu has 90 speed.", nil
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"message":"how fast is pikachu?","user":"ash","conversation_id":"web-1"}`))
+	rec := httptest.NewRecorder()
+	NewHandler(svc, testAdminKey).RegisterRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"response":"Pikachu has 90 speed.","conversation_id":"web-1"}`, rec.Body.String())
+}
+
+func TestHandler_Conversations(t *testing.T) {
+	updated := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	svc := &mockService{
+		conversationsFn: func(ctx context.Context, user string) ([]Conversation, error) {
+			assert.Equal(t, "ash", user)
+			return []Conversation{{ID: "web-1", Title: "how fast is pikachu?", MessageCount: 4, CreatedAt: updated, UpdatedAt: updated}}, nil
+		},
+		conversationFn: func(ctx context.Context, user string, conversation string) (*ConversationDetail, error) {
+			if conversation != "web-1" {
+				return nil, fmt.Errorf("get conversation: %w", ErrConversationNotFound)
+			}
+			return &ConversationDetail{
+				Conversation: Conversation{ID: "web-1", Title: "how fast is pikachu?", MessageCount: 2},
+				Messages: []ConversationMessage{
+					{Role: "user", Text: "how fast is pikachu?"},
+					{Role: "model", Text: "Pikachu has 90 speed."},
+				},
+			}, nil
+		},
+	}
+	h := NewHandler(svc, testAdminKey).RegisterRoutes()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/conversations/?user=ash", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"id":"web-1","title":"how fast is pikachu?","message_count":4,
+		"created_at":"2026-10-17T12:00:00Z","updated_at":"2026-10-17T12:00:00Z"}]`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/conversations/web-1/?user=ash", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var detail ConversationDetail
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &detail))
+	assert.Equal(t, "web-1", detail.ID)
+	assert.Len(t, detail.Messages, 2)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/conversations/game/?user=ash", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/conversations/", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandler_RenameAndDeleteConversation(t *testing.T) {
+	var renamed, deleted string
+	svc := &mockService{
+		renameConversationFn: func(ctx context.Context, user string, conversation string, title string) error {
+			renamed = user + "/" + conversation + ": " + title
+			return nil
+		},
+		deleteConversationFn: func(ctx context.Context, user string, conversation string) error {
+			if conversation != "web-1" {
+				return fmt.Errorf("delete conversation: %w", ErrConversationNotFound)
+			}
+			deleted = user + "/" + conversation
+			return nil
+		},
+	}
+	h := NewHandler(svc, testAdminKey).RegisterRoutes()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/conversations/web-1/?user=ash", strings.NewReader(`{"title":" Speed tiers "}`)))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "ash/web-1: Speed tiers", renamed)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/conversations/web-1/?user=ash", strings.NewReader(`{"title":" "}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/conversations/web-1/?user=ash", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "ash/web-1", deleted)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/conversations/web-2/?user=ash", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestHandler_Conversations_MountedUnderPrefix(t *testing.T) {
+	svc := &mockService{
+		conversationsFn: func(ctx context.Context, user string) ([]Conversation, error) {
+			return []Conversation{}, nil
+		},
+		conversationFn: func(ctx context.Context, user string, conversation string) (*ConversationDetail, error) {
+			return &ConversationDetail{Conversation: Conversation{ID: conversation}}, nil
+		},
+		renameConversationFn: func(ctx context.Context, user string, conversation string, title string) error {
+			return nil
+		},
+		deleteConversationFn: func(ctx context.Context, user string, conversation string) error {
+			return nil
+		},
+	}
+	routes := mounted(NewHandler(svc, testAdminKey))
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{http.MethodGet, "/chat/conversations", "", http.StatusOK},
+		{http.MethodGet, "/chat/conversations/web-1", "", http.StatusOK},
+		{http.MethodPatch, "/chat/conversations/web-1", `{"title":"Speed tiers"}`, http.StatusNoContent},
+		{http.MethodDelete, "/chat/conversations/web-1", "", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		for _, path := range []string{tt.path, tt.path + "/"} {
+			rec := httptest.NewRecorder()
+			routes.ServeHTTP(rec, httptest.NewRequest(tt.method, path+"?user=ash", strings.NewReader(tt.body)))
+			assert.Equal(t, tt.want, rec.Code, tt.method+" "+path)
+		}
+	}
+}
+
+func TestHandler_ListTurns(t *testing.T) {
+	after := uuid.Must(uuid.NewV7())
+	turn := &ChatTurn{
+		ID:              uuid.Must(uuid.NewV7()),
+		User:            "ash",
+		ConversationID:  "web-1",
+		Prompt:          "how fast is it?",
+		RewrittenPrompt: "How fast is Pikachu?",
+		Answer:          "Pikachu has 90 speed.",
+		ToolCalls:       []ToolCall{{Name: "getPokemon", Input: map[string]any{"id": "pikachu"}}},
+		Model:           "openai/gpt-oss-120b",
+		LatencyMS:       1830,
+	}
+	svc := &mockService{
+		listTurnsFn: func(ctx context.Context, filter TurnFilter) (*TurnPage, error) {
+			assert.Equal(t, TurnFilter{
+				User:           "ash",
+				ConversationID: "web-1",
+				CreatedAfter:   time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+				After:          after,
+				Limit:          10,
+			}, filter)
+			return &TurnPage{Turns: []*ChatTurn{turn}, Total: 1}, nil
+		},
+	}
+	h := NewHandler(svc, testAdminKey).RegisterRoutes()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, adminRequest(http.MethodGet,
+		"/turns/?user=ash&conversation_id=web-1&created_after=2026-10-01T00:00:00Z&after="+after.String()+"&limit=10"))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var page TurnPage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	assert.Equal(t, 1, page.Total)
+	require.Len(t, page.Turns, 1)
+	assert.Equal(t, "How fast is Pikachu?", page.Turns[0].RewrittenPrompt)
+	assert.Equal(t, "getPokemon", page.Turns[0].ToolCalls[0].Name)
+	assert.NotContains(t, rec.Body.String(), `"next"`)
+
+	for _, query := range []string{"created_before=yesterday", "after=1", "limit=0"} {
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, adminRequest(http.MethodGet, "/turns/?"+query))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestHandler_GetTurn(t *testing.T) {
+	id := uuid.Must(uuid.NewV7())
+	svc := &mockService{
+		getTurnFn: func(ctx context.Context, turnID uuid.UUID) (*ChatTurn, error) {
+			if turnID != id {
+				return nil, ErrTurnNotFound
+			}
+			return &ChatTurn{ID: id, Prompt: "hi", Rejected: true}, nil
+		},
+	}
+	h := NewHandler(svc, testAdminKey).RegisterRoutes()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, adminRequest(http.MethodGet, "/turns/"+id.String()+"/"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"rejected":true`)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, adminRequest(http.MethodGet, "/turns/"+uuid.NewString()+"/"))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, adminRequest(http.MethodGet, "/turns/nope/"))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandler_Turns_MountedUnderPrefix(t *testing.T) {
+	id := uuid.Must(uuid.NewV7())
+	svc := &mockService{
+		listTurnsFn: func(ctx context.Context, filter TurnFilter) (*TurnPage, error) {
+			return &TurnPage{Turns: []*ChatTurn{}}, nil
+		},
+		getTurnFn: func(ctx context.Context, turnID uuid.UUID) (*ChatTurn, error) {
+			return &ChatTurn{ID: turnID}, nil
+		},
+	}
+	routes := mounted(NewHandler(svc, testAdminKey))
+
+	for _, path := range []string{"/chat/turns", "/chat/turns/", "/chat/turns/" + id.String(), "/chat/turns/" + id.String() + "/"} {
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, adminRequest(http.MethodGet, path))
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+	}
+}
+
+func TestHandler_TurnsRequireAdminKey(t *testing.T) {
+	svc := &mockService{
+		listTurnsFn: func(ctx context.Context, filter TurnFilter) (*TurnPage, error) {
+			return &TurnPage{Turns: []*ChatTurn{}}, nil
+		},
+	}
+	h := NewHandler(svc, testAdminKey).RegisterRoutes()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, adminRequest(http.MethodGet, "/turns/"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	for _, header := range []string{"", "Bearer wrong", testAdminKey} {
+		req := httptest.NewRequest(http.MethodGet, "/turns/", nil)
+		req.Header.Set("Authorization", header)
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, header)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/turns/"+uuid.NewString()+"/", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	NewHandler(svc, "").RegisterRoutes().ServeHTTP(rec, adminRequest(http.MethodGet, "/turns/"))
+	assert.Equal(t, http.StatusForbidden, rec.Code, "without a configured key the endpoints are disabled")
+}
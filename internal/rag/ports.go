@@ -3,15 +3,26 @@ package rag
 import (
 	"context"
 
+	"cyrene/internal/platform/chatstore"
 	"cyrene/internal/platform/vectorstore"
 	"cyrene/internal/pokemon"
 
 	"github.com/firebase/genkit/go/ai"
+	"github.com/google/uuid"
 )
 
 type Service interface {
-	Chat(ctx context.Context, prompt string, user string) (string, error)
+	Chat(ctx context.Context, prompt string, user string, conversation string) (string, error)
+	ChatStream(ctx context.Context, prompt string, user string, conversation string, emit func(ChatEvent) error) (string, error)
+	Conversations(ctx context.Context, user string) ([]Conversation, error)
+	Conversation(ctx context.Context, user string, conversation string) (*ConversationDetail, error)
+	RenameConversation(ctx context.Context, user string, conversation string, title string) error
+	DeleteConversation(ctx context.Context, user string, conversation string) error
+	ListTurns(ctx context.Context, filter TurnFilter) (*TurnPage, error)
+	GetTurn(ctx context.Context, id uuid.UUID) (*ChatTurn, error)
 	Embed(ctx context.Context, dimensions int, texts ...string) ([][]float32, error)
+	EmbedModel() string
+	InvalidateReferences(ctx context.Context, references ...string) error
 }
 
 type pokemonService interface {
@@ -21,11 +32,25 @@ type pokemonService interface {
 type vectorStore interface {
 	Search(ctx context.Context, vector []float32, limit int, filter *vectorstore.Filter) ([]vectorstore.SearchResult, error)
 	Upsert(ctx context.Context, points ...vectorstore.Point) error
+	Delete(ctx context.Context, filter vectorstore.Filter) error
 	Dimensions() int
 }
 
 type chatStore interface {
-	Get(ctx context.Context, username string) ([]*ai.Message, error)
-	Append(ctx context.Context, username string, msgs ...*ai.Message) error
-	Clear(ctx context.Context, username string) error
+	Get(ctx context.Context, username string, conversation string) ([]*ai.Message, error)
+	Append(ctx context.Context, username string, conversation string, msgs ...*ai.Message) error
+	Summary(ctx context.Context, username string, conversation string) (string, error)
+	Compact(ctx context.Context, username string, conversation string, summary string, n int) error
+	List(ctx context.Context, username string) ([]chatstore.Conversation, error)
+	Conversation(ctx context.Context, username string, conversation string) (*chatstore.Conversation, error)
+	Rename(ctx context.Context, username string, conversation string, title string) error
+	Delete(ctx context.Context, username string, conversation string) error
+}
+
+// TurnRepository stores chat turn transcripts.
+type TurnRepository interface {
+	AddTurn(ctx context.Context, turn *ChatTurn) error
+	ListTurns(ctx context.Context, filter TurnFilter) ([]*ChatTurn, error)
+	CountTurns(ctx context.Context, filter TurnFilter) (int, error)
+	FindTurn(ctx context.Context, id uuid.UUID) (*ChatTurn, error)
 }
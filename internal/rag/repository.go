@@ -0,0 +1,176 @@
+package rag
+
+import (
+	"context"
+	"cyrene/internal/platform/postgres/jet/cyrene/public/model"
+	"cyrene/internal/platform/postgres/jet/cyrene/public/table"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+)
+
+type postgresTurnRepository struct {
+	db *sql.DB
+}
+
+func NewTurnRepository(conn *sql.DB) TurnRepository {
+	return &postgresTurnRepository{db: conn}
+}
+
+func (r *postgresTurnRepository) AddTurn(ctx context.Context, turn *ChatTurn) error {
+	calls, err := json.Marshal(turn.ToolCalls)
+	if err != nil {
+		return fmt.Errorf("marshal tool calls: %w", err)
+	}
+
+	stmt := table.ChatTurns.INSERT(
+		table.ChatTurns.ID,
+		table.ChatTurns.Username,
+		table.ChatTurns.ConversationID,
+		table.ChatTurns.Prompt,
+		table.ChatTurns.RewrittenPrompt,
+		table.ChatTurns.Answer,
+		table.ChatTurns.CacheHit,
+		table.ChatTurns.Rejected,
+		table.ChatTurns.ToolCalls,
+		table.ChatTurns.Model,
+		table.ChatTurns.LatencyMs,
+		table.ChatTurns.Error,
+		table.ChatTurns.CreatedAt,
+	).VALUES(
+		turn.ID,
+		turn.User,
+		turn.ConversationID,
+		turn.Prompt,
+		nullString(turn.RewrittenPrompt),
+		turn.Answer,
+		turn.CacheHit,
+		turn.Rejected,
+		string(calls),
+		nullString(turn.Model),
+		turn.LatencyMS,
+		nullString(turn.Error),
+		turn.CreatedAt,
+	)
+
+	if _, err := stmt.ExecContext(ctx, r.db); err != nil {
+		return fmt.Errorf("add chat turn: %w", err)
+	}
+	return nil
+}
+
+// ListTurns returns one page of the turns matching the filter, ordered by ID and
+// so by time.
+func (r *postgresTurnRepository) ListTurns(ctx context.Context, filter TurnFilter) ([]*ChatTurn, error) {
+	stmt := postgres.SELECT(table.ChatTurns.AllColumns).
+		FROM(table.ChatTurns).
+		WHERE(
+			turnCondition(filter).
+				AND(table.ChatTurns.ID.GT(postgres.UUID(filter.After))),
+		).
+		ORDER_BY(table.ChatTurns.ID.ASC()).
+		LIMIT(int64(filter.Limit))
+
+	var dest []model.ChatTurns
+	if err := stmt.QueryContext(ctx, r.db, &dest); err != nil {
+		return nil, fmt.Errorf("list chat turns: %w", err)
+	}
+
+	turns := make([]*ChatTurn, len(dest))
+	for i := range dest {
+		turn, err := toTurnDomain(&dest[i])
+		if err != nil {
+			return nil, err
+		}
+		turns[i] = turn
+	}
+	return turns, nil
+}
+
+// CountTurns returns how many turns match the filter, ignoring its paging fields.
+func (r *postgresTurnRepository) CountTurns(ctx context.Context, filter TurnFilter) (int, error) {
+	stmt := postgres.SELECT(postgres.COUNT(postgres.STAR).AS("count")).
+		FROM(table.ChatTurns).
+		WHERE(turnCondition(filter))
+
+	var dest struct {
+		Count int64
+	}
+	if err := stmt.QueryContext(ctx, r.db, &dest); err != nil {
+		return 0, fmt.Errorf("count chat turns: %w", err)
+	}
+	return int(dest.Count), nil
+}
+
+func (r *postgresTurnRepository) FindTurn(ctx context.Context, id uuid.UUID) (*ChatTurn, error) {
+	stmt := postgres.SELECT(table.ChatTurns.AllColumns).
+		FROM(table.ChatTurns).
+		WHERE(table.ChatTurns.ID.EQ(postgres.UUID(id)))
+
+	var dest model.ChatTurns
+	err := stmt.QueryContext(ctx, r.db, &dest)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, ErrTurnNotFound
+		}
+		return nil, fmt.Errorf("find chat turn: %w", err)
+	}
+	return toTurnDomain(&dest)
+}
+
+// turnCondition translates the user, conversation and time bounds of a TurnFilter.
+func turnCondition(filter TurnFilter) postgres.BoolExpression {
+	cond := postgres.Bool(true)
+	if filter.User != "" {
+		cond = cond.AND(table.ChatTurns.Username.EQ(postgres.String(filter.User)))
+	}
+	if filter.ConversationID != "" {
+		cond = cond.AND(table.ChatTurns.ConversationID.EQ(postgres.String(filter.ConversationID)))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		cond = cond.AND(table.ChatTurns.CreatedAt.GT(postgres.TimestampzT(filter.CreatedAfter)))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		cond = cond.AND(table.ChatTurns.CreatedAt.LT(postgres.TimestampzT(filter.CreatedBefore)))
+	}
+	return cond
+}
+
+func nullString(s string) postgres.Expression {
+	if s == "" {
+		return postgres.NULL
+	}
+	return postgres.String(s)
+}
+
+func toTurnDomain(m *model.ChatTurns) (*ChatTurn, error) {
+	turn := &ChatTurn{
+		ID:             m.ID,
+		User:           m.Username,
+		ConversationID: m.ConversationID,
+		Prompt:         m.Prompt,
+		Answer:         m.Answer,
+		CacheHit:       m.CacheHit,
+		Rejected:       m.Rejected,
+		LatencyMS:      int64(m.LatencyMs),
+		CreatedAt:      m.CreatedAt,
+	}
+	if m.RewrittenPrompt != nil {
+		turn.RewrittenPrompt = *m.RewrittenPrompt
+	}
+	if m.Model != nil {
+		turn.Model = *m.Model
+	}
+	if m.Error != nil {
+		turn.Error = *m.Error
+	}
+	if err := json.Unmarshal([]byte(m.ToolCalls), &turn.ToolCalls); err != nil {
+		return nil, fmt.Errorf("unmarshal tool calls of chat turn %s: %w", m.ID, err)
+	}
+	return turn, nil
+}
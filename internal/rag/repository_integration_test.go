@@ -0,0 +1,136 @@
+//go:build integration
+
+package rag
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testDB *sql.DB
+
+func TestMain(m *testing.M) {
+	connStr := fmt.Sprintf(
+		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		getEnv("DB_USERNAME", "cyrene"),
+		getEnv("DB_PASSWORD", "password1234"),
+		getEnv("DB_HOST", "localhost"),
+		getEnv("DB_PORT", "5432"),
+		getEnv("DB_DATABASE", "cyrene"),
+	)
+
+	var err error
+	testDB, err = sql.Open("pgx", connStr)
+	if err != nil {
+		fmt.Printf("Failed to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := testDB.Ping(); err != nil {
+		fmt.Printf("Failed to ping database: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+
+	testDB.Close()
+	os.Exit(code)
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+func cleanupTurns(t *testing.T, user string) {
+	t.Helper()
+	_, err := testDB.ExecContext(context.Background(), "DELETE FROM chat_turns WHERE username = $1", user)
+	if err != nil {
+		t.Logf("Warning: cleanup failed for %s: %v", user, err)
+	}
+}
+
+func TestTurnRepository_AddAndFind(t *testing.T) {
+	cleanupTurns(t, "test-user-turns")
+	defer cleanupTurns(t, "test-user-turns")
+
+	ctx := context.Background()
+	repo := NewTurnRepository(testDB)
+
+	turn := &ChatTurn{
+		ID:              uuid.Must(uuid.NewV7()),
+		User:            "test-user-turns",
+		ConversationID:  DefaultConversation,
+		Prompt:          "how fast is it?",
+		RewrittenPrompt: "How fast is Pikachu?",
+		Answer:          "Pikachu has 90 speed.",
+		ToolCalls:       []ToolCall{{Name: "getPokemon", Input: map[string]any{"id": "pikachu"}}},
+		Model:           "test/model",
+		LatencyMS:       1200,
+		CreatedAt:       time.Now().UTC().Truncate(time.Microsecond),
+	}
+	require.NoError(t, repo.AddTurn(ctx, turn))
+
+	found, err := repo.FindTurn(ctx, turn.ID)
+	require.NoError(t, err)
+	assert.Equal(t, turn.RewrittenPrompt, found.RewrittenPrompt)
+	assert.Equal(t, turn.ToolCalls, found.ToolCalls)
+	assert.Equal(t, turn.Model, found.Model)
+	assert.Equal(t, turn.LatencyMS, found.LatencyMS)
+	assert.True(t, turn.CreatedAt.Equal(found.CreatedAt))
+	assert.Empty(t, found.Error)
+
+	_, err = repo.FindTurn(ctx, uuid.Must(uuid.NewV7()))
+	assert.ErrorIs(t, err, ErrTurnNotFound)
+}
+
+func TestTurnRepository_ListTurns(t *testing.T) {
+	cleanupTurns(t, "test-user-list")
+	defer cleanupTurns(t, "test-user-list")
+
+	ctx := context.Background()
+	repo := NewTurnRepository(testDB)
+
+	var ids []uuid.UUID
+	for i, conversation := range []string{"web", "game", "web"} {
+		turn := &ChatTurn{
+			ID:             uuid.Must(uuid.NewV7()),
+			User:           "test-user-list",
+			ConversationID: conversation,
+			Prompt:         fmt.Sprintf("prompt %d", i),
+			CacheHit:       i == 2,
+			ToolCalls:      []ToolCall{},
+			CreatedAt:      time.Now(),
+		}
+		require.NoError(t, repo.AddTurn(ctx, turn))
+		ids = append(ids, turn.ID)
+	}
+
+	filter := TurnFilter{User: "test-user-list", ConversationID: "web", Limit: 1}
+	first, err := repo.ListTurns(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+	assert.Equal(t, ids[0], first[0].ID)
+
+	filter.After = first[0].ID
+	second, err := repo.ListTurns(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+	assert.Equal(t, ids[2], second[0].ID)
+	assert.True(t, second[0].CacheHit)
+
+	total, err := repo.CountTurns(ctx, TurnFilter{User: "test-user-list", ConversationID: "web"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+}
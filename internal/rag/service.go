@@ -2,11 +2,14 @@ package rag
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
+	"sync"
 	"time"
 
+	"cyrene/internal/platform/chatstore"
 	platformgenkit "cyrene/internal/platform/genkit"
 	"cyrene/internal/platform/vectorstore"
 
@@ -22,17 +25,29 @@ type service struct {
 	vectorStore vectorStore
 	cacheStore  vectorStore
 
+	// historyTokens is the token budget of the chat history sent with a prompt.
+	historyTokens int
+	turns         TurnRepository
+
+	// compacting holds the conversations being compacted, as conversationKey, so a
+	// conversation is only summarized by one goroutine at a time.
+	compacting sync.Map
+	// background tracks the compactions started after responding.
+	background sync.WaitGroup
+
 	getPokemonTool ai.Tool
 	searchTool     ai.Tool
 }
 
-func NewService(clients *platformgenkit.Clients, pokemon pokemonService, store vectorStore, cacheStore vectorStore, chatStore chatStore) Service {
+func NewService(clients *platformgenkit.Clients, pokemon pokemonService, store vectorStore, cacheStore vectorStore, chatStore chatStore, historyTokens int, turns TurnRepository) Service {
 	s := &service{
-		clients:     clients,
-		pokemon:     pokemon,
-		vectorStore: store,
-		cacheStore:  cacheStore,
-		chatStore:   chatStore,
+		clients:       clients,
+		pokemon:       pokemon,
+		vectorStore:   store,
+		cacheStore:    cacheStore,
+		chatStore:     chatStore,
+		historyTokens: historyTokens,
+		turns:         turns,
 	}
 	s.registerTools(clients.Genkit)
 	return s
@@ -62,7 +77,39 @@ func (s *service) Embed(ctx context.Context, dimensions int, texts ...string) ([
 	return embeddings, nil
 }
 
-func (s *service) Chat(ctx context.Context, prompt string, user string) (answer string, err error) {
+// EmbedModel returns the registry name of the embedder, e.g. "embed/qwen/qwen3-embedding-8b".
+func (s *service) EmbedModel() string {
+	return s.clients.Embedder.Name()
+}
+
+func (s *service) Chat(ctx context.Context, prompt string, user string, conversation string) (string, error) {
+	return s.chat(ctx, prompt, user, conversation, nil)
+}
+
+// ChatStream answers like Chat and passes the model's tokens and tool calls to
+// emit as they are generated. Answers from the cache or rejections are not
+// streamed; they are only returned. An error from emit aborts the generation.
+func (s *service) ChatStream(ctx context.Context, prompt string, user string, conversation string, emit func(ChatEvent) error) (string, error) {
+	return s.chat(ctx, prompt, user, conversation, emit)
+}
+
+func (s *service) chat(ctx context.Context, prompt string, user string, conversation string, emit func(ChatEvent) error) (answer string, err error) {
+	if conversation == "" {
+		conversation = DefaultConversation
+	}
+	if !ValidConversationID(conversation) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidConversation, conversation)
+	}
+
+	record := &ChatTurn{
+		ID:             uuid.Must(uuid.NewV7()),
+		User:           user,
+		ConversationID: conversation,
+		Prompt:         prompt,
+		CreatedAt:      time.Now(),
+	}
+	defer func() { s.recordTurn(ctx, record, answer, err) }()
+
 	defer func() {
 		if r := recover(); r != nil {
 			slog.Error("chat panic recovered", "panic", r)
@@ -70,12 +117,19 @@ func (s *service) Chat(ctx context.Context, prompt string, user string) (answer
 		}
 	}()
 
-	slog.Info("chat request", "prompt", prompt)
+	slog.Info("chat request", "prompt", prompt, "conversation", conversation)
 
-	chatHistory, err := s.chatStore.Get(ctx, user)
+	chatHistory, err := s.chatStore.Get(ctx, user, conversation)
 	if err != nil {
 		slog.Warn("Unable to retrieve chat history", "error", err)
 	}
+	summary, err := s.chatStore.Summary(ctx, user, conversation)
+	if err != nil {
+		slog.Warn("Unable to retrieve chat summary", "error", err)
+	}
+	// The summary rides in the system prompt and takes its share of the history budget.
+	system := systemPrompt + summarySection(summary)
+	chatHistory = trimHistory(chatHistory, s.historyTokens-estimateTextTokens(summarySection(summary)))
 
 	slog.Info("chat length", "length", len(chatHistory))
 
@@ -83,12 +137,14 @@ func (s *service) Chat(ctx context.Context, prompt string, user string) (answer
 	if err != nil {
 		return "", err
 	}
+	record.RewrittenPrompt = newPrompt.Prompt
 	if newPrompt.Rejected {
 		slog.Info("prompt rejected", "reason", newPrompt.Reason)
+		record.Rejected = true
 		return fmt.Sprintf("I am unable to answer you: %s", newPrompt.Reason), nil
 	}
 
-	if len(chatHistory) == 0 {
+	if len(chatHistory) == 0 && summary == "" {
 		prompt = newPrompt.Prompt
 	}
 
@@ -103,48 +159,260 @@ func (s *service) Chat(ctx context.Context, prompt string, user string) (answer
 
 	if cached, err := s.findCachedAnswer(ctx, prompt, embedding); err == nil && cached != nil {
 		slog.Info("cache hit", "cached_question", cached.Question)
-		if err := s.chatStore.Append(ctx, user,
+		record.CacheHit = true
+		s.appendHistory(ctx, user, conversation,
 			ai.NewUserTextMessage(prompt),
 			ai.NewModelTextMessage(cached.Answer),
-		); err != nil {
-			slog.Warn("failed to append chat history", "error", err)
-		}
+		)
 		return cached.Answer, nil
 	}
 	slog.Info("cache miss, calling LLM")
 
-	resp, err := genkit.Generate(ctx, s.clients.Genkit,
+	record.Model = s.clients.Model.Name()
+	genCtx, used := withUsedReferences(ctx)
+	opts := []ai.GenerateOption{
 		ai.WithModel(s.clients.Model),
-		ai.WithSystem(systemPrompt),
+		ai.WithSystem(system),
+		ai.WithMessages(chatHistory...),
 		ai.WithPrompt(prompt),
 		ai.WithTools(s.getPokemonTool, s.searchTool),
-	)
+	}
+	if emit != nil {
+		opts = append(opts, ai.WithStreaming(func(ctx context.Context, chunk *ai.ModelResponseChunk) error {
+			for _, event := range chunkEvents(chunk) {
+				if err := emit(event); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+	}
+	resp, err := genkit.Generate(genCtx, s.clients.Genkit, opts...)
 	if err != nil {
 		slog.Error("LLM generation failed", "error", err)
 		return "", err
 	}
 
 	answer = resp.Text()
-	if err := s.storeCachedAnswer(ctx, newPrompt.Prompt, embedding, answer); err != nil {
+	if err := s.storeCachedAnswer(ctx, newPrompt.Prompt, embedding, answer, used.list()); err != nil {
 		slog.Warn("failed to cache answer", "error", err)
 	} else {
 		slog.Info("cached answer stored")
 	}
 
-	if err := s.chatStore.Append(ctx, user,
-		ai.NewUserTextMessage(prompt),
-		ai.NewModelTextMessage(answer),
-	); err != nil {
-		slog.Warn("failed to append chat history", "error", err)
+	turn := turnMessages(resp, chatHistory)
+	record.ToolCalls = toolCalls(turn)
+	if len(turn) == 0 {
+		turn = []*ai.Message{ai.NewUserTextMessage(prompt), ai.NewModelTextMessage(answer)}
 	}
+	s.appendHistory(ctx, user, conversation, turn...)
 
 	return answer, nil
 }
 
+// conversationKey identifies a conversation of a user.
+type conversationKey struct {
+	user         string
+	conversation string
+}
+
+// appendHistory stores the messages of a turn and then compacts the conversation in
+// the background if it outgrew the history budget, so the answer is not held up by
+// the summary. Failures are only logged, since the prompt has been answered either way.
+func (s *service) appendHistory(ctx context.Context, user string, conversation string, msgs ...*ai.Message) {
+	if err := s.chatStore.Append(ctx, user, conversation, msgs...); err != nil {
+		slog.Warn("failed to append chat history", "error", err)
+		return
+	}
+	if s.historyTokens <= 0 {
+		return
+	}
+
+	key := conversationKey{user: user, conversation: conversation}
+	if _, running := s.compacting.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		defer s.compacting.Delete(key)
+		if err := s.compactHistory(ctx, user, conversation); err != nil {
+			slog.Warn("failed to summarize chat history", "error", err)
+		}
+	}()
+}
+
+// compactHistory folds the oldest turns of a conversation into its summary with the
+// fast model once the summary and messages exceed the history budget. The latest
+// turns that fit in half the budget are kept as they are, so compaction does not
+// run again on the next turn.
+func (s *service) compactHistory(ctx context.Context, user string, conversation string) error {
+	history, err := s.chatStore.Get(ctx, user, conversation)
+	if err != nil {
+		return fmt.Errorf("get chat history: %w", err)
+	}
+	summary, err := s.chatStore.Summary(ctx, user, conversation)
+	if err != nil {
+		return fmt.Errorf("get chat summary: %w", err)
+	}
+
+	tokens := estimateTextTokens(summarySection(summary))
+	for _, msg := range history {
+		tokens += estimateTokens(msg)
+	}
+	if tokens <= s.historyTokens {
+		return nil
+	}
+
+	kept := trimHistory(history, s.historyTokens/2)
+	old := history[:len(history)-len(kept)]
+	if len(old) == 0 {
+		return nil
+	}
+
+	if dialogue := transcript(old); dialogue != "" {
+		prompt := "New messages:\n" + dialogue
+		if summary != "" {
+			prompt = "Existing summary:\n" + summary + "\n\n" + prompt
+		}
+		summary, err = s.fastModelAsk(ctx, summaryPrompt, prompt)
+		if err != nil {
+			return fmt.Errorf("summarize chat history: %w", err)
+		}
+	}
+
+	err = s.chatStore.Compact(ctx, user, conversation, strings.TrimSpace(summary), len(old))
+	if errors.Is(err, chatstore.ErrNotFound) {
+		// The conversation was deleted or expired while it was being summarized.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("compact chat history: %w", err)
+	}
+	slog.Info("chat history summarized", "conversation", conversation, "messages", len(old), "kept", len(kept))
+	return nil
+}
+
+// recordTurn writes the transcript of a chat request, timing it from the record's
+// creation. A failure is only logged, so the user still gets their answer; the write
+// outlives a cancelled request.
+func (s *service) recordTurn(ctx context.Context, record *ChatTurn, answer string, err error) {
+	if s.turns == nil {
+		return
+	}
+
+	record.Answer = answer
+	record.LatencyMS = time.Since(record.CreatedAt).Milliseconds()
+	if record.ToolCalls == nil {
+		record.ToolCalls = []ToolCall{}
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	if err := s.turns.AddTurn(context.WithoutCancel(ctx), record); err != nil {
+		slog.Warn("failed to record chat turn", "error", err)
+	}
+}
+
+// ListTurns pages through recorded chat turns, oldest first.
+func (s *service) ListTurns(ctx context.Context, filter TurnFilter) (*TurnPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = turnPageSize
+	}
+	filter.Limit = min(filter.Limit, maxTurnPageSize)
+
+	turns, err := s.turns.ListTurns(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.turns.CountTurns(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &TurnPage{Turns: turns, Total: total}
+	if page.Turns == nil {
+		page.Turns = []*ChatTurn{}
+	}
+	if len(turns) == filter.Limit {
+		page.Next = turns[len(turns)-1].ID
+	}
+	return page, nil
+}
+
+func (s *service) GetTurn(ctx context.Context, id uuid.UUID) (*ChatTurn, error) {
+	return s.turns.FindTurn(ctx, id)
+}
+
+// Conversations lists the user's conversations, most recently updated first.
+func (s *service) Conversations(ctx context.Context, user string) ([]Conversation, error) {
+	stored, err := s.chatStore.List(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("list conversations: %w", err)
+	}
+	conversations := make([]Conversation, len(stored))
+	for i, c := range stored {
+		conversations[i] = toConversation(c)
+	}
+	return conversations, nil
+}
+
+// Conversation returns a conversation of the user with its messages.
+func (s *service) Conversation(ctx context.Context, user string, conversation string) (*ConversationDetail, error) {
+	stored, err := s.chatStore.Conversation(ctx, user, conversation)
+	if err != nil {
+		return nil, conversationError("get conversation", err)
+	}
+	history, err := s.chatStore.Get(ctx, user, conversation)
+	if err != nil {
+		return nil, fmt.Errorf("get conversation messages: %w", err)
+	}
+	return &ConversationDetail{
+		Conversation: toConversation(*stored),
+		Summary:      stored.Summary,
+		Messages:     conversationMessages(history),
+	}, nil
+}
+
+func (s *service) RenameConversation(ctx context.Context, user string, conversation string, title string) error {
+	if err := s.chatStore.Rename(ctx, user, conversation, title); err != nil {
+		return conversationError("rename conversation", err)
+	}
+	return nil
+}
+
+func (s *service) DeleteConversation(ctx context.Context, user string, conversation string) error {
+	if err := s.chatStore.Delete(ctx, user, conversation); err != nil {
+		return conversationError("delete conversation", err)
+	}
+	return nil
+}
+
+// conversationError wraps a chat store error, reporting a missing conversation as
+// ErrConversationNotFound.
+func conversationError(op string, err error) error {
+	if errors.Is(err, chatstore.ErrNotFound) {
+		return fmt.Errorf("%s: %w", op, ErrConversationNotFound)
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+func toConversation(c chatstore.Conversation) Conversation {
+	return Conversation{
+		ID:           c.ID,
+		Title:        c.Title,
+		MessageCount: c.MessageCount,
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+	}
+}
+
 func (s *service) findCachedAnswer(ctx context.Context, query string, embedding []float32) (*CachedAnswer, error) {
 	filter := &vectorstore.Filter{
 		StringFilters: []vectorstore.StringFilter{
-			{Field: "type", Value: payloadTypeCache},
+			{Field: payloadTypeKey, Value: payloadTypeCache},
 		},
 	}
 
@@ -211,20 +479,46 @@ func (s *service) findCachedAnswer(ctx context.Context, query string, embedding
 	}, nil
 }
 
-func (s *service) storeCachedAnswer(ctx context.Context, question string, embedding []float32, answer string) error {
+func (s *service) storeCachedAnswer(ctx context.Context, question string, embedding []float32, answer string, references []string) error {
+	refs := make([]any, len(references))
+	for i, ref := range references {
+		refs[i] = ref
+	}
+
 	point := vectorstore.Point{
 		ID:     uuid.New().String(),
 		Vector: embedding,
 		Payload: map[string]any{
-			"type":       payloadTypeCache,
-			"question":   question,
-			"answer":     answer,
-			"created_at": time.Now().Unix(),
+			payloadTypeKey:       payloadTypeCache,
+			"question":           question,
+			"answer":             answer,
+			"created_at":         time.Now().Unix(),
+			payloadReferencesKey: refs,
 		},
 	}
 	return s.cacheStore.Upsert(ctx, point)
 }
 
+// InvalidateReferences deletes every cached answer built from one of the given
+// document references.
+func (s *service) InvalidateReferences(ctx context.Context, references ...string) error {
+	if len(references) == 0 {
+		return nil
+	}
+
+	filter := vectorstore.Filter{}
+	for _, ref := range references {
+		filter.StringFilters = append(filter.StringFilters, vectorstore.StringFilter{
+			Field: payloadReferencesKey, Value: ref, Op: vectorstore.FilterOR,
+		})
+	}
+
+	if err := s.cacheStore.Delete(ctx, filter); err != nil {
+		return fmt.Errorf("invalidate cached answers: %w", err)
+	}
+	return nil
+}
+
 func (s *service) fastModelAsk(ctx context.Context, system string, prompt string) (string, error) {
 	resp, err := genkit.Generate(ctx, s.clients.Genkit,
 		ai.WithModel(s.clients.FastModel),
@@ -241,7 +535,7 @@ func (s *service) rewritePrompt(ctx context.Context, query string, chatHistory [
 	resp, _, err := genkit.GenerateData[rewriteResult](ctx, s.clients.Genkit,
 		ai.WithModel(s.clients.FastModel),
 		ai.WithSystem(rewritePrompt),
-		ai.WithMessages(chatHistory...),
+		ai.WithMessages(conversationText(chatHistory)...),
 		ai.WithPrompt(query),
 	)
 	if err != nil {
@@ -0,0 +1,440 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cyrene/internal/platform/chatstore"
+	platformgenkit "cyrene/internal/platform/genkit"
+	"cyrene/internal/platform/vectorstore"
+	"cyrene/internal/pokemon"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockChatStore struct {
+	chatStore
+	mu       sync.Mutex
+	messages []*ai.Message
+	summary  string
+	appended [][]*ai.Message
+}
+
+func (m *mockChatStore) Get(ctx context.Context, username string, conversation string) ([]*ai.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.messages), nil
+}
+
+func (m *mockChatStore) Append(ctx context.Context, username string, conversation string, msgs ...*ai.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msgs...)
+	m.appended = append(m.appended, msgs)
+	return nil
+}
+
+func (m *mockChatStore) Summary(ctx context.Context, username string, conversation string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.summary, nil
+}
+
+func (m *mockChatStore) Compact(ctx context.Context, username string, conversation string, summary string, n int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.summary = summary
+	m.messages = m.messages[n:]
+	return nil
+}
+
+func (m *mockChatStore) Conversation(ctx context.Context, username string, conversation string) (*chatstore.Conversation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &chatstore.Conversation{ID: conversation, Summary: m.summary, MessageCount: len(m.messages)}, nil
+}
+
+// newSummarizingService returns a service whose fast model answers with summary
+// and records the prompts it was given. When release is set, the model waits on it
+// before answering.
+func newSummarizingService(t *testing.T, store chatStore, historyTokens int, summary string, release <-chan struct{}) (*service, *[]string) {
+	t.Helper()
+	g := genkit.Init(context.Background())
+	var prompts []string
+	model := genkit.DefineModel(g, "test/fast", &ai.ModelOptions{Supports: &ai.ModelSupports{Multiturn: true, SystemRole: true}}, func(ctx context.Context, req *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		last := req.Messages[len(req.Messages)-1]
+		prompts = append(prompts, last.Text())
+		if release != nil {
+			<-release
+		}
+		return &ai.ModelResponse{Message: ai.NewModelTextMessage(summary)}, nil
+	})
+	return &service{
+		clients:       &platformgenkit.Clients{Genkit: g, FastModel: model},
+		chatStore:     store,
+		historyTokens: historyTokens,
+	}, &prompts
+}
+
+func chatTurn(prompt, answer string) []*ai.Message {
+	return []*ai.Message{ai.NewUserTextMessage(prompt), ai.NewModelTextMessage(answer)}
+}
+
+func TestAppendHistory_SummarizesOldestTurns(t *testing.T) {
+	long := strings.Repeat("x", 400)
+	store := &mockChatStore{summary: "The player is building a team around Pikachu."}
+	store.messages = append(store.messages, chatTurn("I want to catch a Gyarados", "Gyarados evolves from Magikarp. "+long)...)
+	store.messages = append(store.messages, chatTurn("where does magikarp spawn?", "Magikarp spawns in rivers. "+long)...)
+	svc, prompts := newSummarizingService(t, store, 200, " The player is hunting Gyarados for a Pikachu team. ", nil)
+
+	latest := chatTurn("what level does it evolve?", "Magikarp evolves at level 20.")
+	svc.appendHistory(context.Background(), "ash", DefaultConversation, latest...)
+	svc.background.Wait()
+
+	assert.Equal(t, "The player is hunting Gyarados for a Pikachu team.", store.summary)
+	assert.Equal(t, latest, store.messages, "the latest turn is kept verbatim")
+
+	require.Len(t, *prompts, 1)
+	assert.Contains(t, (*prompts)[0], "Existing summary:\nThe player is building a team around Pikachu.")
+	assert.Contains(t, (*prompts)[0], "Player: I want to catch a Gyarados\n")
+	assert.Contains(t, (*prompts)[0], "Cyrene: Magikarp spawns in rivers.")
+	assert.NotContains(t, (*prompts)[0], "what level does it evolve?")
+}
+
+func TestAppendHistory_WithinBudget(t *testing.T) {
+	store := &mockChatStore{}
+	svc, prompts := newSummarizingService(t, store, 4000, "unused", nil)
+
+	svc.appendHistory(context.Background(), "ash", DefaultConversation, chatTurn("hi", "hello")...)
+	svc.background.Wait()
+	svc.appendHistory(context.Background(), "ash", DefaultConversation, chatTurn("how fast is pikachu?", "90 speed.")...)
+	svc.background.Wait()
+
+	assert.Len(t, store.messages, 4)
+	assert.Empty(t, store.summary)
+	assert.Empty(t, *prompts)
+}
+
+func TestAppendHistory_NoBudget(t *testing.T) {
+	store := &mockChatStore{}
+	svc, prompts := newSummarizingService(t, store, 0, "unused", nil)
+
+	svc.appendHistory(context.Background(), "ash", DefaultConversation, chatTurn("hi", strings.Repeat("x", 400))...)
+	svc.background.Wait()
+
+	assert.Len(t, store.messages, 2)
+	assert.Empty(t, *prompts)
+}
+
+func TestAppendHistory_CompactsInBackgroundOncePerConversation(t *testing.T) {
+	long := strings.Repeat("x", 400)
+	store := &mockChatStore{}
+	store.messages = append(store.messages, chatTurn("I want to catch a Gyarados", long)...)
+	store.messages = append(store.messages, chatTurn("where does magikarp spawn?", long)...)
+	release := make(chan struct{})
+	svc, prompts := newSummarizingService(t, store, 200, "The player is hunting Gyarados.", release)
+
+	// Both turns return while the first compaction is still waiting on the model.
+	svc.appendHistory(context.Background(), "ash", DefaultConversation, chatTurn("what level does it evolve?", "Level 20.")...)
+	svc.appendHistory(context.Background(), "ash", DefaultConversation, chatTurn("and its moves?", "Splash.")...)
+	close(release)
+	svc.background.Wait()
+
+	assert.Len(t, *prompts, 1, "a conversation is compacted by one goroutine at a time")
+	assert.Equal(t, "The player is hunting Gyarados.", store.summary)
+	assert.Len(t, store.messages, 4, "turns appended during compaction are kept")
+}
+
+func TestConversation_IncludesSummary(t *testing.T) {
+	store := &mockChatStore{summary: "The player is hunting Gyarados.", messages: chatTurn("what level?", "Level 20.")}
+	svc := &service{chatStore: store}
+
+	detail, err := svc.Conversation(context.Background(), "ash", DefaultConversation)
+	require.NoError(t, err)
+	assert.Equal(t, "The player is hunting Gyarados.", detail.Summary)
+	assert.Len(t, detail.Messages, 2)
+}
+
+type mockTurnRepository struct {
+	TurnRepository
+	mu    sync.Mutex
+	turns []*ChatTurn
+}
+
+func (m *mockTurnRepository) AddTurn(ctx context.Context, turn *ChatTurn) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.turns = append(m.turns, turn)
+	return nil
+}
+
+type mockVectorStore struct {
+	vectorStore
+	results  []vectorstore.SearchResult
+	upserted []vectorstore.Point
+}
+
+func (m *mockVectorStore) Search(ctx context.Context, vector []float32, limit int, filter *vectorstore.Filter) ([]vectorstore.SearchResult, error) {
+	return m.results, nil
+}
+
+func (m *mockVectorStore) Upsert(ctx context.Context, points ...vectorstore.Point) error {
+	m.upserted = append(m.upserted, points...)
+	return nil
+}
+
+func (m *mockVectorStore) Delete(ctx context.Context, filter vectorstore.Filter) error {
+	m.upserted = slices.DeleteFunc(m.upserted, func(p vectorstore.Point) bool {
+		refs, _ := p.Payload[payloadReferencesKey].([]any)
+		for _, f := range filter.StringFilters {
+			if slices.Contains(refs, any(f.Value)) {
+				return true
+			}
+		}
+		return false
+	})
+	return nil
+}
+
+func (m *mockVectorStore) Dimensions() int {
+	return 3
+}
+
+type mockPokemonService struct{}
+
+func (m *mockPokemonService) GetPokemonByID(ctx context.Context, id string) (*pokemon.Pokemon, error) {
+	return &pokemon.Pokemon{ID: id, Identifier: "pikachu", Metadata: map[string]any{
+		"id":        float64(25),
+		"types":     []any{map[string]any{"type": map[string]any{"name": "electric"}}},
+		"abilities": []any{map[string]any{"ability": map[string]any{"name": "static"}}},
+		"stats":     []any{map[string]any{"stat": map[string]any{"name": "speed"}, "base_stat": float64(90)}},
+		"moves":     []any{map[string]any{"move": map[string]any{"name": "thunderbolt"}}},
+	}}, nil
+}
+
+// newChatService returns a service on fake models: the fast model rewrites every
+// prompt to rewrite and the agent model answers with model.
+func newChatService(t *testing.T, store chatStore, cache vectorStore, turns TurnRepository, historyTokens int, rewrite rewriteResult, model ai.ModelFunc) *service {
+	t.Helper()
+	g := genkit.Init(context.Background())
+	supports := &ai.ModelSupports{Multiturn: true, SystemRole: true, Tools: true}
+
+	data, err := json.Marshal(rewrite)
+	require.NoError(t, err)
+	fast := genkit.DefineModel(g, "test/fast", &ai.ModelOptions{Supports: supports}, func(ctx context.Context, req *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		return &ai.ModelResponse{Message: ai.NewModelTextMessage(string(data))}, nil
+	})
+	agent := genkit.DefineModel(g, "test/agent", &ai.ModelOptions{Supports: supports}, model)
+	embedder := ai.NewEmbedder("test/embed", nil, func(ctx context.Context, req *ai.EmbedRequest) (*ai.EmbedResponse, error) {
+		resp := &ai.EmbedResponse{}
+		for range req.Input {
+			resp.Embeddings = append(resp.Embeddings, &ai.Embedding{Embedding: []float32{0.1, 0.2, 0.3}})
+		}
+		return resp, nil
+	})
+
+	clients := &platformgenkit.Clients{Genkit: g, Embedder: embedder, Model: agent, FastModel: fast}
+	return NewService(clients, &mockPokemonService{}, cache, cache, store, historyTokens, turns).(*service)
+}
+
+// answerWith is an agent model that answers every request with text. Like the
+// OpenAI-compatible models, it returns the request with the response.
+func answerWith(text string) ai.ModelFunc {
+	return func(ctx context.Context, req *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		return &ai.ModelResponse{Request: req, Message: ai.NewModelTextMessage(text)}, nil
+	}
+}
+
+// assertTurnTiming checks that the turn's ID was created with the turn, so IDs
+// order turns by when the request started.
+func assertTurnTiming(t *testing.T, turn *ChatTurn, start time.Time) {
+	t.Helper()
+	require.Equal(t, uuid.Version(7), turn.ID.Version())
+	sec, nsec := turn.ID.Time().UnixTime()
+	assert.WithinDuration(t, turn.CreatedAt, time.Unix(sec, nsec), time.Millisecond)
+	assert.False(t, turn.CreatedAt.Before(start.Truncate(time.Millisecond)))
+	assert.GreaterOrEqual(t, turn.LatencyMS, int64(0))
+}
+
+func TestChat_RecordsCacheHit(t *testing.T) {
+	cache := &mockVectorStore{results: []vectorstore.SearchResult{{
+		Score:   0.99,
+		Payload: map[string]any{"question": "How fast is Pikachu?", "answer": "Pikachu has 90 speed."},
+	}}}
+	turns := &mockTurnRepository{}
+	svc := newChatService(t, &mockChatStore{}, cache, turns, 4000,
+		rewriteResult{Prompt: "How fast is Pikachu?"}, answerWith("unused"))
+
+	start := time.Now()
+	answer, err := svc.Chat(context.Background(), "how fast is pikachu?", "ash", DefaultConversation)
+	require.NoError(t, err)
+	assert.Equal(t, "Pikachu has 90 speed.", answer)
+	svc.background.Wait()
+
+	require.Len(t, turns.turns, 1)
+	turn := turns.turns[0]
+	assertTurnTiming(t, turn, start)
+	assert.True(t, turn.CacheHit)
+	assert.False(t, turn.Rejected)
+	assert.Equal(t, "ash", turn.User)
+	assert.Equal(t, "How fast is Pikachu?", turn.RewrittenPrompt)
+	assert.Equal(t, "Pikachu has 90 speed.", turn.Answer)
+	assert.Empty(t, turn.Model, "the model is not called for a cached answer")
+	assert.Empty(t, turn.Error)
+}
+
+func TestChat_RecordsRejectedPrompt(t *testing.T) {
+	turns := &mockTurnRepository{}
+	svc := newChatService(t, &mockChatStore{}, &mockVectorStore{}, turns, 4000,
+		rewriteResult{Rejected: true, Reason: "not about Pokemon"}, answerWith("unused"))
+
+	start := time.Now()
+	answer, err := svc.Chat(context.Background(), "what is the capital of France?", "ash", "web-1")
+	require.NoError(t, err)
+
+	require.Len(t, turns.turns, 1)
+	turn := turns.turns[0]
+	assertTurnTiming(t, turn, start)
+	assert.True(t, turn.Rejected)
+	assert.False(t, turn.CacheHit)
+	assert.Equal(t, "web-1", turn.ConversationID)
+	assert.Equal(t, answer, turn.Answer)
+	assert.Equal(t, []ToolCall{}, turn.ToolCalls)
+	assert.Empty(t, turn.Error)
+}
+
+func TestChat_RecordsFailedGeneration(t *testing.T) {
+	turns := &mockTurnRepository{}
+	svc := newChatService(t, &mockChatStore{}, &mockVectorStore{}, turns, 4000,
+		rewriteResult{Prompt: "How fast is Pikachu?"},
+		func(ctx context.Context, req *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			return nil, errors.New("model unavailable")
+		})
+
+	start := time.Now()
+	_, err := svc.Chat(context.Background(), "how fast is pikachu?", "ash", DefaultConversation)
+	require.Error(t, err)
+
+	require.Len(t, turns.turns, 1)
+	turn := turns.turns[0]
+	assertTurnTiming(t, turn, start)
+	assert.Equal(t, "test/agent", turn.Model)
+	assert.Empty(t, turn.Answer)
+	assert.Contains(t, turn.Error, "model unavailable")
+}
+
+func TestChat_SendsTrimmedHistoryAndStoresToolMessages(t *testing.T) {
+	store := &mockChatStore{}
+	store.messages = append(store.messages, chatTurn("tell me about gyarados", strings.Repeat("x", 400))...)
+	store.messages = append(store.messages, chatTurn("I like pikachu", "Great choice.")...)
+
+	var requests [][]*ai.Message
+	model := func(ctx context.Context, req *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		requests = append(requests, req.Messages)
+		if len(requests) == 1 {
+			return &ai.ModelResponse{Request: req, Message: ai.NewModelMessage(
+				ai.NewToolRequestPart(&ai.ToolRequest{Name: "getPokemon", Input: map[string]any{"id": "pikachu"}}),
+			)}, nil
+		}
+		return &ai.ModelResponse{Request: req, Message: ai.NewModelTextMessage("Pikachu has 90 speed.")}, nil
+	}
+	turns := &mockTurnRepository{}
+	svc := newChatService(t, store, &mockVectorStore{}, turns, 50, rewriteResult{Prompt: "How fast is Pikachu?"}, model)
+
+	answer, err := svc.Chat(context.Background(), "how fast is it?", "ash", DefaultConversation)
+	require.NoError(t, err)
+	assert.Equal(t, "Pikachu has 90 speed.", answer)
+	svc.background.Wait()
+
+	require.Len(t, requests, 2, "one request for the tool call and one for the answer")
+	var sent []string
+	for _, msg := range requests[0] {
+		if msg.Role != ai.RoleSystem {
+			sent = append(sent, msg.Text())
+		}
+	}
+	assert.Equal(t, []string{"I like pikachu", "Great choice.", "how fast is it?"}, sent,
+		"the oldest turn does not fit the history budget")
+
+	require.Len(t, store.appended, 1)
+	stored := store.appended[0]
+	require.Len(t, stored, 4)
+	assert.Equal(t, "how fast is it?", stored[0].Text())
+	require.True(t, stored[1].Content[0].IsToolRequest())
+	assert.Equal(t, "getPokemon", stored[1].Content[0].ToolRequest.Name)
+	require.Equal(t, ai.RoleTool, stored[2].Role)
+	require.True(t, stored[2].Content[0].IsToolResponse())
+	assert.Equal(t, "getPokemon", stored[2].Content[0].ToolResponse.Name)
+	assert.Equal(t, "Pikachu has 90 speed.", stored[3].Text())
+
+	require.Len(t, turns.turns, 1)
+	assert.Equal(t, []ToolCall{{Name: "getPokemon", Input: map[string]any{"id": "pikachu"}}}, turns.turns[0].ToolCalls)
+}
+
+func TestChat_InvalidatesAnswerFromPokemonLookedUpByName(t *testing.T) {
+	var calls int
+	model := func(ctx context.Context, req *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		calls++
+		if calls == 1 {
+			return &ai.ModelResponse{Request: req, Message: ai.NewModelMessage(
+				ai.NewToolRequestPart(&ai.ToolRequest{Name: "getPokemon", Input: map[string]any{"id": "pikachu"}}),
+			)}, nil
+		}
+		return &ai.ModelResponse{Request: req, Message: ai.NewModelTextMessage("Pikachu has 90 speed.")}, nil
+	}
+	cache := &mockVectorStore{}
+	svc := newChatService(t, &mockChatStore{}, cache, &mockTurnRepository{}, 4000, rewriteResult{Prompt: "How fast is Pikachu?"}, model)
+
+	_, err := svc.Chat(context.Background(), "how fast is pikachu?", "ash", DefaultConversation)
+	require.NoError(t, err)
+	svc.background.Wait()
+
+	require.Len(t, cache.upserted, 1)
+	assert.Equal(t, []any{"pokemon_25"}, cache.upserted[0].Payload[payloadReferencesKey],
+		"the reference is built from the numeric ID, as the document was ingested")
+
+	require.NoError(t, svc.InvalidateReferences(context.Background(), "pokemon_25"))
+	assert.Empty(t, cache.upserted)
+}
+
+func TestChat_SendsSummaryInSystemPrompt(t *testing.T) {
+	store := &mockChatStore{summary: "The player is hunting Gyarados."}
+	store.messages = append(store.messages, chatTurn("tell me about gyarados", strings.Repeat("x", 60))...)
+	store.messages = append(store.messages, chatTurn("I like pikachu", "Great choice.")...)
+
+	var request *ai.ModelRequest
+	model := func(ctx context.Context, req *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		request = req
+		return &ai.ModelResponse{Request: req, Message: ai.NewModelTextMessage("Pikachu has 90 speed.")}, nil
+	}
+	svc := newChatService(t, store, &mockVectorStore{}, &mockTurnRepository{}, 40, rewriteResult{Prompt: "How fast is Pikachu?"}, model)
+
+	_, err := svc.Chat(context.Background(), "how fast is it?", "ash", DefaultConversation)
+	require.NoError(t, err)
+	svc.background.Wait()
+
+	require.NotNil(t, request)
+	var system, sent []string
+	for _, msg := range request.Messages {
+		if msg.Role == ai.RoleSystem {
+			system = append(system, msg.Text())
+		} else {
+			sent = append(sent, msg.Text())
+		}
+	}
+	require.Len(t, system, 1, "the summary is part of the single system prompt")
+	assert.True(t, strings.HasPrefix(system[0], systemPrompt))
+	assert.Contains(t, system[0], "The player is hunting Gyarados.")
+	assert.Equal(t, []string{"I like pikachu", "Great choice.", "how fast is it?"}, sent,
+		"the summary takes its share of the history budget")
+}
@@ -1,6 +1,9 @@
 package rag
 
 import (
+	"strconv"
+
+	"cyrene/internal/ingest"
 	"cyrene/internal/platform/vectorstore"
 
 	"github.com/firebase/genkit/go/ai"
@@ -36,7 +39,12 @@ func (s *service) defineGetPokemonTool(g *genkit.Genkit) ai.Tool {
 			if err != nil {
 				return nil, err
 			}
-			return toToolResponse(p.Metadata, p.Identifier), nil
+			resp := toToolResponse(p.Metadata, p.Identifier)
+			// The input may be a name; documents are ingested under the numeric ID.
+			if resp.ID > 0 {
+				addUsedReferences(ctx, ingest.NewDocumentID(ingest.DocumentTypePokemon, strconv.Itoa(resp.ID)))
+			}
+			return resp, nil
 		},
 	)
 }
@@ -117,16 +125,41 @@ func (s *service) defineVectorSearchTool(g *genkit.Genkit) ai.Tool {
 	return genkit.DefineTool(
 		g,
 		"searchPokemon",
-		"Searches the Pokemon database using semantic similarity. Use for exploratory queries like finding Pokemon by type, abilities, characteristics, or conceptual similarities (e.g. 'fast electric Pokemon', 'tanky water types', 'Pokemon that can learn fire moves'). Returns ranked results with relevance scores.",
+		"Searches the Pokemon database using semantic similarity. Use for exploratory queries like finding Pokemon by type, abilities, characteristics, or conceptual similarities (e.g. 'fast electric Pokemon', 'tanky water types', 'Pokemon that can learn fire moves'). The database also holds moves, abilities, held items, type matchups, evolution chains, and the server's Cobblemon species and spawn locations; set type to restrict results to one kind. Returns ranked results with relevance scores, one per document, each with its best matching section as content.",
 		func(ctx *ai.ToolContext, input struct {
 			Query string `json:"query" jsonschema_description:"Natural language search query describing the Pokemon you're looking for"`
 			Limit int    `json:"limit" jsonschema_description:"Max results to return (default 5)"`
+			Type  string `json:"type,omitempty" jsonschema_description:"Optional kind of document to search: pokemon, move, ability, item, type, evolution_chain, cobblemon_species or spawn"`
 		}) ([]vectorstore.SearchResult, error) {
 			embeddings, err := s.Embed(ctx, s.vectorStore.Dimensions(), input.Query)
 			if err != nil {
 				return nil, err
 			}
-			return s.vectorStore.Search(ctx, embeddings[0], input.Limit, nil)
+
+			var filter *vectorstore.Filter
+			if input.Type != "" {
+				filter = &vectorstore.Filter{
+					StringFilters: []vectorstore.StringFilter{
+						{Field: payloadTypeKey, Value: input.Type, Op: vectorstore.FilterAND},
+					},
+				}
+			}
+
+			limit := input.Limit
+			if limit <= 0 {
+				limit = searchDefaultLimit
+			}
+			results, err := s.vectorStore.Search(ctx, embeddings[0], limit*searchOverfetch, filter)
+			if err != nil {
+				return nil, err
+			}
+			results = dedupeByReference(results, limit)
+			for _, r := range results {
+				if ref, ok := r.Payload[payloadReferenceKey].(string); ok {
+					addUsedReferences(ctx, ref)
+				}
+			}
+			return results, nil
 		},
 	)
 }
@@ -0,0 +1,17 @@
+-- +goose up
+create table ingestion_jobs (
+    id              uuid primary key,
+    job_id          uuid not null,
+    document_type   text not null,
+    external_id     text not null,
+    status          text not null,
+    error           text,
+    created_at      timestamptz not null default now(),
+    updated_at      timestamptz not null default now()
+);
+
+create index idx_ingestion_jobs_job_id on ingestion_jobs(job_id);
+create unique index idx_ingestion_jobs_document on ingestion_jobs(job_id, document_type, external_id);
+
+-- +goose down
+drop table ingestion_jobs;
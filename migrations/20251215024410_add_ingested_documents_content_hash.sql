@@ -0,0 +1,11 @@
+-- +goose up
+alter table ingested_documents
+    add column content_hash     text    not null default '',
+    add column embedding_model  text    not null default '',
+    add column embedding_dim    integer not null default 0;
+
+-- +goose down
+alter table ingested_documents
+    drop column content_hash,
+    drop column embedding_model,
+    drop column embedding_dim;
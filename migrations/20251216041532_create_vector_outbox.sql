@@ -0,0 +1,19 @@
+-- +goose up
+create table vector_outbox (
+    id              uuid primary key,
+    reference       text not null,
+    operation       text not null,
+    points          jsonb not null default '[]',
+    status          text not null,
+    attempts        integer not null default 0,
+    last_error      text,
+    next_attempt_at timestamptz not null default now(),
+    created_at      timestamptz not null default now(),
+    updated_at      timestamptz not null default now()
+);
+
+create index idx_vector_outbox_pending on vector_outbox(next_attempt_at) where status = 'pending';
+create index idx_vector_outbox_reference on vector_outbox(reference) where status = 'pending';
+
+-- +goose down
+drop table vector_outbox;
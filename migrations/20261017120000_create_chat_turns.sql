@@ -0,0 +1,22 @@
+-- +goose up
+create table chat_turns (
+    id               uuid primary key,
+    username         text not null,
+    conversation_id  text not null,
+    prompt           text not null,
+    rewritten_prompt text,
+    answer           text not null default '',
+    cache_hit        boolean not null default false,
+    rejected         boolean not null default false,
+    tool_calls       jsonb not null default '[]',
+    model            text,
+    latency_ms       integer not null,
+    error            text,
+    created_at       timestamptz not null default now()
+);
+
+create index idx_chat_turns_conversation on chat_turns(username, conversation_id, created_at);
+create index idx_chat_turns_created_at on chat_turns(created_at);
+
+-- +goose down
+drop table chat_turns;
@@ -0,0 +1,5 @@
+-- +goose up
+create index idx_vector_outbox_updated on vector_outbox(status, updated_at) where status <> 'pending';
+
+-- +goose down
+drop index idx_vector_outbox_updated;
@@ -0,0 +1,7 @@
+-- +goose up
+alter table ingested_documents
+    add column content text not null default '';
+
+-- +goose down
+alter table ingested_documents
+    drop column content;